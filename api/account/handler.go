@@ -0,0 +1,144 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+)
+
+var (
+	statisticKeys = []string{
+		"active_session",
+		"active_subscription",
+		"deposit_add",
+		"deposit_subtract",
+		"end_session",
+		"end_subscription",
+		"granted_bytes",
+		"session_bandwidth",
+		"session_duration",
+		"session_node",
+		"session_payment",
+		"start_session",
+		"start_subscription",
+		"subscription_deposit",
+		"subscription_payment",
+		"utilised_bytes",
+	}
+)
+
+func HandlerGetAccountStatistics(db *mongo.Database, timezones types.Timezones) gin.HandlerFunc {
+	requestHandlers := map[string]func(db *mongo.Database, req *RequestGetAccountStatistics) ([]bson.M, error){
+		"": handleHistorical,
+	}
+
+	return func(c *gin.Context) {
+		req, err := NewRequestGetAccountStatistics(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		hFunc, ok := requestHandlers[req.Query.Method]
+		if !ok {
+			err := fmt.Errorf("unknown method %s", req.Query.Method)
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		result, err := hFunc(db, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
+
+func handleHistorical(db *mongo.Database, req *RequestGetAccountStatistics) ([]bson.M, error) {
+	days := types.RollingTimeframeDays(req.Query.Timeframe)
+	if days != 0 {
+		return handleHistoricalRolling(db, days, req)
+	}
+
+	filter := bson.M{
+		"acc_addr":  req.URI.AccAddr,
+		"timeframe": req.Query.Timeframe,
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp,
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"acc_addr":  1,
+		"timeframe": 1,
+		"timestamp": 1,
+	}
+	for _, key := range statisticKeys {
+		projection[key] = 1
+	}
+
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(req.Sort).
+		SetSkip(req.Query.Skip).
+		SetLimit(req.Query.Limit)
+
+	result, err := database.AccountStatisticFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func handleHistoricalRolling(db *mongo.Database, days int, req *RequestGetAccountStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"acc_addr":  req.URI.AccAddr,
+		"timeframe": types.BucketTimeframe(req.Query.Timeframe),
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp.AddDate(0, 0, 1-days),
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"timestamp": 1,
+	}
+	for _, key := range statisticKeys {
+		projection[key] = 1
+	}
+
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "timestamp", Value: 1},
+		})
+
+	items, err := database.AccountStatisticFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := types.NewRollingStatistics(items, days, req.Query.FromTimestamp, req.Query.ToTimestamp, req.Location, statisticKeys...)
+	for i := 0; i < len(result); i++ {
+		result[i]["acc_addr"] = req.URI.AccAddr
+		result[i]["timeframe"] = req.Query.Timeframe
+	}
+
+	types.SortStatistics(result, req.Sort)
+
+	return types.PaginateStatistics(result, req.Query.Skip, req.Query.Limit), nil
+}
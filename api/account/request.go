@@ -0,0 +1,59 @@
+package account
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/sentinel-official/explorer/types"
+)
+
+type RequestGetAccountStatistics struct {
+	Sort     bson.D
+	Location *time.Location
+
+	Query struct {
+		FromTimestamp time.Time `form:"from_timestamp"`
+		Limit         int64     `form:"limit,default=30" binding:"gte=0,lte=100"`
+		Method        string    `form:"method"`
+		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
+		Sort          string    `form:"sort"`
+		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=hour day week month year 7d 30d"`
+		Timezone      string    `form:"tz"`
+		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+	}
+	URI struct {
+		AccAddr string `uri:"acc_addr"`
+	}
+}
+
+func NewRequestGetAccountStatistics(c *gin.Context, timezones types.Timezones) (req *RequestGetAccountStatistics, err error) {
+	req = &RequestGetAccountStatistics{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	req.Location, err = timezones.TimeframeLocation(req.Query.Timeframe, req.Query.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+
+	vFunc, ok := validators[req.Query.Method]
+	if !ok {
+		return req, nil
+	}
+	if vFunc == nil {
+		return req, nil
+	}
+
+	if err := vFunc(req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
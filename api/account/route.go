@@ -0,0 +1,12 @@
+package account
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/sentinel-official/explorer/types"
+)
+
+func RegisterRoutes(router gin.IRouter, db *mongo.Database, timezones types.Timezones) {
+	router.GET("/accounts/:acc_addr/statistics", HandlerGetAccountStatistics(db, timezones))
+}
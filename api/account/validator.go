@@ -0,0 +1,23 @@
+package account
+
+import (
+	"github.com/sentinel-official/explorer/utils"
+)
+
+var (
+	validators = map[string]func(req *RequestGetAccountStatistics) error{
+		"": validateHistorical,
+	}
+)
+
+func validateHistorical(req *RequestGetAccountStatistics) (err error) {
+	allowed := []string{
+		"-timestamp",
+		"timestamp",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return err
+	}
+
+	return nil
+}
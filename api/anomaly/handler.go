@@ -0,0 +1,63 @@
+package anomaly
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+)
+
+func HandlerGetAnomalies(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetAnomalies(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"timestamp": bson.M{
+				"$gte": req.Query.FromTimestamp,
+				"$lt":  req.Query.ToTimestamp,
+			},
+		}
+		if req.Query.Addr != "" {
+			filter["addr"] = req.Query.Addr
+		}
+		if req.Query.Direction != "" {
+			filter["direction"] = req.Query.Direction
+		}
+		if req.Query.Severity != "" {
+			filter["severity"] = req.Query.Severity
+		}
+		if req.Query.Source != "" {
+			filter["source"] = req.Query.Source
+		}
+		if req.Query.Type != "" {
+			filter["type"] = req.Query.Type
+		}
+
+		projection := bson.M{
+			"_id": 0,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(req.Sort).
+			SetSkip(req.Query.Skip).
+			SetLimit(req.Query.Limit)
+
+		items, err := database.AnomalyFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(items))
+	}
+}
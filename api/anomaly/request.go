@@ -0,0 +1,48 @@
+package anomaly
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type RequestGetAnomalies struct {
+	Sort bson.D
+
+	Query struct {
+		Addr          string    `form:"addr"`
+		Direction     string    `form:"direction" binding:"omitempty,oneof=drop spike"`
+		FromTimestamp time.Time `form:"from_timestamp"`
+		Limit         int64     `form:"limit,default=25" binding:"gte=0,lte=100"`
+		Severity      string    `form:"severity" binding:"omitempty,oneof=low medium high"`
+		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
+		Sort          string    `form:"sort,default=-timestamp"`
+		Source        string    `form:"source" binding:"omitempty,oneof=statistics node_statistics"`
+		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+		Type          string    `form:"type"`
+	}
+}
+
+func NewRequestGetAnomalies(c *gin.Context) (req *RequestGetAnomalies, err error) {
+	req = &RequestGetAnomalies{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"-detected_at",
+		"-score",
+		"-timestamp",
+		"detected_at",
+		"score",
+		"timestamp",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
@@ -0,0 +1,10 @@
+package anomaly
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func RegisterRoutes(router gin.IRouter, db *mongo.Database) {
+	router.GET("/anomalies", HandlerGetAnomalies(db))
+}
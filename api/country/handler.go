@@ -0,0 +1,200 @@
+package country
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	hubtypes "github.com/sentinel-official/hub/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+func HandlerGetCountry(db *mongo.Database, timezones types.Timezones) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetCountry(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"$or": bson.A{
+				bson.M{
+					"verified_location.country_code": req.URI.Code,
+				},
+				bson.M{
+					"verified_location.country_code": bson.M{
+						"$in": bson.A{nil, ""},
+					},
+					"location.country": primitive.Regex{
+						Pattern: nodetypes.CountryPattern(req.URI.Code),
+						Options: "i",
+					},
+				},
+			},
+		}
+		projection := bson.M{
+			"_id":               0,
+			"addr":              1,
+			"location":          1,
+			"status":            1,
+			"verified_location": 1,
+		}
+
+		dNodes, err := database.NodeFind(context.TODO(), db, filter, options.Find().SetProjection(projection))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		var (
+			activeNodes = 0
+			cityNodes   = make(map[string]int)
+			name        string
+		)
+
+		for _, item := range dNodes {
+			if name == "" && item.VerifiedLocation != nil {
+				name = item.VerifiedLocation.Country
+			}
+			if item.Status == hubtypes.StatusActive.String() {
+				activeNodes++
+			}
+			city := item.Location.City
+			if item.VerifiedLocation != nil && item.VerifiedLocation.CountryCode != "" && item.VerifiedLocation.City != "" {
+				city = item.VerifiedLocation.City
+			}
+			if city != "" {
+				cityNodes[city]++
+			}
+		}
+
+		cities := make([]bson.M, 0, len(cityNodes))
+		for city, count := range cityNodes {
+			cities = append(cities, bson.M{
+				"city":  city,
+				"nodes": count,
+			})
+		}
+
+		sort.Slice(cities, func(i, j int) bool {
+			return cities[i]["city"].(string) < cities[j]["city"].(string)
+		})
+
+		match := bson.M{
+			"country": req.URI.Code,
+			"city": bson.M{
+				"$exists": false,
+			},
+			"timeframe": "day",
+			"timestamp": bson.M{
+				"$gte": req.Query.FromTimestamp,
+				"$lt":  req.Query.ToTimestamp,
+			},
+			"tz": types.TimezoneFilter(req.Query.Timezone),
+		}
+
+		totals, err := database.CountryStatisticAggregateAll(context.TODO(), db, []bson.M{
+			{
+				"$match": match,
+			},
+			{
+				"$group": bson.M{
+					"_id":           nil,
+					"end_session":   bson.M{"$sum": "$end_session"},
+					"start_session": bson.M{"$sum": "$start_session"},
+					"download": bson.M{
+						"$sum": bson.M{
+							"$toLong": "$session_bandwidth.download",
+						},
+					},
+					"upload": bson.M{
+						"$sum": bson.M{
+							"$toLong": "$session_bandwidth.upload",
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		earnings, err := database.CountryStatisticAggregateAll(context.TODO(), db, []bson.M{
+			{
+				"$match": match,
+			},
+			{
+				"$project": bson.M{
+					"earning": bson.M{
+						"$concatArrays": bson.A{
+							bson.M{"$ifNull": bson.A{"$bytes_earning", bson.A{}}},
+							bson.M{"$ifNull": bson.A{"$hours_earning", bson.A{}}},
+						},
+					},
+				},
+			},
+			{
+				"$unwind": "$earning",
+			},
+			{
+				"$group": bson.M{
+					"_id": "$earning.denom",
+					"amount": bson.M{
+						"$sum": bson.M{
+							"$toLong": "$earning.amount",
+						},
+					},
+				},
+			},
+			{
+				"$project": bson.M{
+					"_id":    0,
+					"denom":  "$_id",
+					"amount": bson.M{"$toString": "$amount"},
+				},
+			},
+			{
+				"$sort": bson.M{
+					"denom": 1,
+				},
+			},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		result := bson.M{
+			"active_nodes":      activeNodes,
+			"cities":            cities,
+			"country":           req.URI.Code,
+			"earning":           earnings,
+			"end_session":       0,
+			"name":              name,
+			"nodes":             len(dNodes),
+			"session_bandwidth": bson.M{"download": 0, "upload": 0},
+			"start_session":     0,
+		}
+
+		if len(totals) > 0 {
+			result["end_session"] = totals[0]["end_session"]
+			result["start_session"] = totals[0]["start_session"]
+			result["session_bandwidth"] = bson.M{
+				"download": totals[0]["download"],
+				"upload":   totals[0]["upload"],
+			}
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
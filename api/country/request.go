@@ -0,0 +1,38 @@
+package country
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sentinel-official/explorer/types"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+type RequestGetCountry struct {
+	Query struct {
+		FromTimestamp time.Time `form:"from_timestamp"`
+		Timezone      string    `form:"tz"`
+		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+	}
+	URI struct {
+		Code string `uri:"code" binding:"required,len=2,alpha"`
+	}
+}
+
+func NewRequestGetCountry(c *gin.Context, timezones types.Timezones) (req *RequestGetCountry, err error) {
+	req = &RequestGetCountry{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+	if _, err = timezones.Location(req.Query.Timezone); err != nil {
+		return nil, err
+	}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+
+	req.URI.Code = nodetypes.CountryCode(req.URI.Code)
+
+	return req, nil
+}
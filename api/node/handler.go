@@ -4,14 +4,30 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
 	"github.com/sentinel-official/explorer/types"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+var (
+	historicalKeys = []string{
+		"active_session",
+		"active_subscription",
+		"bytes_earning",
+		"hours_earning",
+		"session_address",
+		"session_bandwidth",
+	}
 )
 
 func HandlerGetNodes(db *mongo.Database) gin.HandlerFunc {
@@ -26,16 +42,43 @@ func HandlerGetNodes(db *mongo.Database) gin.HandlerFunc {
 		if req.Query.Status != "" {
 			filter["status"] = req.Query.Status
 		}
+		if req.Query.LocationMismatch != nil {
+			filter["verified_location.country_match"] = !*req.Query.LocationMismatch
+		}
+
+		switch req.Query.Certificate {
+		case "expired":
+			filter["certificate.not_after"] = bson.M{"$lt": time.Now().UTC()}
+		case "expiring":
+			now := time.Now().UTC()
+			filter["certificate.not_after"] = bson.M{"$gte": now, "$lt": now.AddDate(0, 0, int(req.Query.CertificateExpiringDays))}
+		case "hostname_mismatch":
+			filter["certificate.hostname_match"] = false
+		case "self_signed":
+			filter["certificate.self_signed"] = true
+		case "untrusted":
+			filter["certificate.trusted"] = false
+		}
 
 		projection := bson.M{
-			"_id":            0,
-			"addr":           1,
-			"handshake_dns":  1,
-			"internet_speed": 1,
-			"location":       1,
-			"moniker":        1,
-			"type":           1,
-			"version":        1,
+			"_id":                            0,
+			"addr":                           1,
+			"certificate.hostname_match":     1,
+			"certificate.issuer":             1,
+			"certificate.not_after":          1,
+			"certificate.self_signed":        1,
+			"certificate.trusted":            1,
+			"cluster.id":                     1,
+			"cluster.size":                   1,
+			"handshake_dns":                  1,
+			"health.certificate_fetch_error": 1,
+			"internet_speed":                 1,
+			"location":                       1,
+			"measured_speed":                 1,
+			"moniker":                        1,
+			"type":                           1,
+			"verified_location":              1,
+			"version":                        1,
 		}
 		opts := options.Find().
 			SetProjection(projection).
@@ -65,14 +108,18 @@ func HandlerGetNode(db *mongo.Database) gin.HandlerFunc {
 			"addr": req.URI.NodeAddr,
 		}
 		projection := bson.M{
-			"_id":            0,
-			"addr":           1,
-			"handshake_dns":  1,
-			"internet_speed": 1,
-			"location":       1,
-			"moniker":        1,
-			"type":           1,
-			"version":        1,
+			"_id":               0,
+			"addr":              1,
+			"certificate":       1,
+			"cluster":           1,
+			"handshake_dns":     1,
+			"internet_speed":    1,
+			"location":          1,
+			"measured_speed":    1,
+			"moniker":           1,
+			"type":              1,
+			"verified_location": 1,
+			"version":           1,
 		}
 		opts := options.FindOne().
 			SetProjection(projection)
@@ -121,14 +168,18 @@ func HandlerGetNodeEvents(db *mongo.Database) gin.HandlerFunc {
 	}
 }
 
-func HandlerGetNodeStatistics(db *mongo.Database, excludeAddrs []string) gin.HandlerFunc {
+func HandlerGetNodeStatistics(db *mongo.Database, excludeAddrs []string, timezones types.Timezones) gin.HandlerFunc {
 	requestHandlers := map[string]func(db *mongo.Database, req *RequestGetNodeStatistics) ([]bson.M, error){
 		"": handleHistorical,
-		types.StatisticMethodCurrentSessionAddressCount: handleCurrentSessionAddressCount(excludeAddrs),
+		types.StatisticMethodCurrentSessionAddressCount:  handleCurrentSessionAddressCount(excludeAddrs),
+		types.StatisticMethodDistributionSessionBytes:    handleDistribution("session_bytes_distribution"),
+		types.StatisticMethodDistributionSessionDuration: handleDistribution("session_duration_distribution"),
+		types.StatisticMethodDistributionSessionPayment:  handleDistribution("session_payment_distribution"),
+		types.StatisticMethodDistributionSessionRating:   handleDistribution("session_rating_distribution"),
 	}
 
 	return func(c *gin.Context) {
-		req, err := NewRequestGetNodeStatistics(c)
+		req, err := NewRequestGetNodeStatistics(c, timezones)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
 			return
@@ -147,11 +198,41 @@ func HandlerGetNodeStatistics(db *mongo.Database, excludeAddrs []string) gin.Han
 			return
 		}
 
+		if req.Query.Format == "columnar" {
+			keys := append([]string{"timestamp"}, historicalKeys...)
+			c.JSON(http.StatusOK, types.NewResponseResult(types.ColumnarStatistics(result, keys...)))
+			return
+		}
+
 		c.JSON(http.StatusOK, types.NewResponseResult(result))
 	}
 }
 
+func fillHistorical(items []bson.M, timeframe string, req *RequestGetNodeStatistics) []bson.M {
+	zeros := bson.M{
+		"active_session":      0,
+		"active_subscription": 0,
+		"bytes_earning":       bson.A{},
+		"hours_earning":       bson.A{},
+		"session_address":     0,
+		"session_bandwidth":   bson.M{"download": "0", "upload": "0"},
+	}
+
+	result := types.FillStatistics(items, timeframe, req.Query.Fill, req.Query.FromTimestamp, req.Query.ToTimestamp, req.Location, zeros)
+	for i := 0; i < len(result); i++ {
+		result[i]["addr"] = req.URI.NodeAddr
+		result[i]["timeframe"] = req.Query.Timeframe
+	}
+
+	return result
+}
+
 func handleHistorical(db *mongo.Database, req *RequestGetNodeStatistics) ([]bson.M, error) {
+	days := types.RollingTimeframeDays(req.Query.Timeframe)
+	if days != 0 {
+		return handleHistoricalRolling(db, days, req)
+	}
+
 	filter := bson.M{
 		"addr":      req.URI.NodeAddr,
 		"timeframe": req.Query.Timeframe,
@@ -159,6 +240,7 @@ func handleHistorical(db *mongo.Database, req *RequestGetNodeStatistics) ([]bson
 			"$gte": req.Query.FromTimestamp,
 			"$lt":  req.Query.ToTimestamp,
 		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
 	}
 	projection := bson.M{
 		"active_session":      1,
@@ -172,6 +254,24 @@ func handleHistorical(db *mongo.Database, req *RequestGetNodeStatistics) ([]bson
 		"timeframe":           1,
 		"timestamp":           1,
 	}
+	if req.Query.Fill != "" {
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(bson.D{
+				bson.E{Key: "timestamp", Value: 1},
+			})
+
+		items, err := database.NodeStatisticFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		result := fillHistorical(items, req.Query.Timeframe, req)
+		types.SortStatistics(result, req.Sort)
+
+		return types.PaginateStatistics(result, req.Query.Skip, req.Query.Limit), nil
+	}
+
 	opts := options.Find().
 		SetProjection(projection).
 		SetSort(req.Sort).
@@ -186,6 +286,81 @@ func handleHistorical(db *mongo.Database, req *RequestGetNodeStatistics) ([]bson
 	return result, nil
 }
 
+func handleHistoricalRolling(db *mongo.Database, days int, req *RequestGetNodeStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"addr":      req.URI.NodeAddr,
+		"timeframe": types.BucketTimeframe(req.Query.Timeframe),
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp.AddDate(0, 0, 1-days),
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"timestamp": 1,
+	}
+	for _, key := range historicalKeys {
+		projection[key] = 1
+	}
+
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "timestamp", Value: 1},
+		})
+
+	items, err := database.NodeStatisticFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := types.NewRollingStatistics(items, days, req.Query.FromTimestamp, req.Query.ToTimestamp, req.Location, historicalKeys...)
+	for i := 0; i < len(result); i++ {
+		result[i]["addr"] = req.URI.NodeAddr
+		result[i]["timeframe"] = req.Query.Timeframe
+	}
+
+	if req.Query.Fill != "" {
+		result = fillHistorical(result, types.BucketTimeframe(req.Query.Timeframe), req)
+	}
+
+	types.SortStatistics(result, req.Sort)
+
+	return types.PaginateStatistics(result, req.Query.Skip, req.Query.Limit), nil
+}
+
+func handleDistribution(key string) func(*mongo.Database, *RequestGetNodeStatistics) ([]bson.M, error) {
+	return func(db *mongo.Database, req *RequestGetNodeStatistics) ([]bson.M, error) {
+		filter := bson.M{
+			"addr":      req.URI.NodeAddr,
+			"timeframe": req.Query.Timeframe,
+			"timestamp": bson.M{
+				"$gte": req.Query.FromTimestamp,
+				"$lt":  req.Query.ToTimestamp,
+			},
+			"tz": types.TimezoneFilter(req.Query.Timezone),
+			key: bson.M{
+				"$exists": true,
+			},
+		}
+		projection := bson.M{
+			"addr":      1,
+			"_id":       0,
+			key:         1,
+			"timeframe": 1,
+			"timestamp": 1,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(req.Sort).
+			SetSkip(req.Query.Skip).
+			SetLimit(req.Query.Limit)
+
+		return database.NodeStatisticFind(context.TODO(), db, filter, opts)
+	}
+}
+
 func handleCurrentSessionAddressCount(excludeAddrs []string) func(*mongo.Database, *RequestGetNodeStatistics) ([]bson.M, error) {
 	return func(db *mongo.Database, req *RequestGetNodeStatistics) ([]bson.M, error) {
 		filter := bson.M{
@@ -211,3 +386,504 @@ func handleCurrentSessionAddressCount(excludeAddrs []string) func(*mongo.Databas
 		}, err
 	}
 }
+
+func HandlerGetNodeLeaderboard(db *mongo.Database, excludeAddrs []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetNodeLeaderboard(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		items, err := leaderboardItems(db, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		if err := leaderboardSessionFactors(db, items, excludeAddrs, req); err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+		if err := leaderboardBytesFactor(db, items, req); err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+		if err := leaderboardEarningFactor(db, items, req); err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+		if err := leaderboardUptimeFactor(db, items, req); err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		result := make([]*LeaderboardItem, 0, len(items))
+		for _, item := range items {
+			result = append(result, item)
+		}
+
+		RankLeaderboardItems(result, req.Weights)
+
+		if req.Query.Skip >= int64(len(result)) {
+			result = result[:0]
+		} else {
+			result = result[req.Query.Skip:]
+		}
+		if req.Query.Limit > 0 && req.Query.Limit < int64(len(result)) {
+			result = result[:req.Query.Limit]
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
+
+func HandlerGetNodeUptime(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetNodeUptime(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		now := time.Now().UTC()
+
+		var maxWindow time.Duration
+		for _, window := range req.Windows {
+			if UptimeWindows[window] > maxWindow {
+				maxWindow = UptimeWindows[window]
+			}
+		}
+
+		filter := bson.M{
+			"addr": req.URI.NodeAddr,
+			"timestamp": bson.M{
+				"$gte": now.Add(-maxWindow),
+			},
+		}
+		projection := bson.M{
+			"_id":         0,
+			"error_class": 1,
+			"success":     1,
+			"timestamp":   1,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(bson.D{
+				bson.E{Key: "timestamp", Value: 1},
+			})
+
+		checks, err := database.NodeHealthCheckFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		result := make([]*UptimeItem, 0, len(req.Windows))
+		for _, window := range req.Windows {
+			result = append(result, NewUptimeItem(window, checks, now.Add(-UptimeWindows[window]), now))
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
+
+func leaderboardItems(db *mongo.Database, req *RequestGetNodeLeaderboard) (map[string]*LeaderboardItem, error) {
+	filter := bson.M{}
+	if req.Query.Status != "" {
+		filter["status"] = req.Query.Status
+	}
+
+	projection := bson.M{
+		"_id":             0,
+		"addr":            1,
+		"gigabyte_prices": 1,
+		"moniker":         1,
+	}
+
+	nodes, err := database.NodeFind(context.TODO(), db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]*LeaderboardItem)
+	for _, node := range nodes {
+		item := NewLeaderboardItem(node.Addr, node.Moniker)
+		for _, coin := range node.GigabytePrices {
+			if coin.Denom != req.Query.Denom {
+				continue
+			}
+
+			if v, err := strconv.ParseFloat(coin.Amount, 64); err == nil {
+				item.Factors[LeaderboardFactorPrice].Value = v
+			}
+		}
+
+		items[node.Addr] = item
+	}
+
+	return items, nil
+}
+
+func leaderboardSessionFactors(db *mongo.Database, items map[string]*LeaderboardItem, excludeAddrs []string, req *RequestGetNodeLeaderboard) error {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"start_timestamp": bson.M{
+					"$lt": req.ToTimestamp,
+				},
+				"$or": bson.A{
+					bson.M{
+						"end_timestamp": bson.M{
+							"$gte": req.FromTimestamp,
+						},
+					},
+					bson.M{
+						"end_timestamp": time.Time{},
+					},
+					bson.M{
+						"end_timestamp": bson.M{
+							"$exists": false,
+						},
+					},
+				},
+				"acc_addr": bson.M{
+					"$nin": excludeAddrs,
+				},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":     "$node_addr",
+				"clients": bson.M{"$addToSet": "$acc_addr"},
+				"rating":  bson.M{"$avg": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$rating", 0}}, "$rating", nil}}},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":     1,
+				"clients": bson.M{"$toLong": bson.M{"$size": "$clients"}},
+				"rating":  1,
+			},
+		},
+	}
+
+	result, err := database.SessionAggregateAll(context.TODO(), db, pipeline)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range result {
+		item, ok := items[types.StringFromInterface(v["_id"])]
+		if !ok {
+			continue
+		}
+
+		item.Factors[LeaderboardFactorClients].Value = float64(types.Int64FromInterface(v["clients"]))
+		item.Factors[LeaderboardFactorRating].Value, _ = v["rating"].(float64)
+	}
+
+	return nil
+}
+
+func leaderboardBytesFactor(db *mongo.Database, items map[string]*LeaderboardItem, req *RequestGetNodeLeaderboard) error {
+	toDouble := func(v string) bson.M {
+		return bson.M{
+			"$convert": bson.M{
+				"input":   v,
+				"to":      "double",
+				"onError": 0,
+				"onNull":  0,
+			},
+		}
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"timeframe": "day",
+				"timestamp": bson.M{
+					"$gte": req.FromTimestamp,
+					"$lt":  req.ToTimestamp,
+				},
+				"tz": types.TimezoneFilter(""),
+				"session_bandwidth": bson.M{
+					"$exists": true,
+				},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":   "$addr",
+				"bytes": bson.M{"$sum": bson.M{"$add": bson.A{toDouble("$session_bandwidth.upload"), toDouble("$session_bandwidth.download")}}},
+			},
+		},
+	}
+
+	result, err := database.NodeStatisticAggregateAll(context.TODO(), db, pipeline)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range result {
+		item, ok := items[types.StringFromInterface(v["_id"])]
+		if !ok {
+			continue
+		}
+
+		item.Factors[LeaderboardFactorBytes].Value, _ = v["bytes"].(float64)
+	}
+
+	return nil
+}
+
+func leaderboardEarningFactor(db *mongo.Database, items map[string]*LeaderboardItem, req *RequestGetNodeLeaderboard) error {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"timeframe": "day",
+				"timestamp": bson.M{
+					"$gte": req.FromTimestamp,
+					"$lt":  req.ToTimestamp,
+				},
+				"tz": types.TimezoneFilter(""),
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":  0,
+				"addr": 1,
+				"earning": bson.M{
+					"$concatArrays": bson.A{
+						bson.M{"$ifNull": bson.A{"$bytes_earning", bson.A{}}},
+						bson.M{"$ifNull": bson.A{"$hours_earning", bson.A{}}},
+					},
+				},
+			},
+		},
+		{
+			"$unwind": "$earning",
+		},
+		{
+			"$match": bson.M{
+				"earning.denom": req.Query.Denom,
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":     "$addr",
+				"earning": bson.M{"$sum": bson.M{"$toDouble": "$earning.amount"}},
+			},
+		},
+	}
+
+	result, err := database.NodeStatisticAggregateAll(context.TODO(), db, pipeline)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range result {
+		item, ok := items[types.StringFromInterface(v["_id"])]
+		if !ok {
+			continue
+		}
+
+		item.Factors[LeaderboardFactorEarning].Value, _ = v["earning"].(float64)
+	}
+
+	return nil
+}
+
+func leaderboardUptimeFactor(db *mongo.Database, items map[string]*LeaderboardItem, req *RequestGetNodeLeaderboard) error {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"timestamp": bson.M{
+					"$gte": req.FromTimestamp,
+					"$lt":  req.ToTimestamp,
+				},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":    "$addr",
+				"uptime": bson.M{"$avg": bson.M{"$cond": bson.A{"$success", 1, 0}}},
+			},
+		},
+	}
+
+	result, err := database.NodeHealthCheckAggregateAll(context.TODO(), db, pipeline)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range result {
+		item, ok := items[types.StringFromInterface(v["_id"])]
+		if !ok {
+			continue
+		}
+
+		item.Factors[LeaderboardFactorUptime].Value, _ = v["uptime"].(float64)
+	}
+
+	return nil
+}
+
+func HandlerGetNodeRelated(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetNodeRelated(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"addr": req.URI.NodeAddr,
+		}
+		projection := bson.M{
+			"_id":     0,
+			"cluster": 1,
+		}
+
+		node, err := database.NodeFindOne(context.TODO(), db, filter, options.FindOne().SetProjection(projection))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		items := []*RelatedItem{}
+		if node == nil || node.Cluster == nil || node.Cluster.Size < 2 {
+			c.JSON(http.StatusOK, types.NewResponseResult(items))
+			return
+		}
+
+		filter = bson.M{
+			"addr": bson.M{
+				"$ne": req.URI.NodeAddr,
+			},
+			"cluster.id": node.Cluster.ID,
+		}
+		projection = bson.M{
+			"_id":        0,
+			"addr":       1,
+			"cluster":    1,
+			"moniker":    1,
+			"remote_url": 1,
+			"status":     1,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(bson.D{
+				bson.E{Key: "addr", Value: 1},
+			}).
+			SetSkip(req.Query.Skip).
+			SetLimit(req.Query.Limit)
+
+		nodes, err := database.NodeFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		for _, item := range nodes {
+			items = append(items, NewRelatedItem(item, node.Cluster))
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(items))
+	}
+}
+
+func HandlerGetNodeCapacity(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetNodeCapacity(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"status": "active",
+		}
+		if req.Query.Country != "" {
+			filter["$or"] = bson.A{
+				bson.M{
+					"verified_location.country_code": req.Query.Country,
+				},
+				bson.M{
+					"verified_location.country_code": bson.M{
+						"$in": bson.A{nil, ""},
+					},
+					"location.country": primitive.Regex{
+						Pattern: nodetypes.CountryPattern(req.Query.Country),
+						Options: "i",
+					},
+				},
+			}
+		}
+
+		projection := bson.M{
+			"_id":                            0,
+			"addr":                           1,
+			"location.country":               1,
+			"moniker":                        1,
+			"peers":                          1,
+			"qos":                            1,
+			"verified_location.country_code": 1,
+		}
+
+		nodes, err := database.NodeFind(context.TODO(), db, filter, options.Find().SetProjection(projection))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		items := make([]*CapacityItem, 0, len(nodes))
+		for _, node := range nodes {
+			items = append(items, NewCapacityItem(node, req.Query.Saturation))
+		}
+
+		summary := NewCapacitySummary(items)
+
+		if req.Query.State != "" {
+			filtered := make([]*CapacityItem, 0, len(items))
+			for _, item := range items {
+				if item.State == req.Query.State {
+					filtered = append(filtered, item)
+				}
+			}
+
+			items = filtered
+		}
+
+		sort.SliceStable(items, func(i, j int) bool {
+			var x, y float64 = -1, -1
+			if items[i].Utilization != nil {
+				x = *items[i].Utilization
+			}
+			if items[j].Utilization != nil {
+				y = *items[j].Utilization
+			}
+			if x != y {
+				return x > y
+			}
+
+			return items[i].Addr < items[j].Addr
+		})
+
+		if req.Query.Skip >= int64(len(items)) {
+			items = items[:0]
+		} else {
+			items = items[req.Query.Skip:]
+		}
+		if req.Query.Limit > 0 && req.Query.Limit < int64(len(items)) {
+			items = items[:req.Query.Limit]
+		}
+
+		result := &CapacityResult{
+			Items:   items,
+			Summary: summary,
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
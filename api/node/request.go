@@ -1,11 +1,16 @@
 package node
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 
+	"github.com/sentinel-official/explorer/types"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
 	"github.com/sentinel-official/explorer/utils"
 )
 
@@ -13,10 +18,13 @@ type RequestGetNodes struct {
 	Sort bson.D
 
 	Query struct {
-		Status string `form:"status" binding:"omitempty,oneof=active inactive"`
-		Sort   string `form:"sort"`
-		Skip   int64  `form:"skip" binding:"gte=0"`
-		Limit  int64  `form:"limit,default=25" binding:"gte=0,lte=100"`
+		Certificate             string `form:"certificate" binding:"omitempty,oneof=expired expiring hostname_mismatch self_signed untrusted"`
+		CertificateExpiringDays int64  `form:"certificate_expiring_days,default=14" binding:"gte=0,lte=365"`
+		LocationMismatch        *bool  `form:"location_mismatch"`
+		Status                  string `form:"status" binding:"omitempty,oneof=active inactive"`
+		Sort                    string `form:"sort"`
+		Skip                    int64  `form:"skip" binding:"gte=0"`
+		Limit                   int64  `form:"limit,default=25" binding:"gte=0,lte=100"`
 	}
 }
 
@@ -31,6 +39,12 @@ func NewRequestGetNodes(c *gin.Context) (req *RequestGetNodes, err error) {
 		"-peers",
 		"register_height",
 		"-register_height",
+		"certificate.not_after",
+		"-certificate.not_after",
+		"measured_speed.latency.ttfb.avg",
+		"-measured_speed.latency.ttfb.avg",
+		"measured_speed.throughput.upload",
+		"-measured_speed.throughput.upload",
 	}
 	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
 		return nil, err
@@ -88,16 +102,20 @@ func NewRequestGetNodeEvents(c *gin.Context) (req *RequestGetNodeEvents, err err
 }
 
 type RequestGetNodeStatistics struct {
-	Sort bson.D
+	Sort     bson.D
+	Location *time.Location
 
 	Query struct {
+		Fill          string    `form:"fill" binding:"omitempty,oneof=zero previous null"`
+		Format        string    `form:"format" binding:"omitempty,oneof=rows columnar"`
 		FromTimestamp time.Time `form:"from_timestamp"`
 		Limit         int64     `form:"limit,default=30" binding:"gte=0,lte=100"`
 		Method        string    `form:"method"`
 		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
 		Sort          string    `form:"sort"`
 		Status        string    `form:"status" binding:"omitempty,oneof=active inactive inactive_pending"`
-		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=day week month year"`
+		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=hour day week month year 7d 30d"`
+		Timezone      string    `form:"tz"`
 		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
 	}
 	URI struct {
@@ -105,15 +123,25 @@ type RequestGetNodeStatistics struct {
 	}
 }
 
-func NewRequestGetNodeStatistics(c *gin.Context) (req *RequestGetNodeStatistics, err error) {
+func NewRequestGetNodeStatistics(c *gin.Context, timezones types.Timezones) (req *RequestGetNodeStatistics, err error) {
 	req = &RequestGetNodeStatistics{}
 	if err = c.ShouldBindQuery(&req.Query); err != nil {
 		return nil, err
 	}
+
+	req.Location, err = timezones.TimeframeLocation(req.Query.Timeframe, req.Query.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
 	if err = c.ShouldBindUri(&req.URI); err != nil {
 		return nil, err
 	}
 
+	if req.Query.Method != "" && (req.Query.Fill != "" || req.Query.Format != "") {
+		return nil, fmt.Errorf("method %s does not support fill or format", req.Query.Method)
+	}
+
 	vFunc, ok := validators[req.Query.Method]
 	if !ok {
 		return req, nil
@@ -128,3 +156,134 @@ func NewRequestGetNodeStatistics(c *gin.Context) (req *RequestGetNodeStatistics,
 
 	return req, nil
 }
+
+type RequestGetNodeLeaderboard struct {
+	FromTimestamp time.Time
+	ToTimestamp   time.Time
+	Weights       map[string]float64
+
+	Query struct {
+		Denom         string  `form:"denom,default=udvpn"`
+		Limit         int64   `form:"limit,default=25" binding:"gte=0,lte=100"`
+		Skip          int64   `form:"skip" binding:"gte=0"`
+		Status        string  `form:"status,default=active" binding:"omitempty,oneof=active inactive"`
+		WeightBytes   float64 `form:"weight_bytes,default=1" binding:"gte=0"`
+		WeightClients float64 `form:"weight_clients,default=1" binding:"gte=0"`
+		WeightEarning float64 `form:"weight_earning,default=1" binding:"gte=0"`
+		WeightPrice   float64 `form:"weight_price,default=1" binding:"gte=0"`
+		WeightRating  float64 `form:"weight_rating,default=1" binding:"gte=0"`
+		WeightUptime  float64 `form:"weight_uptime,default=1" binding:"gte=0"`
+		Window        string  `form:"window,default=30d" binding:"oneof=7d 30d 90d 365d"`
+	}
+}
+
+func NewRequestGetNodeLeaderboard(c *gin.Context) (req *RequestGetNodeLeaderboard, err error) {
+	req = &RequestGetNodeLeaderboard{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	req.Weights = map[string]float64{
+		LeaderboardFactorBytes:   req.Query.WeightBytes,
+		LeaderboardFactorClients: req.Query.WeightClients,
+		LeaderboardFactorEarning: req.Query.WeightEarning,
+		LeaderboardFactorPrice:   req.Query.WeightPrice,
+		LeaderboardFactorRating:  req.Query.WeightRating,
+		LeaderboardFactorUptime:  req.Query.WeightUptime,
+	}
+
+	var total float64
+	for _, v := range req.Weights {
+		total = total + v
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("at least one weight must be greater than zero")
+	}
+
+	days, err := strconv.Atoi(strings.TrimSuffix(req.Query.Window, "d"))
+	if err != nil {
+		return nil, err
+	}
+
+	req.ToTimestamp = time.Now().UTC()
+	req.FromTimestamp = utils.DayDate(req.ToTimestamp).AddDate(0, 0, -days)
+
+	return req, nil
+}
+
+type RequestGetNodeUptime struct {
+	Windows []string
+
+	Query struct {
+		Window string `form:"window" binding:"omitempty,oneof=24h 7d 30d"`
+	}
+	URI struct {
+		NodeAddr string `uri:"node_addr"`
+	}
+}
+
+func NewRequestGetNodeUptime(c *gin.Context) (req *RequestGetNodeUptime, err error) {
+	req = &RequestGetNodeUptime{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+
+	req.Windows = []string{"24h", "7d", "30d"}
+	if req.Query.Window != "" {
+		req.Windows = []string{req.Query.Window}
+	}
+
+	return req, nil
+}
+
+type RequestGetNodeRelated struct {
+	URI struct {
+		NodeAddr string `uri:"node_addr"`
+	}
+	Query struct {
+		Skip  int64 `form:"skip" binding:"gte=0"`
+		Limit int64 `form:"limit,default=25" binding:"gte=0,lte=100"`
+	}
+}
+
+func NewRequestGetNodeRelated(c *gin.Context) (req *RequestGetNodeRelated, err error) {
+	req = &RequestGetNodeRelated{}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestGetNodeCapacity struct {
+	Query struct {
+		Country    string  `form:"country"`
+		Limit      int64   `form:"limit,default=25" binding:"gte=0,lte=100"`
+		Saturation float64 `form:"saturation,default=0.9" binding:"gt=0,lte=1"`
+		Skip       int64   `form:"skip" binding:"gte=0"`
+		State      string  `form:"state" binding:"omitempty,oneof=available idle saturated unknown"`
+	}
+}
+
+func NewRequestGetNodeCapacity(c *gin.Context) (req *RequestGetNodeCapacity, err error) {
+	req = &RequestGetNodeCapacity{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+	if req.Query.Country != "" {
+		code := nodetypes.CountryCode(req.Query.Country)
+		if code == "" {
+			return nil, fmt.Errorf("invalid country %s", req.Query.Country)
+		}
+
+		req.Query.Country = code
+	}
+
+	return req, nil
+}
@@ -1 +1,319 @@
 package node
+
+import (
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+const (
+	LeaderboardFactorBytes   = "bytes"
+	LeaderboardFactorClients = "clients"
+	LeaderboardFactorEarning = "earning"
+	LeaderboardFactorPrice   = "price"
+	LeaderboardFactorRating  = "rating"
+	LeaderboardFactorUptime  = "uptime"
+)
+
+type LeaderboardFactor struct {
+	Value        float64 `json:"value"`
+	Score        float64 `json:"score"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+}
+
+type LeaderboardItem struct {
+	Rank    int                           `json:"rank"`
+	Addr    string                        `json:"addr"`
+	Moniker string                        `json:"moniker,omitempty"`
+	Score   float64                       `json:"score"`
+	Factors map[string]*LeaderboardFactor `json:"factors"`
+}
+
+func NewLeaderboardItem(addr, moniker string) *LeaderboardItem {
+	item := &LeaderboardItem{
+		Addr:    addr,
+		Moniker: moniker,
+		Factors: make(map[string]*LeaderboardFactor),
+	}
+
+	for _, key := range []string{
+		LeaderboardFactorBytes,
+		LeaderboardFactorClients,
+		LeaderboardFactorEarning,
+		LeaderboardFactorPrice,
+		LeaderboardFactorRating,
+		LeaderboardFactorUptime,
+	} {
+		item.Factors[key] = &LeaderboardFactor{}
+	}
+
+	return item
+}
+
+func RankLeaderboardItems(items []*LeaderboardItem, weights map[string]float64) {
+	var total float64
+	for _, v := range weights {
+		total = total + v
+	}
+
+	var (
+		maxValues = make(map[string]float64)
+		minPrice  float64
+	)
+
+	for _, item := range items {
+		for key, factor := range item.Factors {
+			if factor.Value > maxValues[key] {
+				maxValues[key] = factor.Value
+			}
+		}
+
+		if v := item.Factors[LeaderboardFactorPrice].Value; v > 0 && (minPrice == 0 || v < minPrice) {
+			minPrice = v
+		}
+	}
+
+	for _, item := range items {
+		item.Score = 0
+		for key, factor := range item.Factors {
+			switch key {
+			case LeaderboardFactorPrice:
+				if factor.Value > 0 {
+					factor.Score = minPrice / factor.Value
+				}
+			case LeaderboardFactorUptime:
+				factor.Score = factor.Value
+			default:
+				if maxValues[key] > 0 {
+					factor.Score = factor.Value / maxValues[key]
+				}
+			}
+
+			factor.Weight = weights[key]
+			factor.Contribution = factor.Score * factor.Weight / total
+
+			item.Score = item.Score + factor.Contribution
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+
+		return items[i].Addr < items[j].Addr
+	})
+
+	for i := range items {
+		items[i].Rank = i + 1
+	}
+}
+
+var (
+	UptimeWindows = map[string]time.Duration{
+		"24h": 24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+		"30d": 30 * 24 * time.Hour,
+	}
+)
+
+type UptimeOutage struct {
+	StartTimestamp time.Time  `json:"start_timestamp"`
+	EndTimestamp   *time.Time `json:"end_timestamp"`
+	Duration       float64    `json:"duration"`
+	ErrorClass     string     `json:"error_class,omitempty"`
+}
+
+type UptimeItem struct {
+	Window       string          `json:"window"`
+	Checks       int             `json:"checks"`
+	Successes    int             `json:"successes"`
+	Availability *float64        `json:"availability"`
+	Outages      []*UptimeOutage `json:"outages"`
+}
+
+func NewUptimeItem(window string, checks []bson.M, fromTimestamp, now time.Time) *UptimeItem {
+	item := &UptimeItem{
+		Window:  window,
+		Outages: []*UptimeOutage{},
+	}
+
+	var (
+		observed time.Duration
+		up       time.Duration
+		outage   *UptimeOutage
+	)
+
+	for i, check := range checks {
+		timestamp := types.TimeFromInterface(check["timestamp"])
+		if timestamp.Before(fromTimestamp) {
+			continue
+		}
+
+		next := now
+		if i+1 < len(checks) {
+			next = types.TimeFromInterface(checks[i+1]["timestamp"])
+		}
+
+		success, _ := check["success"].(bool)
+
+		item.Checks++
+		observed = observed + next.Sub(timestamp)
+
+		if success {
+			item.Successes++
+			up = up + next.Sub(timestamp)
+
+			if outage != nil {
+				end := timestamp
+				outage.EndTimestamp = &end
+				outage.Duration = end.Sub(outage.StartTimestamp).Seconds()
+				outage = nil
+			}
+
+			continue
+		}
+
+		if outage == nil {
+			outage = &UptimeOutage{
+				StartTimestamp: timestamp,
+				ErrorClass:     types.StringFromInterface(check["error_class"]),
+			}
+
+			item.Outages = append(item.Outages, outage)
+		}
+	}
+
+	if outage != nil {
+		outage.Duration = now.Sub(outage.StartTimestamp).Seconds()
+	}
+
+	if observed > 0 {
+		availability := float64(up) / float64(observed) * 100
+		item.Availability = &availability
+	}
+
+	return item
+}
+
+type RelatedItem struct {
+	Addr      string             `json:"addr"`
+	Moniker   string             `json:"moniker,omitempty"`
+	RemoteURL string             `json:"remote_url,omitempty"`
+	Status    string             `json:"status,omitempty"`
+	Cluster   *nodetypes.Cluster `json:"cluster,omitempty"`
+	Matches   []string           `json:"matches"`
+}
+
+func NewRelatedItem(node *models.Node, cluster *nodetypes.Cluster) *RelatedItem {
+	item := &RelatedItem{
+		Addr:      node.Addr,
+		Moniker:   node.Moniker,
+		RemoteURL: node.RemoteURL,
+		Status:    node.Status,
+		Cluster:   node.Cluster,
+		Matches:   []string{},
+	}
+
+	if node.Cluster != nil {
+		if matches := cluster.Matches(node.Cluster); matches != nil {
+			item.Matches = matches
+		}
+	}
+
+	return item
+}
+
+const (
+	CapacityStateAvailable = "available"
+	CapacityStateIdle      = "idle"
+	CapacityStateSaturated = "saturated"
+	CapacityStateUnknown   = "unknown"
+)
+
+type CapacityItem struct {
+	Addr        string   `json:"addr"`
+	Moniker     string   `json:"moniker,omitempty"`
+	Country     string   `json:"country,omitempty"`
+	Peers       int      `json:"peers"`
+	MaxPeers    int      `json:"max_peers"`
+	Utilization *float64 `json:"utilization,omitempty"`
+	State       string   `json:"state"`
+}
+
+func NewCapacityItem(node *models.Node, saturation float64) *CapacityItem {
+	item := &CapacityItem{
+		Addr:     node.Addr,
+		Moniker:  node.Moniker,
+		Country:  nodetypes.CountryCode(node.Location.Country),
+		Peers:    node.Peers,
+		MaxPeers: node.QOS.MaxPeers,
+		State:    CapacityStateUnknown,
+	}
+
+	if node.VerifiedLocation != nil && node.VerifiedLocation.CountryCode != "" {
+		item.Country = nodetypes.CountryCode(node.VerifiedLocation.CountryCode)
+	}
+	if item.MaxPeers > 0 {
+		utilization := float64(item.Peers) / float64(item.MaxPeers)
+		item.Utilization = &utilization
+
+		item.State = CapacityStateAvailable
+		if utilization >= saturation {
+			item.State = CapacityStateSaturated
+		}
+	}
+	if item.Peers == 0 {
+		item.State = CapacityStateIdle
+	}
+
+	return item
+}
+
+type CapacitySummary struct {
+	NodeCount   int64            `json:"node_count"`
+	Peers       int64            `json:"peers"`
+	MaxPeers    int64            `json:"max_peers"`
+	Utilization float64          `json:"utilization"`
+	States      map[string]int64 `json:"states"`
+}
+
+func NewCapacitySummary(items []*CapacityItem) *CapacitySummary {
+	s := &CapacitySummary{
+		States: map[string]int64{
+			CapacityStateAvailable: 0,
+			CapacityStateIdle:      0,
+			CapacityStateSaturated: 0,
+			CapacityStateUnknown:   0,
+		},
+	}
+
+	var peers int64
+	for _, item := range items {
+		s.NodeCount = s.NodeCount + 1
+		s.Peers = s.Peers + int64(item.Peers)
+		s.States[item.State] = s.States[item.State] + 1
+
+		if item.MaxPeers > 0 {
+			peers = peers + int64(item.Peers)
+			s.MaxPeers = s.MaxPeers + int64(item.MaxPeers)
+		}
+	}
+
+	if s.MaxPeers > 0 {
+		s.Utilization = float64(peers) / float64(s.MaxPeers)
+	}
+
+	return s
+}
+
+type CapacityResult struct {
+	Items   []*CapacityItem  `json:"items"`
+	Summary *CapacitySummary `json:"summary"`
+}
@@ -3,11 +3,17 @@ package node
 import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/sentinel-official/explorer/types"
 )
 
-func RegisterRoutes(router gin.IRouter, db *mongo.Database, excludeAddrs []string) {
+func RegisterRoutes(router gin.IRouter, db *mongo.Database, excludeAddrs []string, timezones types.Timezones) {
 	router.GET("/nodes", HandlerGetNodes(db))
+	router.GET("/nodes/capacity", HandlerGetNodeCapacity(db))
+	router.GET("/nodes/leaderboard", HandlerGetNodeLeaderboard(db, excludeAddrs))
 	router.GET("/nodes/:node_addr", HandlerGetNode(db))
 	router.GET("/nodes/:node_addr/events", HandlerGetNodeEvents(db))
-	router.GET("/nodes/:node_addr/statistics", HandlerGetNodeStatistics(db, excludeAddrs))
+	router.GET("/nodes/:node_addr/related", HandlerGetNodeRelated(db))
+	router.GET("/nodes/:node_addr/statistics", HandlerGetNodeStatistics(db, excludeAddrs, timezones))
+	router.GET("/nodes/:node_addr/uptime", HandlerGetNodeUptime(db))
 }
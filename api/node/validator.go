@@ -1,16 +1,43 @@
 package node
 
 import (
+	"fmt"
+
+	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
 
 var (
 	validators = map[string]func(req *RequestGetNodeStatistics) error{
 		"": validateHistorical,
+		types.StatisticMethodDistributionSessionBytes:    validateDistribution,
+		types.StatisticMethodDistributionSessionDuration: validateDistribution,
+		types.StatisticMethodDistributionSessionPayment:  validateDistribution,
+		types.StatisticMethodDistributionSessionRating:   validateDistribution,
 	}
 )
 
 func validateHistorical(req *RequestGetNodeStatistics) (err error) {
+	if req.Query.Fill != "" && req.Query.FromTimestamp.IsZero() {
+		return fmt.Errorf("fill requires from_timestamp")
+	}
+
+	allowed := []string{
+		"-timestamp",
+		"timestamp",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateDistribution(req *RequestGetNodeStatistics) (err error) {
+	if types.RollingTimeframeDays(req.Query.Timeframe) != 0 {
+		return fmt.Errorf("timeframe %s is not supported", req.Query.Timeframe)
+	}
+
 	allowed := []string{
 		"-timestamp",
 		"timestamp",
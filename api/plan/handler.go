@@ -0,0 +1,345 @@
+package plan
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+)
+
+var (
+	statisticKeys = []string{
+		"active_session",
+		"active_subscription",
+		"end_session",
+		"end_subscription",
+		"linked_node",
+		"plan_payment",
+		"plan_staking_reward",
+		"session_address",
+		"session_bandwidth",
+		"session_node",
+		"start_session",
+		"start_subscription",
+		"subscription_address",
+	}
+)
+
+func HandlerGetPlanStatistics(db *mongo.Database, timezones types.Timezones) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetPlanStatistics(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		var result []bson.M
+		if days := types.RollingTimeframeDays(req.Query.Timeframe); days != 0 {
+			result, err = handleHistoricalRolling(db, days, req)
+		} else {
+			result, err = handleHistorical(db, req)
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
+
+func handleHistorical(db *mongo.Database, req *RequestGetPlanStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"plan_id":   req.URI.ID,
+		"timeframe": req.Query.Timeframe,
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp,
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"plan_id":   1,
+		"timeframe": 1,
+		"timestamp": 1,
+	}
+	for _, key := range statisticKeys {
+		projection[key] = 1
+	}
+
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(req.Sort).
+		SetSkip(req.Query.Skip).
+		SetLimit(req.Query.Limit)
+
+	result, err := database.PlanStatisticFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func handleHistoricalRolling(db *mongo.Database, days int, req *RequestGetPlanStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"plan_id":   req.URI.ID,
+		"timeframe": types.BucketTimeframe(req.Query.Timeframe),
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp.AddDate(0, 0, 1-days),
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"timestamp": 1,
+	}
+	for _, key := range statisticKeys {
+		projection[key] = 1
+	}
+
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "timestamp", Value: 1},
+		})
+
+	items, err := database.PlanStatisticFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := types.NewRollingStatistics(items, days, req.Query.FromTimestamp, req.Query.ToTimestamp, req.Location, statisticKeys...)
+	for i := 0; i < len(result); i++ {
+		result[i]["plan_id"] = req.URI.ID
+		result[i]["timeframe"] = req.Query.Timeframe
+	}
+
+	types.SortStatistics(result, req.Sort)
+
+	return types.PaginateStatistics(result, req.Query.Skip, req.Query.Limit), nil
+}
+
+func HandlerGetPlans(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetPlans(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{}
+		if req.Query.Status != "" {
+			filter["status"] = req.Query.Status
+		}
+		if req.Query.Provider != "" {
+			filter["prov_addr"] = req.Query.Provider
+		}
+		if req.Query.PriceDenom != "" {
+			filter["prices.denom"] = req.Query.PriceDenom
+		}
+
+		if len(req.Sort) != 0 && req.Sort[0].Key == "price" {
+			result, err := handlePlansByPrice(db, filter, req)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+				return
+			}
+
+			c.JSON(http.StatusOK, types.NewResponseResult(result))
+			return
+		}
+
+		projection := bson.M{
+			"_id": 0,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(req.Sort).
+			SetSkip(req.Query.Skip).
+			SetLimit(req.Query.Limit)
+
+		items, err := database.PlanFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(items))
+	}
+}
+
+func handlePlansByPrice(db *mongo.Database, filter bson.M, req *RequestGetPlans) ([]bson.M, error) {
+	pipeline := []bson.M{
+		{
+			"$match": filter,
+		},
+		{
+			"$addFields": bson.M{
+				"price": bson.M{
+					"$let": bson.M{
+						"vars": bson.M{
+							"coin": bson.M{
+								"$arrayElemAt": bson.A{
+									bson.M{
+										"$filter": bson.M{
+											"input": "$prices",
+											"cond":  bson.M{"$eq": bson.A{"$$this.denom", req.Query.PriceDenom}},
+										},
+									},
+									0,
+								},
+							},
+						},
+						"in": bson.M{"$toDecimal": "$$coin.amount"},
+					},
+				},
+			},
+		},
+		{
+			"$sort": append(req.Sort, bson.E{Key: "id", Value: 1}),
+		},
+		{
+			"$skip": req.Query.Skip,
+		},
+	}
+	if req.Query.Limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": req.Query.Limit})
+	}
+
+	pipeline = append(pipeline, bson.M{
+		"$project": bson.M{
+			"_id":   0,
+			"price": 0,
+		},
+	})
+
+	return database.PlanAggregateAll(context.TODO(), db, pipeline)
+}
+
+func HandlerGetPlan(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetPlan(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"id": req.URI.ID,
+		}
+		projection := bson.M{
+			"_id": 0,
+		}
+		opts := options.FindOne().
+			SetProjection(projection)
+
+		item, err := database.PlanFindOne(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(item))
+	}
+}
+
+func HandlerGetPlanNodes(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetPlanNodes(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"id": req.URI.ID,
+		}
+		projection := bson.M{
+			"_id":        0,
+			"node_addrs": 1,
+		}
+
+		plan, err := database.PlanFindOne(context.TODO(), db, filter, options.FindOne().SetProjection(projection))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+		if plan == nil || len(plan.NodeAddrs) == 0 {
+			c.JSON(http.StatusOK, types.NewResponseResult([]bson.M{}))
+			return
+		}
+
+		filter = bson.M{
+			"addr": bson.M{
+				"$in": plan.NodeAddrs,
+			},
+		}
+		if req.Query.Status != "" {
+			filter["status"] = req.Query.Status
+		}
+
+		projection = bson.M{
+			"_id":                  0,
+			"health.client_config": 0,
+			"health.server_config": 0,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(req.Sort).
+			SetSkip(req.Query.Skip).
+			SetLimit(req.Query.Limit)
+
+		items, err := database.NodeFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(items))
+	}
+}
+
+func HandlerGetPlanEvents(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetPlanEvents(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"type": bson.M{
+				"$in": bson.A{
+					types.EventTypePlanLinkNode,
+					types.EventTypePlanUnlinkNode,
+					types.EventTypePlanUpdateStatus,
+				},
+			},
+			"plan_id": req.URI.ID,
+		}
+		projection := bson.M{}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(req.Sort).
+			SetSkip(req.Query.Skip).
+			SetLimit(req.Query.Limit)
+
+		items, err := database.EventFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(items))
+	}
+}
@@ -0,0 +1,176 @@
+package plan
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type RequestGetPlanStatistics struct {
+	Sort     bson.D
+	Location *time.Location
+
+	Query struct {
+		FromTimestamp time.Time `form:"from_timestamp"`
+		Limit         int64     `form:"limit,default=30" binding:"gte=0,lte=100"`
+		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
+		Sort          string    `form:"sort"`
+		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=hour day week month year 7d 30d"`
+		Timezone      string    `form:"tz"`
+		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+	}
+	URI struct {
+		ID uint64 `uri:"id" binding:"gt=0"`
+	}
+}
+
+func NewRequestGetPlanStatistics(c *gin.Context, timezones types.Timezones) (req *RequestGetPlanStatistics, err error) {
+	req = &RequestGetPlanStatistics{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	req.Location, err = timezones.TimeframeLocation(req.Query.Timeframe, req.Query.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"-timestamp",
+		"timestamp",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestGetPlans struct {
+	Sort bson.D
+
+	Query struct {
+		Limit      int64  `form:"limit,default=25" binding:"gte=0,lte=100"`
+		PriceDenom string `form:"price_denom"`
+		Provider   string `form:"provider"`
+		Skip       int64  `form:"skip" binding:"gte=0"`
+		Sort       string `form:"sort"`
+		Status     string `form:"status" binding:"omitempty,oneof=active inactive inactive_pending"`
+	}
+}
+
+func NewRequestGetPlans(c *gin.Context) (req *RequestGetPlans, err error) {
+	req = &RequestGetPlans{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"create_height",
+		"-create_height",
+		"price",
+		"-price",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return nil, err
+	}
+
+	if len(req.Sort) != 0 && req.Sort[0].Key == "price" && req.Query.PriceDenom == "" {
+		return nil, fmt.Errorf("sort by price requires price_denom")
+	}
+
+	return req, nil
+}
+
+type RequestGetPlan struct {
+	URI struct {
+		ID uint64 `uri:"id" binding:"gt=0"`
+	}
+}
+
+func NewRequestGetPlan(c *gin.Context) (req *RequestGetPlan, err error) {
+	req = &RequestGetPlan{}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestGetPlanNodes struct {
+	Sort bson.D
+
+	URI struct {
+		ID uint64 `uri:"id" binding:"gt=0"`
+	}
+	Query struct {
+		Limit  int64  `form:"limit,default=25" binding:"gte=0,lte=100"`
+		Skip   int64  `form:"skip" binding:"gte=0"`
+		Sort   string `form:"sort"`
+		Status string `form:"status" binding:"omitempty,oneof=active inactive inactive_pending"`
+	}
+}
+
+func NewRequestGetPlanNodes(c *gin.Context) (req *RequestGetPlanNodes, err error) {
+	req = &RequestGetPlanNodes{}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"peers",
+		"-peers",
+		"register_height",
+		"-register_height",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestGetPlanEvents struct {
+	Sort bson.D
+
+	URI struct {
+		ID uint64 `uri:"id" binding:"gt=0"`
+	}
+	Query struct {
+		Limit int64  `form:"limit,default=25" binding:"gte=0,lte=100"`
+		Skip  int64  `form:"skip" binding:"gte=0"`
+		Sort  string `form:"sort"`
+	}
+}
+
+func NewRequestGetPlanEvents(c *gin.Context) (req *RequestGetPlanEvents, err error) {
+	req = &RequestGetPlanEvents{}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"height",
+		"-height",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
@@ -0,0 +1,16 @@
+package plan
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/sentinel-official/explorer/types"
+)
+
+func RegisterRoutes(router gin.IRouter, db *mongo.Database, timezones types.Timezones) {
+	router.GET("/plans", HandlerGetPlans(db))
+	router.GET("/plans/:id", HandlerGetPlan(db))
+	router.GET("/plans/:id/events", HandlerGetPlanEvents(db))
+	router.GET("/plans/:id/nodes", HandlerGetPlanNodes(db))
+	router.GET("/plans/:id/statistics", HandlerGetPlanStatistics(db, timezones))
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+)
+
+var (
+	statisticKeys = []string{
+		"active_session",
+		"active_subscription",
+		"end_session",
+		"end_subscription",
+		"linked_node",
+		"plan_payment",
+		"plan_staking_reward",
+		"session_address",
+		"session_bandwidth",
+		"session_node",
+		"start_session",
+		"start_subscription",
+		"subscription_address",
+	}
+)
+
+func HandlerGetProviderStatistics(db *mongo.Database, timezones types.Timezones) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetProviderStatistics(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		var result []bson.M
+		if days := types.RollingTimeframeDays(req.Query.Timeframe); days != 0 {
+			result, err = handleHistoricalRolling(db, days, req)
+		} else {
+			result, err = handleHistorical(db, req)
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
+
+func handleHistorical(db *mongo.Database, req *RequestGetProviderStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"prov_addr": req.URI.ProvAddr,
+		"timeframe": req.Query.Timeframe,
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp,
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"prov_addr": 1,
+		"timeframe": 1,
+		"timestamp": 1,
+	}
+	for _, key := range statisticKeys {
+		projection[key] = 1
+	}
+
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(req.Sort).
+		SetSkip(req.Query.Skip).
+		SetLimit(req.Query.Limit)
+
+	result, err := database.ProviderStatisticFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func handleHistoricalRolling(db *mongo.Database, days int, req *RequestGetProviderStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"prov_addr": req.URI.ProvAddr,
+		"timeframe": types.BucketTimeframe(req.Query.Timeframe),
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp.AddDate(0, 0, 1-days),
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"timestamp": 1,
+	}
+	for _, key := range statisticKeys {
+		projection[key] = 1
+	}
+
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "timestamp", Value: 1},
+		})
+
+	items, err := database.ProviderStatisticFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := types.NewRollingStatistics(items, days, req.Query.FromTimestamp, req.Query.ToTimestamp, req.Location, statisticKeys...)
+	for i := 0; i < len(result); i++ {
+		result[i]["prov_addr"] = req.URI.ProvAddr
+		result[i]["timeframe"] = req.Query.Timeframe
+	}
+
+	types.SortStatistics(result, req.Sort)
+
+	return types.PaginateStatistics(result, req.Query.Skip, req.Query.Limit), nil
+}
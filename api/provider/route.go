@@ -0,0 +1,12 @@
+package provider
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/sentinel-official/explorer/types"
+)
+
+func RegisterRoutes(router gin.IRouter, db *mongo.Database, timezones types.Timezones) {
+	router.GET("/providers/:prov_addr/statistics", HandlerGetProviderStatistics(db, timezones))
+}
@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/types"
 )
 
@@ -108,3 +109,100 @@ func HandlerGetSessionEvents(db *mongo.Database) gin.HandlerFunc {
 		c.JSON(http.StatusOK, types.NewResponseResult(items))
 	}
 }
+
+func HandlerGetSessionUsage(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetSessionUsage(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"id": req.URI.ID,
+		}
+		projection := bson.M{
+			"_id":             0,
+			"start_timestamp": 1,
+			"subscription_id": 1,
+		}
+
+		session, err := database.SessionFindOne(context.TODO(), db, filter, options.FindOne().SetProjection(projection))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+		if session == nil {
+			c.JSON(http.StatusOK, types.NewResponseResult(nil))
+			return
+		}
+
+		filter = bson.M{
+			"id": session.SubscriptionID,
+		}
+		projection = bson.M{
+			"_id":       0,
+			"deposit":   1,
+			"gigabytes": 1,
+			"hours":     1,
+			"plan_id":   1,
+		}
+
+		subscription, err := database.SubscriptionFindOne(context.TODO(), db, filter, options.FindOne().SetProjection(projection))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		skip, limit := req.Query.Skip, req.Query.Limit
+		if skip > 0 {
+			skip = skip - 1
+			if limit > 0 {
+				limit = limit + 1
+			}
+		}
+
+		filter = bson.M{
+			"type":       types.EventTypeSessionUpdateDetails,
+			"session_id": req.URI.ID,
+		}
+		projection = bson.M{
+			"_id":       0,
+			"bandwidth": 1,
+			"duration":  1,
+			"height":    1,
+			"timestamp": 1,
+			"tx_hash":   1,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(bson.D{
+				bson.E{Key: "height", Value: 1},
+			}).
+			SetSkip(skip).
+			SetLimit(limit)
+
+		events, err := database.EventFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		var (
+			prev  *models.Event
+			items = make([]*ResponseSessionUsage, 0, len(events))
+		)
+
+		for i := 0; i < len(events); i++ {
+			if i == 0 && req.Query.Skip > 0 {
+				prev = events[i]
+				continue
+			}
+
+			items = append(items, NewResponseSessionUsage(prev, events[i], session.StartTimestamp, subscription))
+			prev = events[i]
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(items))
+	}
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"github.com/sentinel-official/explorer/database"
+)
+
+func newTestEventDoc(height int64, upload, download string, duration time.Duration) bson.D {
+	return bson.D{
+		bson.E{Key: "height", Value: height},
+		bson.E{Key: "timestamp", Value: testStart.Add(duration)},
+		bson.E{Key: "bandwidth", Value: bson.D{
+			bson.E{Key: "upload", Value: upload},
+			bson.E{Key: "download", Value: download},
+		}},
+		bson.E{Key: "duration", Value: int64(duration)},
+	}
+}
+
+func TestHandlerGetSessionUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("skip keeps the previous update", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db."+database.SessionCollectionName, mtest.FirstBatch, bson.D{
+				bson.E{Key: "start_timestamp", Value: testStart},
+				bson.E{Key: "subscription_id", Value: int64(7)},
+			}),
+			mtest.CreateCursorResponse(0, "db."+database.SubscriptionCollectionName, mtest.FirstBatch, bson.D{
+				bson.E{Key: "hours", Value: int64(4)},
+				bson.E{Key: "deposit", Value: bson.D{
+					bson.E{Key: "denom", Value: "udvpn"},
+					bson.E{Key: "amount", Value: "1000"},
+				}},
+			}),
+			mtest.CreateCursorResponse(0, "db."+database.EventCollectionName, mtest.FirstBatch,
+				newTestEventDoc(10, "1000", "2000", time.Hour),
+				newTestEventDoc(20, "3000", "6000", 2*time.Hour),
+			),
+		)
+
+		router := gin.New()
+		RegisterRoutes(router, mt.DB)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sessions/42/usage?skip=1&limit=1", nil))
+		if w.Code != http.StatusOK {
+			mt.Fatalf("status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+
+		var res struct {
+			Result []*ResponseSessionUsage `json:"result"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			mt.Fatal(err)
+		}
+		if len(res.Result) != 1 {
+			mt.Fatalf("%d items, want 1", len(res.Result))
+		}
+
+		item := res.Result[0]
+		if item.Height != 20 {
+			mt.Errorf("height %d, want 20", item.Height)
+		}
+		if item.BandwidthDelta.Upload != "2000" || item.BandwidthDelta.Download != "4000" {
+			mt.Errorf("bandwidth delta %+v, want 2000/4000", item.BandwidthDelta)
+		}
+		if item.DurationDelta != int64(time.Hour) {
+			mt.Errorf("duration delta %d, want %d", item.DurationDelta, int64(time.Hour))
+		}
+		if item.PaymentDelta == nil || item.PaymentDelta.Amount != "250" {
+			mt.Errorf("payment delta %+v, want 250", item.PaymentDelta)
+		}
+
+		var find bson.Raw
+		for e := mt.GetStartedEvent(); e != nil; e = mt.GetStartedEvent() {
+			if e.CommandName == "find" && e.Command.Lookup("find").StringValue() == database.EventCollectionName {
+				find = e.Command
+			}
+		}
+		if find == nil {
+			mt.Fatal("no find command was sent to events")
+		}
+		if v, ok := find.Lookup("skip").AsInt64OK(); ok && v != 0 {
+			mt.Errorf("events find skip %d, want 0", v)
+		}
+		if v := find.Lookup("limit").AsInt64(); v != 2 {
+			mt.Errorf("events find limit %d, want 2", v)
+		}
+	})
+}
@@ -65,3 +65,25 @@ func NewRequestGetSessionEvents(c *gin.Context) (req *RequestGetSessionEvents, e
 
 	return req, nil
 }
+
+type RequestGetSessionUsage struct {
+	URI struct {
+		ID uint64 `uri:"id"`
+	}
+	Query struct {
+		Skip  int64 `form:"skip" binding:"gte=0"`
+		Limit int64 `form:"limit,default=25" binding:"gte=0,lte=100"`
+	}
+}
+
+func NewRequestGetSessionUsage(c *gin.Context) (req *RequestGetSessionUsage, err error) {
+	req = &RequestGetSessionUsage{}
+	if err = c.ShouldBindUri(&req.URI); err != nil {
+		return nil, err
+	}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
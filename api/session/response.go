@@ -1 +1,156 @@
 package session
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	hubtypes "github.com/sentinel-official/hub/types"
+
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+const (
+	PaymentBasisGigabytes = "gigabytes"
+	PaymentBasisHours     = "hours"
+	PaymentBasisPlan      = "plan"
+)
+
+// ResponseSessionUsage is one interval of a session between two consecutive
+// detail updates. Payment is the amount accrued by the session up to the
+// update and PaymentDelta the amount accrued within the interval. Sessions of
+// a plan subscription report the plan payment basis without an amount, since
+// plans are paid to the provider up front and not per session.
+type ResponseSessionUsage struct {
+	Height    int64     `json:"height"`
+	Timestamp time.Time `json:"timestamp"`
+	TxHash    string    `json:"tx_hash,omitempty"`
+
+	Bandwidth      *types.Bandwidth `json:"bandwidth"`
+	Duration       int64            `json:"duration"`
+	BandwidthDelta *types.Bandwidth `json:"bandwidth_delta"`
+	DurationDelta  int64            `json:"duration_delta"`
+	Throughput     *types.Bandwidth `json:"throughput"`
+	PaymentBasis   string           `json:"payment_basis,omitempty"`
+	Payment        *types.Coin      `json:"payment,omitempty"`
+	PaymentDelta   *types.Coin      `json:"payment_delta,omitempty"`
+}
+
+func NewResponseSessionUsage(prev, curr *models.Event, startTimestamp time.Time, subscription *models.Subscription) *ResponseSessionUsage {
+	var (
+		bandwidth     = types.NewBandwidth(nil)
+		duration      int64
+		prevBandwidth *types.Bandwidth
+		prevTimestamp = startTimestamp
+	)
+
+	if curr.Bandwidth != nil {
+		bandwidth = curr.Bandwidth.Copy()
+	}
+	if prev != nil {
+		if prev.Bandwidth != nil {
+			bandwidth = bandwidth.Sub(prev.Bandwidth)
+		}
+
+		duration = prev.Duration
+		prevBandwidth = prev.Bandwidth
+		prevTimestamp = prev.Timestamp
+	}
+
+	durationDelta := curr.Duration - duration
+
+	interval := time.Duration(durationDelta)
+	if interval <= 0 {
+		interval = curr.Timestamp.Sub(prevTimestamp)
+	}
+
+	throughput := types.NewBandwidth(nil)
+	if seconds := int64(interval / time.Second); seconds > 0 {
+		throughput = &types.Bandwidth{
+			Upload:   utils.MustIntFromString(bandwidth.Upload).QuoRaw(seconds).String(),
+			Download: utils.MustIntFromString(bandwidth.Download).QuoRaw(seconds).String(),
+		}
+	}
+
+	res := &ResponseSessionUsage{
+		Height:         curr.Height,
+		Timestamp:      curr.Timestamp,
+		TxHash:         curr.TxHash,
+		Bandwidth:      curr.Bandwidth,
+		Duration:       curr.Duration,
+		BandwidthDelta: bandwidth,
+		DurationDelta:  durationDelta,
+		Throughput:     throughput,
+		PaymentBasis:   sessionPaymentBasis(subscription),
+	}
+
+	payment := sessionPayment(curr.Bandwidth, curr.Duration, subscription)
+	if payment != nil {
+		res.Payment = &types.Coin{
+			Denom:  subscription.Deposit.Denom,
+			Amount: payment.String(),
+		}
+		res.PaymentDelta = &types.Coin{
+			Denom:  subscription.Deposit.Denom,
+			Amount: payment.String(),
+		}
+
+		if prev != nil {
+			prevPayment := sessionPayment(prevBandwidth, duration, subscription)
+			if prevPayment != nil {
+				res.PaymentDelta.Amount = payment.Sub(*prevPayment).String()
+			}
+		}
+	}
+
+	return res
+}
+
+func sessionPaymentBasis(subscription *models.Subscription) string {
+	switch {
+	case subscription == nil:
+		return ""
+	case subscription.PlanID != 0:
+		return PaymentBasisPlan
+	case subscription.Gigabytes != 0:
+		return PaymentBasisGigabytes
+	case subscription.Hours != 0:
+		return PaymentBasisHours
+	default:
+		return ""
+	}
+}
+
+// sessionPayment prorates the subscription deposit by the bytes or the
+// duration consumed so far, capped at the deposit.
+func sessionPayment(bandwidth *types.Bandwidth, duration int64, subscription *models.Subscription) *sdk.Int {
+	if subscription == nil || subscription.Deposit == nil {
+		return nil
+	}
+
+	var used, total sdk.Int
+	switch sessionPaymentBasis(subscription) {
+	case PaymentBasisGigabytes:
+		if bandwidth == nil {
+			return nil
+		}
+
+		used = utils.MustIntFromString(bandwidth.Upload).Add(utils.MustIntFromString(bandwidth.Download))
+		total = hubtypes.Gigabyte.MulRaw(subscription.Gigabytes)
+	case PaymentBasisHours:
+		used = sdk.NewInt(duration)
+		total = sdk.NewInt(int64(time.Hour)).MulRaw(subscription.Hours)
+	default:
+		return nil
+	}
+
+	deposit := utils.MustIntFromString(subscription.Deposit.Amount)
+
+	amount := sdk.NewDecFromInt(deposit).MulInt(used).QuoInt(total).Ceil().TruncateInt()
+	if amount.GT(deposit) {
+		amount = deposit
+	}
+
+	return &amount
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+)
+
+var (
+	testStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestEvent(upload, download string, duration time.Duration) *models.Event {
+	return &models.Event{
+		Bandwidth: &types.Bandwidth{
+			Upload:   upload,
+			Download: download,
+		},
+		Duration:  int64(duration),
+		Timestamp: testStart.Add(duration),
+	}
+}
+
+func TestNewResponseSessionUsage(t *testing.T) {
+	tests := []struct {
+		name         string
+		prev         *models.Event
+		curr         *models.Event
+		subscription *models.Subscription
+		delta        *types.Bandwidth
+		throughput   *types.Bandwidth
+		basis        string
+		payment      string
+		paymentDelta string
+	}{
+		{
+			name: "first update",
+			curr: newTestEvent("1000", "3000", 10*time.Second),
+			delta: &types.Bandwidth{
+				Upload:   "1000",
+				Download: "3000",
+			},
+			throughput: &types.Bandwidth{
+				Upload:   "100",
+				Download: "300",
+			},
+		},
+		{
+			name: "gigabytes",
+			prev: newTestEvent("100000000", "400000000", time.Minute),
+			curr: newTestEvent("200000000", "800000000", 2*time.Minute),
+			subscription: &models.Subscription{
+				Gigabytes: 2,
+				Deposit: &types.Coin{
+					Denom:  "udvpn",
+					Amount: "1000",
+				},
+			},
+			delta: &types.Bandwidth{
+				Upload:   "100000000",
+				Download: "400000000",
+			},
+			throughput: &types.Bandwidth{
+				Upload:   "1666666",
+				Download: "6666666",
+			},
+			basis:        PaymentBasisGigabytes,
+			payment:      "500",
+			paymentDelta: "250",
+		},
+		{
+			name: "gigabytes capped at deposit",
+			curr: newTestEvent("2000000000", "2000000000", time.Hour),
+			subscription: &models.Subscription{
+				Gigabytes: 2,
+				Deposit: &types.Coin{
+					Denom:  "udvpn",
+					Amount: "1000",
+				},
+			},
+			delta: &types.Bandwidth{
+				Upload:   "2000000000",
+				Download: "2000000000",
+			},
+			throughput: &types.Bandwidth{
+				Upload:   "555555",
+				Download: "555555",
+			},
+			basis:        PaymentBasisGigabytes,
+			payment:      "1000",
+			paymentDelta: "1000",
+		},
+		{
+			name: "hours",
+			prev: newTestEvent("0", "0", time.Hour),
+			curr: newTestEvent("0", "0", 3*time.Hour),
+			subscription: &models.Subscription{
+				Hours: 4,
+				Deposit: &types.Coin{
+					Denom:  "udvpn",
+					Amount: "1000",
+				},
+			},
+			delta: &types.Bandwidth{
+				Upload:   "0",
+				Download: "0",
+			},
+			throughput: &types.Bandwidth{
+				Upload:   "0",
+				Download: "0",
+			},
+			basis:        PaymentBasisHours,
+			payment:      "750",
+			paymentDelta: "500",
+		},
+		{
+			name: "plan",
+			curr: newTestEvent("1000", "1000", 10*time.Second),
+			subscription: &models.Subscription{
+				PlanID: 1,
+			},
+			delta: &types.Bandwidth{
+				Upload:   "1000",
+				Download: "1000",
+			},
+			throughput: &types.Bandwidth{
+				Upload:   "100",
+				Download: "100",
+			},
+			basis: PaymentBasisPlan,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := NewResponseSessionUsage(tt.prev, tt.curr, testStart, tt.subscription)
+
+			if *res.BandwidthDelta != *tt.delta {
+				t.Errorf("bandwidth delta %+v, want %+v", res.BandwidthDelta, tt.delta)
+			}
+			if *res.Throughput != *tt.throughput {
+				t.Errorf("throughput %+v, want %+v", res.Throughput, tt.throughput)
+			}
+			if res.PaymentBasis != tt.basis {
+				t.Errorf("payment basis %q, want %q", res.PaymentBasis, tt.basis)
+			}
+
+			if tt.payment == "" {
+				if res.Payment != nil || res.PaymentDelta != nil {
+					t.Errorf("payment %+v and delta %+v, want none", res.Payment, res.PaymentDelta)
+				}
+
+				return
+			}
+			if res.Payment == nil || res.Payment.Amount != tt.payment {
+				t.Errorf("payment %+v, want %s", res.Payment, tt.payment)
+			}
+			if res.PaymentDelta == nil || res.PaymentDelta.Amount != tt.paymentDelta {
+				t.Errorf("payment delta %+v, want %s", res.PaymentDelta, tt.paymentDelta)
+			}
+		})
+	}
+}
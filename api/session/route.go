@@ -13,6 +13,7 @@ func RegisterRoutes(router gin.IRouter, db *mongo.Database) {
 	router.GET("/sessions", HandlerGetSessions(db))
 	router.GET("/sessions/:id", HandlerGetSession(db))
 	router.GET("/sessions/:id/events", HandlerGetSessionEvents(db))
+	router.GET("/sessions/:id/usage", HandlerGetSessionUsage(db))
 
 	router.GET("/subscriptions/:id/sessions", HandlerGetSessions(db))
 }
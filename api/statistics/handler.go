@@ -9,132 +9,257 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/sentinel-official/explorer/database"
 	"github.com/sentinel-official/explorer/types"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
 )
 
-func HandlerGetStatistics(db *mongo.Database, excludeAddrs []string) gin.HandlerFunc {
-	requestHandlers := map[string]func(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error){
-		types.StatisticMethodAverageActiveNodeCount:            handleAverageActiveNodeCount,
-		types.StatisticMethodAverageActiveSessionCount:         handleAverageActiveSessionCount,
-		types.StatisticMethodAverageActiveSubscriptionCount:    handleAverageActiveSubscriptionCount,
-		types.StatisticMethodAverageBytesPayment:               handleAverageBytesPayment,
-		types.StatisticMethodAverageBytesStakingReward:         handleAverageBytesStakingReward,
-		types.StatisticMethodAverageEndSessionCount:            handleAverageEndSessionCount,
-		types.StatisticMethodAverageEndSubscriptionCount:       handleAverageEndSubscriptionCount,
-		types.StatisticMethodAveragePlanPayment:                handleAveragePlanPayment,
-		types.StatisticMethodAveragePlanStakingReward:          handleAveragePlanStakingReward,
-		types.StatisticMethodAverageRegisterNodeCount:          handleAverageRegisterNodeCount,
-		types.StatisticMethodAverageStartSessionCount:          handleAverageStartSessionCount,
-		types.StatisticMethodAverageStartSubscriptionCount:     handleAverageStartSubscriptionCount,
-		types.StatisticMethodAverageSubscriptionDeposit:        handleAverageSubscriptionDeposit,
-		types.StatisticMethodCurrentNodeCount:                  handleCurrentNodeCount,
-		types.StatisticMethodCurrentSessionAddressCount:        handleCurrentSessionAddressCount(excludeAddrs),
-		types.StatisticMethodCurrentSessionCount:               handleCurrentSessionCount(excludeAddrs),
-		types.StatisticMethodCurrentSessionNodeCount:           handleCurrentSessionNodeCount(excludeAddrs),
-		types.StatisticMethodCurrentSubscriptionCount:          handleCurrentSubscriptionCount(excludeAddrs),
-		types.StatisticMethodHistoricalActiveNodeCount:         handleHistoricalActiveNodeCount,
-		types.StatisticMethodHistoricalActiveSessionCount:      handleHistoricalActiveSessionCount,
-		types.StatisticMethodHistoricalActiveSubscriptionCount: handleHistoricalActiveSubscriptionCount,
-		types.StatisticMethodHistoricalBytesPayment:            handleHistoricalBytesPayment,
-		types.StatisticMethodHistoricalBytesStakingReward:      handleHistoricalBytesStakingReward,
-		types.StatisticMethodHistoricalEndSessionCount:         handleHistoricalEndSessionCount,
-		types.StatisticMethodHistoricalEndSubscriptionCount:    handleHistoricalEndSubscriptionCount,
-		types.StatisticMethodHistoricalHoursPayment:            handleHistoricalHoursPayment,
-		types.StatisticMethodHistoricalHoursStakingReward:      handleHistoricalHoursStakingReward,
-		types.StatisticMethodHistoricalPlanPayment:             handleHistoricalPlanPayment,
-		types.StatisticMethodHistoricalPlanStakingReward:       handleHistoricalPlanStakingReward,
-		types.StatisticMethodHistoricalRegisterNodeCount:       handleHistoricalRegisterNodeCount,
-		types.StatisticMethodHistoricalSessionAddressCount:     handleHistoricalSessionAddressCount,
-		types.StatisticMethodHistoricalSessionBytes:            handleHistoricalSessionBytes,
-		types.StatisticMethodHistoricalSessionDuration:         handleHistoricalSessionDuration,
-		types.StatisticMethodHistoricalSessionNodeCount:        handleHistoricalSessionNodeCount,
-		types.StatisticMethodHistoricalStartSessionCount:       handleHistoricalStartSessionCount,
-		types.StatisticMethodHistoricalStartSubscriptionCount:  handleHistoricalStartSubscriptionCount,
-		types.StatisticMethodHistoricalSubscriptionDeposit:     handleHistoricalSubscriptionDeposit,
-		types.StatisticMethodTotalBytesPayment:                 handleTotalBytesPayment,
-		types.StatisticMethodTotalBytesStakingReward:           handleTotalBytesStakingReward,
-		types.StatisticMethodTotalHoursPayment:                 handleTotalHoursPayment,
-		types.StatisticMethodTotalHoursStakingReward:           handleTotalHoursStakingReward,
-		types.StatisticMethodTotalPlanPayment:                  handleTotalPlanPayment,
-		types.StatisticMethodTotalPlanStakingReward:            handleTotalPlanStakingReward,
-		types.StatisticMethodTotalSessionBytes:                 handleTotalSessionBytes,
-		types.StatisticMethodTotalSessionDuration:              handleTotalSessionDuration,
-		types.StatisticMethodTotalSubscriptionDeposit:          handleTotalSubscriptionDeposit,
+var (
+	groupByKeys = map[string]string{
+		types.StatisticTypeActiveNode:     "active_node",
+		types.StatisticTypeActiveSession:  "active_session",
+		types.StatisticTypeBytesPayment:   "bytes_earning",
+		types.StatisticTypeEndSession:     "end_session",
+		types.StatisticTypeHoursPayment:   "hours_earning",
+		types.StatisticTypeSessionAddress: "session_address",
+		types.StatisticTypeSessionBytes:   "session_bandwidth",
+		types.StatisticTypeStartSession:   "start_session",
 	}
 
+	aggregationOperators = map[string]string{
+		types.StatisticAggregationAvg:  "$avg",
+		types.StatisticAggregationLast: "$last",
+		types.StatisticAggregationMax:  "$max",
+		types.StatisticAggregationMin:  "$min",
+		types.StatisticAggregationSum:  "$sum",
+	}
+)
+
+type handlerFunc func(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error)
+
+func currentHandlers(excludeAddrs []string) map[string]handlerFunc {
+	return map[string]handlerFunc{
+		types.StatisticMethodCurrentDistinctOperatorCount: handleCurrentDistinctOperatorCount,
+		types.StatisticMethodCurrentNodeCount:             handleCurrentNodeCount,
+		types.StatisticMethodCurrentSessionAddressCount:   handleCurrentSessionAddressCount(excludeAddrs),
+		types.StatisticMethodCurrentSessionCount:          handleCurrentSessionCount(excludeAddrs),
+		types.StatisticMethodCurrentSessionNodeCount:      handleCurrentSessionNodeCount(excludeAddrs),
+		types.StatisticMethodCurrentSubscriptionCount:     handleCurrentSubscriptionCount(excludeAddrs),
+	}
+}
+
+func resolveHandler(requestHandlers map[string]handlerFunc, req *RequestGetStatistics) (handlerFunc, error) {
+	if req.Query.GroupBy != "" {
+		key, ok := groupByKeys[req.Query.Type]
+		if !ok || req.Query.Aggregation != "" {
+			return nil, fmt.Errorf("type %s does not support group_by", req.Query.Type)
+		}
+		if types.RollingTimeframeDays(req.Query.Timeframe) != 0 {
+			return nil, fmt.Errorf("timeframe %s does not support group_by", req.Query.Timeframe)
+		}
+
+		return func(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
+			return handleGroupBy(db, key, req)
+		}, nil
+	}
+
+	if req.Query.Type != "" {
+		return handleQuery, nil
+	}
+
+	v, ok := requestHandlers[req.Query.Method]
+	if !ok {
+		return nil, fmt.Errorf("unknown method %s", req.Query.Method)
+	}
+
+	return v, nil
+}
+
+func HandlerGetStatistics(db *mongo.Database, excludeAddrs []string, timezones types.Timezones) gin.HandlerFunc {
+	requestHandlers := currentHandlers(excludeAddrs)
+
 	return func(c *gin.Context) {
-		req, err := NewRequestGetStatistics(c)
+		req, err := NewRequestGetStatistics(c, timezones)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
 			return
 		}
 
-		handlerFunc, ok := requestHandlers[req.Query.Method]
-		if !ok {
-			err := fmt.Errorf("unknown method %s", req.Query.Method)
+		hFunc, err := resolveHandler(requestHandlers, req)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
 			return
 		}
 
-		result, err := handlerFunc(db, req)
+		result, err := hFunc(db, req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
 			return
 		}
 
+		if req.Query.Format == "columnar" {
+			c.JSON(http.StatusOK, types.NewResponseResult(types.ColumnarStatistics(result, "timestamp", "value")))
+			return
+		}
+
 		c.JSON(http.StatusOK, types.NewResponseResult(result))
 	}
 }
 
-func handleAverage(db *mongo.Database, t, unwind, _id, value string, req *RequestGetStatistics) ([]bson.M, error) {
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"type":      t,
-				"timeframe": req.Query.Timeframe,
-				"timestamp": bson.M{
-					"$gte": req.Query.FromTimestamp,
-					"$lt":  req.Query.ToTimestamp,
+func HandlerPostStatisticsBatch(db *mongo.Database, excludeAddrs []string, timezones types.Timezones) gin.HandlerFunc {
+	requestHandlers := currentHandlers(excludeAddrs)
+
+	return func(c *gin.Context) {
+		req, err := NewRequestPostStatisticsBatch(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		hFuncs := make([]handlerFunc, len(req.Items))
+		for i := range req.Items {
+			hFuncs[i], err = resolveHandler(requestHandlers, req.Items[i])
+			if err != nil {
+				err = fmt.Errorf("query %d: %w", i, err)
+				c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+				return
+			}
+		}
+
+		var (
+			current  = make([][]bson.M, len(req.Items))
+			previous = make([][]bson.M, len(req.Previous))
+			group    = errgroup.Group{}
+		)
+
+		group.SetLimit(8)
+		for i := range req.Items {
+			i := i
+			group.Go(func() (err error) {
+				current[i], err = hFuncs[i](db, req.Items[i])
+				return err
+			})
+		}
+		for i := range req.Previous {
+			i := i
+			group.Go(func() (err error) {
+				previous[i], err = hFuncs[i](db, req.Previous[i])
+				return err
+			})
+		}
+
+		if err := group.Wait(); err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		result := make([]bson.M, 0, len(req.Items))
+		for i, query := range req.Body.Queries {
+			item := bson.M{
+				"id":     query.ID,
+				"result": current[i],
+			}
+			if req.Body.Compare {
+				item["previous"] = previous[i]
+				item["deltas"] = types.NewStatisticDeltas(current[i], previous[i], types.BucketTimeframe(req.Items[i].Query.Timeframe), req.Items[i].Query.FromTimestamp, req.Previous[i].Query.FromTimestamp, req.Items[i].Location)
+			}
+
+			if req.Items[i].Query.Format == "columnar" {
+				item["result"] = types.ColumnarStatistics(current[i], "timestamp", "value")
+				if req.Body.Compare {
+					item["previous"] = types.ColumnarStatistics(previous[i], "timestamp", "value")
+				}
+			}
+
+			result = append(result, item)
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
+}
+
+func handleQuery(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
+	if req.Query.Aggregation != "" {
+		return handleAggregate(db, req)
+	}
+
+	days := types.RollingTimeframeDays(req.Query.Timeframe)
+	if days != 0 {
+		return handleHistoricalRolling(db, days, req)
+	}
+
+	return handleHistorical(db, req)
+}
+
+func seriesValue(req *RequestGetStatistics) interface{} {
+	var value interface{} = "$value"
+
+	switch types.StatisticTypeValueKinds[req.Query.Type] {
+	case types.StatisticValueKindBandwidth, types.StatisticValueKindHistogram:
+		if req.Query.Field != "" {
+			value = "$value." + req.Query.Field
+		}
+	case types.StatisticValueKindCoins, types.StatisticValueKindHistograms:
+		if req.Query.Denom != "" {
+			value = bson.M{
+				"$filter": bson.M{
+					"input": value,
+					"cond":  bson.M{"$eq": bson.A{"$$this.denom", req.Query.Denom}},
 				},
-			},
-		},
-		{
-			"$unwind": unwind,
-		},
-		{
-			"$group": bson.M{
-				"_id": _id,
-				"value": bson.M{
-					"$avg": value,
+			}
+		}
+		if req.Query.Field != "" {
+			value = bson.M{
+				"$map": bson.M{
+					"input": value,
+					"in": bson.M{
+						"denom":         "$$this.denom",
+						req.Query.Field: "$$this." + req.Query.Field,
+					},
 				},
-			},
-		},
-		{
-			"$sort": bson.D{
-				bson.E{Key: "_id", Value: 1},
-			},
-		},
+			}
+		}
 	}
 
-	return database.StatisticAggregateAll(context.TODO(), db, pipeline)
+	return value
 }
 
-func handleHistorical(db *mongo.Database, t string, req *RequestGetStatistics) ([]bson.M, error) {
+func handleHistorical(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
 	filter := bson.M{
-		"type":      t,
+		"type":      req.Query.Type,
 		"timeframe": req.Query.Timeframe,
 		"timestamp": bson.M{
 			"$gte": req.Query.FromTimestamp,
 			"$lt":  req.Query.ToTimestamp,
 		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
 	}
 	projection := bson.M{
 		"_id":       0,
 		"timestamp": 1,
-		"value":     1,
+		"value":     seriesValue(req),
 	}
+
+	if req.Query.Fill != "" {
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(bson.D{
+				bson.E{Key: "timestamp", Value: 1},
+			})
+
+		items, err := database.StatisticFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		result := fillSeries(items, req.Query.Timeframe, req)
+		types.SortStatistics(result, req.Sort)
+
+		return types.PaginateStatistics(result, req.Query.Skip, req.Query.Limit), nil
+	}
+
 	opts := options.Find().
 		SetProjection(projection).
 		SetSort(req.Sort).
@@ -144,103 +269,221 @@ func handleHistorical(db *mongo.Database, t string, req *RequestGetStatistics) (
 	return database.StatisticFind(context.TODO(), db, filter, opts)
 }
 
-func handleTotal(db *mongo.Database, t, unwind, _id, value interface{}, req *RequestGetStatistics) ([]bson.M, error) {
+func handleHistoricalRolling(db *mongo.Database, days int, req *RequestGetStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"type":      req.Query.Type,
+		"timeframe": types.BucketTimeframe(req.Query.Timeframe),
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp.AddDate(0, 0, 1-days),
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"timestamp": 1,
+		"value":     seriesValue(req),
+	}
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "timestamp", Value: 1},
+		})
+
+	items, err := database.StatisticFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := types.NewRollingStatistics(items, days, req.Query.FromTimestamp, req.Query.ToTimestamp, req.Location, "value")
+	if req.Query.Fill != "" {
+		result = fillSeries(result, types.BucketTimeframe(req.Query.Timeframe), req)
+	}
+
+	types.SortStatistics(result, req.Sort)
+
+	return types.PaginateStatistics(result, req.Query.Skip, req.Query.Limit), nil
+}
+
+func fillSeries(items []bson.M, timeframe string, req *RequestGetStatistics) []bson.M {
+	var zero interface{}
+
+	switch types.StatisticTypeValueKinds[req.Query.Type] {
+	case types.StatisticValueKindAmount:
+		zero = "0"
+	case types.StatisticValueKindBandwidth:
+		zero = bson.M{"download": "0", "upload": "0"}
+		if req.Query.Field != "" {
+			zero = "0"
+		}
+	case types.StatisticValueKindCoins, types.StatisticValueKindHistograms:
+		zero = bson.A{}
+	case types.StatisticValueKindHistogram:
+		zero = types.NewHistogram().Result()
+		if req.Query.Field != "" {
+			zero = 0
+		}
+	case types.StatisticValueKindNumber:
+		zero = 0
+	}
+
+	zeros := bson.M{
+		"value": zero,
+	}
+
+	return types.FillStatistics(items, timeframe, req.Query.Fill, req.Query.FromTimestamp, req.Query.ToTimestamp, req.Location, zeros)
+}
+
+func handleAggregate(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
+	operator := aggregationOperators[req.Query.Aggregation]
+	numeric := func(v string) interface{} {
+		switch req.Query.Aggregation {
+		case types.StatisticAggregationLast:
+			return v
+		case types.StatisticAggregationSum:
+			return bson.M{"$toLong": v}
+		default:
+			return bson.M{"$toDouble": v}
+		}
+	}
+
 	pipeline := []bson.M{
 		{
 			"$match": bson.M{
-				"type":      t,
-				"timeframe": req.Query.Timeframe,
+				"type":      req.Query.Type,
+				"timeframe": types.BucketTimeframe(req.Query.Timeframe),
 				"timestamp": bson.M{
 					"$gte": req.Query.FromTimestamp,
 					"$lt":  req.Query.ToTimestamp,
 				},
+				"tz": types.TimezoneFilter(req.Query.Timezone),
 			},
 		},
+		{
+			"$sort": bson.D{
+				bson.E{Key: "timestamp", Value: 1},
+			},
+		},
+	}
+
+	group := bson.M{
+		"_id": nil,
 	}
 
-	if unwind != nil {
+	switch kind := types.StatisticTypeValueKinds[req.Query.Type]; kind {
+	case types.StatisticValueKindCoins, types.StatisticValueKindHistograms:
+		pipeline = append(pipeline, bson.M{"$unwind": "$value"})
+		if req.Query.Denom != "" {
+			pipeline = append(pipeline, bson.M{"$match": bson.M{"value.denom": req.Query.Denom}})
+		}
+
+		key := "amount"
+		if kind == types.StatisticValueKindHistograms {
+			key = req.Query.Field
+		}
+
+		group["_id"] = "$value.denom"
+		group["value"] = bson.M{operator: numeric("$value." + key)}
+	case types.StatisticValueKindBandwidth:
+		if req.Query.Field != "" {
+			group["value"] = bson.M{operator: numeric("$value." + req.Query.Field)}
+			break
+		}
+
+		group["download"] = bson.M{operator: numeric("$value.download")}
+		group["upload"] = bson.M{operator: numeric("$value.upload")}
+	case types.StatisticValueKindHistogram:
+		group["value"] = bson.M{operator: "$value." + req.Query.Field}
+	case types.StatisticValueKindAmount:
+		group["value"] = bson.M{operator: numeric("$value")}
+	default:
+		group["value"] = bson.M{operator: "$value"}
+	}
+
+	pipeline = append(pipeline, bson.M{"$group": group})
+
+	if _, ok := group["download"]; ok {
 		pipeline = append(
 			pipeline,
 			bson.M{
-				"$unwind": unwind,
+				"$project": bson.M{
+					"_id": "$_id",
+					"value": bson.M{
+						"download": "$download",
+						"upload":   "$upload",
+					},
+				},
 			},
 		)
 	}
 
 	pipeline = append(
 		pipeline,
-		[]bson.M{
-			{
-				"$group": bson.M{
-					"_id": _id,
-					"value": bson.M{
-						"$sum": bson.M{
-							"$toLong": value,
-						},
-					},
-				},
-			},
-			{
-				"$sort": bson.D{
-					bson.E{Key: "_id", Value: 1},
-				},
+		bson.M{
+			"$sort": bson.D{
+				bson.E{Key: "_id", Value: 1},
 			},
-		}...,
+		},
 	)
 
 	return database.StatisticAggregateAll(context.TODO(), db, pipeline)
 }
 
-func handleAverageActiveNodeCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeActiveNode, "$_id", "", "$value", req)
-}
-
-func handleAverageActiveSessionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeActiveSession, "$_id", "", "$value", req)
-}
-
-func handleAverageActiveSubscriptionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeActiveSubscription, "$_id", "", "$value", req)
-}
-
-func handleAverageEndSessionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeEndSession, "$_id", "", "$value", req)
-}
-
-func handleAverageEndSubscriptionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeEndSubscription, "$_id", "", "$value", req)
-}
-
-func handleAverageRegisterNodeCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeRegisterNode, "$_id", "", "$value", req)
-}
-
-func handleAverageBytesPayment(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeBytesPayment, "$value", "$value.denom", "$value.amount", req)
-}
-
-func handleAverageBytesStakingReward(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeBytesStakingReward, "$value", "$value.denom", "$value.amount", req)
-}
+func handleGroupBy(db *mongo.Database, key string, req *RequestGetStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"city": bson.M{
+			"$exists": req.Query.GroupBy == "city",
+		},
+		key: bson.M{
+			"$exists": true,
+		},
+		"timeframe": req.Query.Timeframe,
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp,
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
+	if req.Query.Country != "" {
+		filter["country"] = req.Query.Country
+	}
 
-func handleAverageStartSessionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeStartSession, "$_id", "", "$value", req)
-}
+	projection := bson.M{
+		"_id":       0,
+		"city":      1,
+		"country":   1,
+		"timestamp": 1,
+		"value":     "$" + key,
+	}
 
-func handleAverageStartSubscriptionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeStartSubscription, "$_id", "", "$value", req)
-}
+	sort := bson.D{
+		bson.E{Key: "timestamp", Value: 1},
+	}
+	if len(req.Sort) != 0 {
+		sort = req.Sort
+	}
 
-func handleAverageSubscriptionDeposit(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypeSubscriptionDeposit, "$value", "$value.denom", "$value.amount", req)
-}
+	sort = append(sort, bson.E{Key: "country", Value: 1}, bson.E{Key: "city", Value: 1})
 
-func handleAveragePlanPayment(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypePlanPayment, "$value", "$value.denom", "$value.amount", req)
-}
+	pipeline := []bson.M{
+		{
+			"$match": filter,
+		},
+		{
+			"$project": projection,
+		},
+		{
+			"$sort": sort,
+		},
+		{
+			"$skip": req.Query.Skip,
+		},
+	}
+	if req.Query.Limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": req.Query.Limit})
+	}
 
-func handleAveragePlanStakingReward(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleAverage(db, types.StatisticTypePlanStakingReward, "$value", "$value.denom", "$value.amount", req)
+	return database.CountryStatisticAggregateAll(context.TODO(), db, pipeline)
 }
 
 func handleCurrentNodeCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
@@ -266,6 +509,46 @@ func handleCurrentNodeCount(db *mongo.Database, req *RequestGetStatistics) ([]bs
 	}, err
 }
 
+func handleCurrentDistinctOperatorCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
+	filter := bson.M{}
+	if req.Query.Status != "" {
+		filter["status"] = req.Query.Status
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": filter,
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"$ifNull": bson.A{"$cluster.id", "$addr"},
+				},
+			},
+		},
+		{
+			"$count": "value",
+		},
+	}
+
+	items, err := database.NodeAggregateAll(context.TODO(), db, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var count interface{} = 0
+	if len(items) > 0 {
+		count = items[0]["value"]
+	}
+
+	return []bson.M{
+		{
+			"_id":   nil,
+			"value": count,
+		},
+	}, nil
+}
+
 func handleCurrentSessionCount(excludeAddrs []string) func(*mongo.Database, *RequestGetStatistics) ([]bson.M, error) {
 	return func(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
 		filter := bson.M{
@@ -366,151 +649,357 @@ func handleCurrentSessionNodeCount(excludeAddrs []string) func(*mongo.Database,
 	}
 }
 
-func handleHistoricalActiveNodeCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeActiveNode, req)
-}
-
-func handleHistoricalActiveSessionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeActiveSession, req)
-}
-
-func handleHistoricalSessionAddressCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeSessionAddress, req)
-}
-
-func handleHistoricalActiveSubscriptionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeActiveSubscription, req)
-}
-
-func handleHistoricalSessionBytes(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeSessionBytes, req)
-}
-
-func handleHistoricalEndSessionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeEndSession, req)
-}
-
-func handleHistoricalEndSubscriptionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeEndSubscription, req)
-}
-
-func handleHistoricalRegisterNodeCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeRegisterNode, req)
-}
-
-func handleHistoricalSessionDuration(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeSessionDuration, req)
-}
-
-func handleHistoricalBytesPayment(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeBytesPayment, req)
-}
+func HandlerGetCohortStatistics(db *mongo.Database, timezones types.Timezones) gin.HandlerFunc {
+	requestHandlers := map[string]func(db *mongo.Database, req *RequestGetCohortStatistics) ([]bson.M, error){
+		types.StatisticMethodCohortNewReturning: handleCohortNewReturning,
+		types.StatisticMethodCohortRetention:    handleCohortRetention,
+	}
 
-func handleHistoricalHoursStakingReward(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeHoursStakingReward, req)
-}
+	return func(c *gin.Context) {
+		req, err := NewRequestGetCohortStatistics(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
 
-func handleHistoricalBytesStakingReward(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeBytesStakingReward, req)
-}
+		result, err := requestHandlers[req.Query.Method](db, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
 
-func handleHistoricalStartSessionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeStartSession, req)
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
 }
 
-func handleHistoricalSessionNodeCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeSessionNode, req)
-}
+func handleCohortRetention(db *mongo.Database, req *RequestGetCohortStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"cohort": bson.M{
+			"$gte": req.Query.FromTimestamp,
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"timeframe": req.Query.Timeframe,
+		"tz":        types.TimezoneFilter(req.Query.Timezone),
+	}
+	if req.Query.MaxPeriod > 0 {
+		filter["period"] = bson.M{
+			"$lte": req.Query.MaxPeriod,
+		}
+	}
 
-func handleHistoricalStartSubscriptionCount(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeStartSubscription, req)
-}
+	sort := bson.D{
+		bson.E{Key: "timestamp", Value: 1},
+	}
+	if len(req.Sort) != 0 {
+		sort = req.Sort
+	}
 
-func handleHistoricalSubscriptionDeposit(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeSubscriptionDeposit, req)
-}
+	pipeline := []bson.M{
+		{
+			"$match": filter,
+		},
+		{
+			"$sort": bson.D{
+				bson.E{Key: "cohort", Value: 1},
+				bson.E{Key: "period", Value: 1},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":  "$cohort",
+				"size": bson.M{"$first": "$size"},
+				"periods": bson.M{
+					"$push": bson.M{
+						"active":    "$active",
+						"period":    "$period",
+						"retention": bson.M{"$divide": bson.A{"$active", "$size"}},
+						"timestamp": "$timestamp",
+					},
+				},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":       0,
+				"periods":   1,
+				"size":      1,
+				"timestamp": "$_id",
+			},
+		},
+		{
+			"$sort": sort,
+		},
+		{
+			"$skip": req.Query.Skip,
+		},
+	}
+	if req.Query.Limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": req.Query.Limit})
+	}
 
-func handleHistoricalPlanPayment(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypePlanPayment, req)
+	return database.CohortStatisticAggregateAll(context.TODO(), db, pipeline)
 }
 
-func handleHistoricalHoursPayment(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypeHoursPayment, req)
-}
+func handleCohortNewReturning(db *mongo.Database, req *RequestGetCohortStatistics) ([]bson.M, error) {
+	filter := bson.M{
+		"timeframe": req.Query.Timeframe,
+		"timestamp": bson.M{
+			"$gte": req.Query.FromTimestamp,
+			"$lt":  req.Query.ToTimestamp,
+		},
+		"tz": types.TimezoneFilter(req.Query.Timezone),
+	}
 
-func handleHistoricalPlanStakingReward(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleHistorical(db, types.StatisticTypePlanStakingReward, req)
-}
+	sort := bson.D{
+		bson.E{Key: "timestamp", Value: 1},
+	}
+	if len(req.Sort) != 0 {
+		sort = req.Sort
+	}
 
-func handleTotalSessionBytes(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
 	pipeline := []bson.M{
 		{
-			"$match": bson.M{
-				"type":      types.StatisticTypeSessionBytes,
-				"timeframe": req.Query.Timeframe,
-				"timestamp": bson.M{
-					"$gte": req.Query.FromTimestamp,
-					"$lt":  req.Query.ToTimestamp,
-				},
-			},
+			"$match": filter,
 		},
 		{
 			"$group": bson.M{
-				"_id": nil,
-				"download": bson.M{
+				"_id": "$timestamp",
+				"new": bson.M{
 					"$sum": bson.M{
-						"$toLong": "$value.download",
+						"$cond": bson.A{bson.M{"$eq": bson.A{"$period", 0}}, "$active", 0},
 					},
 				},
-				"upload": bson.M{
+				"returning": bson.M{
 					"$sum": bson.M{
-						"$toLong": "$value.upload",
+						"$cond": bson.A{bson.M{"$eq": bson.A{"$period", 0}}, 0, "$active"},
 					},
 				},
 			},
 		},
 		{
 			"$project": bson.M{
-				"_id": "$_id",
-				"value": bson.M{
-					"download": "$download",
-					"upload":   "$upload",
-				},
+				"_id":       0,
+				"new":       1,
+				"returning": 1,
+				"timestamp": "$_id",
+				"total":     bson.M{"$add": bson.A{"$new", "$returning"}},
 			},
 		},
+		{
+			"$sort": sort,
+		},
+		{
+			"$skip": req.Query.Skip,
+		},
+	}
+	if req.Query.Limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": req.Query.Limit})
 	}
 
-	return database.StatisticAggregateAll(context.TODO(), db, pipeline)
+	return database.CohortStatisticAggregateAll(context.TODO(), db, pipeline)
 }
 
-func handleTotalSessionDuration(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleTotal(db, types.StatisticTypeSessionDuration, nil, nil, "$value", req)
-}
+func HandlerGetNodeSoftwareStatistics(db *mongo.Database, timezones types.Timezones) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetNodeSoftwareStatistics(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
 
-func handleTotalBytesPayment(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleTotal(db, types.StatisticTypeBytesPayment, "$value", "$value.denom", "$value.amount", req)
-}
+		filter := bson.M{
+			"group":     req.Query.Group,
+			"timeframe": req.Query.Timeframe,
+			"timestamp": bson.M{
+				"$gte": req.Query.FromTimestamp,
+				"$lt":  req.Query.ToTimestamp,
+			},
+			"tz": types.TimezoneFilter(req.Query.Timezone),
+		}
+		if req.Query.Key != "" {
+			filter["key"] = req.Query.Key
+		}
 
-func handleTotalHoursPayment(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleTotal(db, types.StatisticTypeHoursPayment, "$value", "$value.denom", "$value.amount", req)
-}
+		sort := bson.D{
+			bson.E{Key: "timestamp", Value: 1},
+		}
+		if len(req.Sort) != 0 {
+			sort = req.Sort
+		}
 
-func handleTotalBytesStakingReward(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleTotal(db, types.StatisticTypeBytesStakingReward, "$value", "$value.denom", "$value.amount", req)
-}
+		sort = append(sort, bson.E{Key: "key", Value: 1})
 
-func handleTotalHoursStakingReward(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleTotal(db, types.StatisticTypeHoursStakingReward, "$value", "$value.denom", "$value.amount", req)
-}
+		projection := bson.M{
+			"_id":        0,
+			"key":        1,
+			"node_count": 1,
+			"share":      1,
+			"timestamp":  1,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(sort).
+			SetSkip(req.Query.Skip).
+			SetLimit(req.Query.Limit)
 
-func handleTotalSubscriptionDeposit(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleTotal(db, types.StatisticTypeSubscriptionDeposit, "$value", "$value.denom", "$value.amount", req)
+		result, err := database.NodeSoftwareStatisticFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
 }
 
-func handleTotalPlanPayment(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleTotal(db, types.StatisticTypePlanPayment, "$value", "$value.denom", "$value.amount", req)
+func HandlerGetNodeSoftwareAdoption(db *mongo.Database, timezones types.Timezones) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetNodeSoftwareAdoption(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"group":     "version",
+			"timeframe": req.Query.Timeframe,
+			"timestamp": bson.M{
+				"$gte": req.Query.FromTimestamp,
+				"$lt":  req.Query.ToTimestamp,
+			},
+			"tz": types.TimezoneFilter(req.Query.Timezone),
+		}
+
+		sort := bson.D{
+			bson.E{Key: "timestamp", Value: 1},
+		}
+		if len(req.Sort) != 0 {
+			sort = req.Sort
+		}
+
+		pipeline := []bson.M{
+			{
+				"$match": filter,
+			},
+			{
+				"$group": bson.M{
+					"_id": "$timestamp",
+					"versions": bson.M{
+						"$push": bson.M{
+							"key":        "$key",
+							"node_count": "$node_count",
+						},
+					},
+				},
+			},
+			{
+				"$project": bson.M{
+					"_id":       0,
+					"timestamp": "$_id",
+					"versions":  1,
+				},
+			},
+			{
+				"$sort": sort,
+			},
+			{
+				"$skip": req.Query.Skip,
+			},
+		}
+		if req.Query.Limit > 0 {
+			pipeline = append(pipeline, bson.M{"$limit": req.Query.Limit})
+		}
+
+		items, err := database.NodeSoftwareStatisticAggregateAll(context.TODO(), db, pipeline)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		result := make([]bson.M, 0, len(items))
+		for _, item := range items {
+			var count, total int64
+			versions, _ := item["versions"].(bson.A)
+			for _, v := range versions {
+				version, ok := v.(bson.M)
+				if !ok {
+					continue
+				}
+
+				key := types.StringFromInterface(version["key"])
+				value := types.Int64FromInterface(version["node_count"])
+
+				total = total + value
+				if key == req.Query.Version || (req.Query.Mode == "at_least" && nodetypes.CompareVersions(key, req.Query.Version) >= 0) {
+					count = count + value
+				}
+			}
+
+			share := 0.0
+			if total > 0 {
+				share = float64(count) / float64(total)
+			}
+
+			result = append(result, bson.M{
+				"node_count": count,
+				"share":      share,
+				"timestamp":  item["timestamp"],
+				"total":      total,
+			})
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
 }
 
-func handleTotalPlanStakingReward(db *mongo.Database, req *RequestGetStatistics) ([]bson.M, error) {
-	return handleTotal(db, types.StatisticTypePlanStakingReward, "$value", "$value.denom", "$value.amount", req)
+func HandlerGetCapacityStatistics(db *mongo.Database, timezones types.Timezones) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := NewRequestGetCapacityStatistics(c, timezones)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, types.NewResponseError(1, err.Error()))
+			return
+		}
+
+		filter := bson.M{
+			"scope":     req.Query.Scope,
+			"timeframe": req.Query.Timeframe,
+			"timestamp": bson.M{
+				"$gte": req.Query.FromTimestamp,
+				"$lt":  req.Query.ToTimestamp,
+			},
+			"tz": types.TimezoneFilter(req.Query.Timezone),
+		}
+		if req.Query.Key != "" {
+			filter["key"] = req.Query.Key
+		}
+
+		sort := bson.D{
+			bson.E{Key: "timestamp", Value: 1},
+		}
+		if len(req.Sort) != 0 {
+			sort = req.Sort
+		}
+
+		sort = append(sort, bson.E{Key: "key", Value: 1})
+
+		projection := bson.M{
+			"_id":       0,
+			"scope":     0,
+			"timeframe": 0,
+			"tz":        0,
+		}
+		opts := options.Find().
+			SetProjection(projection).
+			SetSort(sort).
+			SetSkip(req.Query.Skip).
+			SetLimit(req.Query.Limit)
+
+		result, err := database.CapacityStatisticFind(context.TODO(), db, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.NewResponseError(2, err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NewResponseResult(result))
+	}
 }
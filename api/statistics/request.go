@@ -1,42 +1,289 @@
 package statistics
 
 import (
+	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/sentinel-official/explorer/types"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+	"github.com/sentinel-official/explorer/utils"
 )
 
 type RequestGetStatistics struct {
-	Sort bson.D
+	Sort     bson.D
+	Location *time.Location
 
 	Query struct {
+		Aggregation   string    `form:"aggregation" binding:"omitempty,oneof=sum avg min max last"`
+		Country       string    `form:"country"`
+		Denom         string    `form:"denom"`
+		Field         string    `form:"field"`
+		Fill          string    `form:"fill" binding:"omitempty,oneof=zero previous null"`
+		Format        string    `form:"format" binding:"omitempty,oneof=rows columnar"`
 		FromTimestamp time.Time `form:"from_timestamp"`
+		GroupBy       string    `form:"group_by" binding:"omitempty,oneof=country city"`
 		Limit         int64     `form:"limit,default=30" binding:"gte=0,lte=100"`
-		Method        string    `form:"method" binding:"required"`
+		Method        string    `form:"method" binding:"required_without=Type"`
 		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
 		Sort          string    `form:"sort"`
 		Status        string    `form:"status" binding:"omitempty,oneof=active inactive inactive_pending"`
-		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=day week month year"`
+		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=hour day week month year 7d 30d"`
+		Timezone      string    `form:"tz"`
 		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+		Type          string    `form:"type"`
 	}
 }
 
-func NewRequestGetStatistics(c *gin.Context) (req *RequestGetStatistics, err error) {
+func NewRequestGetStatistics(c *gin.Context, timezones types.Timezones) (req *RequestGetStatistics, err error) {
+	return newRequestGetStatistics(c.Request.URL.Query(), timezones)
+}
+
+func newRequestGetStatistics(values url.Values, timezones types.Timezones) (req *RequestGetStatistics, err error) {
 	req = &RequestGetStatistics{}
-	if err = c.ShouldBindQuery(&req.Query); err != nil {
+	if err = binding.MapFormWithTag(&req.Query, values, "form"); err != nil {
+		return nil, err
+	}
+	if err = binding.Validator.ValidateStruct(&req.Query); err != nil {
 		return nil, err
 	}
 
-	validatorFunc, ok := validators[req.Query.Method]
-	if !ok {
-		return req, nil
+	req.Location, err = timezones.TimeframeLocation(req.Query.Timeframe, req.Query.Timezone)
+	if err != nil {
+		return nil, err
 	}
-	if validatorFunc == nil {
+
+	if req.Query.Country != "" {
+		country := nodetypes.CountryCode(req.Query.Country)
+		if country == "" {
+			return nil, fmt.Errorf("invalid country code %s", req.Query.Country)
+		}
+
+		req.Query.Country = country
+	}
+
+	if alias, ok := types.StatisticMethodAliases[req.Query.Method]; ok {
+		req.Query.Type, req.Query.Aggregation = alias.Type, alias.Aggregation
+	}
+	if req.Query.Type == "" {
+		if req.Query.Fill != "" || req.Query.Format != "" {
+			return nil, fmt.Errorf("method %s does not support fill or format", req.Query.Method)
+		}
+
 		return req, nil
 	}
 
-	if err := validatorFunc(req); err != nil {
+	if err := validateQuery(req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestPostStatisticsBatch struct {
+	Items    []*RequestGetStatistics
+	Previous []*RequestGetStatistics
+
+	Body struct {
+		Compare bool `json:"compare"`
+		Queries []struct {
+			ID     string            `json:"id"`
+			Params map[string]string `json:"params" binding:"required"`
+		} `json:"queries" binding:"required,min=1,max=20,dive"`
+	}
+}
+
+func NewRequestPostStatisticsBatch(c *gin.Context, timezones types.Timezones) (req *RequestPostStatisticsBatch, err error) {
+	req = &RequestPostStatisticsBatch{}
+	if err = c.ShouldBindJSON(&req.Body); err != nil {
+		return nil, err
+	}
+
+	for i, query := range req.Body.Queries {
+		values := url.Values{}
+		for key, value := range query.Params {
+			values.Set(key, value)
+		}
+
+		item, err := newRequestGetStatistics(values, timezones)
+		if err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
+		}
+
+		req.Items = append(req.Items, item)
+		if !req.Body.Compare {
+			continue
+		}
+
+		if item.Query.Type == "" {
+			return nil, fmt.Errorf("query %d: method %s does not support compare", i, item.Query.Method)
+		}
+		if !values.Has("from_timestamp") || !values.Has("to_timestamp") {
+			return nil, fmt.Errorf("query %d: compare requires from_timestamp and to_timestamp", i)
+		}
+
+		d := item.Query.ToTimestamp.Sub(item.Query.FromTimestamp)
+
+		prev := *item
+		prev.Query.FromTimestamp = item.Query.FromTimestamp.Add(-d)
+		prev.Query.ToTimestamp = item.Query.FromTimestamp
+
+		req.Previous = append(req.Previous, &prev)
+	}
+
+	return req, nil
+}
+
+type RequestGetCohortStatistics struct {
+	Sort bson.D
+
+	Query struct {
+		FromTimestamp time.Time `form:"from_timestamp"`
+		Limit         int64     `form:"limit,default=30" binding:"gte=0,lte=100"`
+		MaxPeriod     int64     `form:"max_period" binding:"gte=0"`
+		Method        string    `form:"method,default=CohortRetention" binding:"oneof=CohortNewReturning CohortRetention"`
+		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
+		Sort          string    `form:"sort"`
+		Timeframe     string    `form:"timeframe,default=month" binding:"oneof=day week month year"`
+		Timezone      string    `form:"tz"`
+		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+	}
+}
+
+func NewRequestGetCohortStatistics(c *gin.Context, timezones types.Timezones) (req *RequestGetCohortStatistics, err error) {
+	req = &RequestGetCohortStatistics{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	if _, err = timezones.Location(req.Query.Timezone); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"-timestamp",
+		"timestamp",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestGetNodeSoftwareStatistics struct {
+	Sort bson.D
+
+	Query struct {
+		FromTimestamp time.Time `form:"from_timestamp"`
+		Group         string    `form:"group,default=version" binding:"oneof=type version"`
+		Key           string    `form:"key"`
+		Limit         int64     `form:"limit,default=100" binding:"gte=0,lte=500"`
+		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
+		Sort          string    `form:"sort"`
+		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=day week month"`
+		Timezone      string    `form:"tz"`
+		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+	}
+}
+
+func NewRequestGetNodeSoftwareStatistics(c *gin.Context, timezones types.Timezones) (req *RequestGetNodeSoftwareStatistics, err error) {
+	req = &RequestGetNodeSoftwareStatistics{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	if _, err = timezones.Location(req.Query.Timezone); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"-timestamp",
+		"timestamp",
+		"-node_count",
+		"node_count",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestGetNodeSoftwareAdoption struct {
+	Sort bson.D
+
+	Query struct {
+		FromTimestamp time.Time `form:"from_timestamp"`
+		Limit         int64     `form:"limit,default=30" binding:"gte=0,lte=500"`
+		Mode          string    `form:"mode,default=at_least" binding:"oneof=at_least exact"`
+		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
+		Sort          string    `form:"sort"`
+		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=day week month"`
+		Timezone      string    `form:"tz"`
+		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+		Version       string    `form:"version" binding:"required"`
+	}
+}
+
+func NewRequestGetNodeSoftwareAdoption(c *gin.Context, timezones types.Timezones) (req *RequestGetNodeSoftwareAdoption, err error) {
+	req = &RequestGetNodeSoftwareAdoption{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	if _, err = timezones.Location(req.Query.Timezone); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"-timestamp",
+		"timestamp",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+type RequestGetCapacityStatistics struct {
+	Sort bson.D
+
+	Query struct {
+		FromTimestamp time.Time `form:"from_timestamp"`
+		Key           string    `form:"key"`
+		Limit         int64     `form:"limit,default=30" binding:"gte=0,lte=500"`
+		Scope         string    `form:"scope,default=network" binding:"oneof=country network node"`
+		Skip          int64     `form:"skip,default=0" binding:"gte=0"`
+		Sort          string    `form:"sort"`
+		Timeframe     string    `form:"timeframe,default=day" binding:"oneof=hour day week month year"`
+		Timezone      string    `form:"tz"`
+		ToTimestamp   time.Time `form:"to_timestamp,default=9999-12-31T23:59:59Z" binding:"gtfield=FromTimestamp"`
+	}
+}
+
+func NewRequestGetCapacityStatistics(c *gin.Context, timezones types.Timezones) (req *RequestGetCapacityStatistics, err error) {
+	req = &RequestGetCapacityStatistics{}
+	if err = c.ShouldBindQuery(&req.Query); err != nil {
+		return nil, err
+	}
+
+	if _, err = timezones.TimeframeLocation(req.Query.Timeframe, req.Query.Timezone); err != nil {
+		return nil, err
+	}
+
+	allowed := []string{
+		"-timestamp",
+		"timestamp",
+		"-utilization",
+		"utilization",
+	}
+	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
 		return nil, err
 	}
 
@@ -3,8 +3,15 @@ package statistics
 import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/sentinel-official/explorer/types"
 )
 
-func RegisterRoutes(router gin.IRouter, db *mongo.Database, excludeAddrs []string) {
-	router.GET("/statistics", HandlerGetStatistics(db, excludeAddrs))
+func RegisterRoutes(router gin.IRouter, db *mongo.Database, excludeAddrs []string, timezones types.Timezones) {
+	router.GET("/statistics", HandlerGetStatistics(db, excludeAddrs, timezones))
+	router.POST("/statistics/batch", HandlerPostStatisticsBatch(db, excludeAddrs, timezones))
+	router.GET("/statistics/capacity", HandlerGetCapacityStatistics(db, timezones))
+	router.GET("/statistics/cohorts", HandlerGetCohortStatistics(db, timezones))
+	router.GET("/statistics/node-software", HandlerGetNodeSoftwareStatistics(db, timezones))
+	router.GET("/statistics/node-software/adoption", HandlerGetNodeSoftwareAdoption(db, timezones))
 }
@@ -1,277 +1,58 @@
 package statistics
 
 import (
+	"fmt"
+
 	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
 
-var (
-	validators = map[string]func(req *RequestGetStatistics) error{
-		types.StatisticMethodHistoricalActiveNodeCount:         validateHistoricalActiveNodeCount,
-		types.StatisticMethodHistoricalActiveSessionCount:      validateHistoricalActiveSessionCount,
-		types.StatisticMethodHistoricalActiveSubscriptionCount: validateHistoricalActiveSubscriptionCount,
-		types.StatisticMethodHistoricalBytesPayment:            validateHistoricalBytesPayment,
-		types.StatisticMethodHistoricalBytesStakingReward:      validateHistoricalBytesStakingReward,
-		types.StatisticMethodHistoricalEndSessionCount:         validateHistoricalEndSessionCount,
-		types.StatisticMethodHistoricalEndSubscriptionCount:    validateHistoricalEndSubscriptionCount,
-		types.StatisticMethodHistoricalHoursPayment:            validateHistoricalHoursPayment,
-		types.StatisticMethodHistoricalHoursStakingReward:      validateHistoricalHoursStakingReward,
-		types.StatisticMethodHistoricalPlanPayment:             validateHistoricalPlanPayment,
-		types.StatisticMethodHistoricalPlanStakingReward:       validateHistoricalPlanStakingReward,
-		types.StatisticMethodHistoricalRegisterNodeCount:       validateHistoricalRegisterNodeCount,
-		types.StatisticMethodHistoricalSessionAddressCount:     validateHistoricalSessionAddressCount,
-		types.StatisticMethodHistoricalSessionBytes:            validateHistoricalSessionBytes,
-		types.StatisticMethodHistoricalSessionDuration:         validateHistoricalSessionDuration,
-		types.StatisticMethodHistoricalSessionNodeCount:        validateHistoricalSessionNodeCount,
-		types.StatisticMethodHistoricalStartSessionCount:       validateHistoricalStartSessionCount,
-		types.StatisticMethodHistoricalStartSubscriptionCount:  validateHistoricalStartSubscriptionCount,
-		types.StatisticMethodHistoricalSubscriptionDeposit:     validateHistoricalSubscriptionDeposit,
-	}
-)
-
-func validateHistoricalActiveNodeCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalActiveSessionCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalActiveSubscriptionCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalSessionBytes(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalEndSessionCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalEndSubscriptionCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalRegisterNodeCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalSessionDuration(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalBytesPayment(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalBytesStakingReward(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
+func validateQuery(req *RequestGetStatistics) (err error) {
+	kind, ok := types.StatisticTypeValueKinds[req.Query.Type]
+	if !ok {
+		return fmt.Errorf("unknown type %s", req.Query.Type)
 	}
 
-	return nil
-}
-
-func validateHistoricalStartSessionCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
+	fields := types.StatisticValueKindFields[kind]
+	if req.Query.Field != "" && !utils.ContainsString(fields, req.Query.Field) {
+		return fmt.Errorf("field %s is not supported for type %s", req.Query.Field, req.Query.Type)
 	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
+	if req.Query.Field == "" && req.Query.Aggregation != "" && (kind == types.StatisticValueKindHistogram || kind == types.StatisticValueKindHistograms) {
+		return fmt.Errorf("field is required for aggregation of type %s", req.Query.Type)
 	}
-
-	return nil
-}
-
-func validateHistoricalStartSubscriptionCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
+	if req.Query.Denom != "" && kind != types.StatisticValueKindCoins && kind != types.StatisticValueKindHistograms {
+		return fmt.Errorf("denom is not supported for type %s", req.Query.Type)
 	}
 
-	return nil
-}
-
-func validateHistoricalSubscriptionDeposit(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
+	if req.Query.Fill != "" || req.Query.Format != "" {
+		if req.Query.Aggregation != "" || req.Query.GroupBy != "" {
+			return fmt.Errorf("fill and format are not supported with aggregation or group_by")
+		}
 	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
+	if req.Query.Fill != "" && req.Query.FromTimestamp.IsZero() {
+		return fmt.Errorf("fill requires from_timestamp")
 	}
 
-	return nil
-}
+	if req.Query.Aggregation != "" {
+		if req.Sort, err = utils.ParseQuerySort(nil, req.Query.Sort); err != nil {
+			return err
+		}
 
-func validateHistoricalPlanPayment(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
+		return nil
 	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalPlanStakingReward(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func validateHistoricalHoursPayment(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
-	}
-
-	return nil
-}
 
-func validateHistoricalSessionAddressCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
+	if types.RollingTimeframeDays(req.Query.Timeframe) != 0 && (kind == types.StatisticValueKindHistogram || kind == types.StatisticValueKindHistograms) {
+		return fmt.Errorf("timeframe %s is not supported", req.Query.Timeframe)
 	}
 
-	return nil
-}
-
-func validateHistoricalHoursStakingReward(req *RequestGetStatistics) (err error) {
 	allowed := []string{
 		"-timestamp",
 		"timestamp",
 	}
-	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
-		return err
+	if kind == types.StatisticValueKindNumber {
+		allowed = append(allowed, "-value", "value")
 	}
 
-	return nil
-}
-
-func validateHistoricalSessionNodeCount(req *RequestGetStatistics) (err error) {
-	allowed := []string{
-		"-timestamp",
-		"timestamp",
-		"-value",
-		"value",
-	}
 	if req.Sort, err = utils.ParseQuerySort(allowed, req.Query.Sort); err != nil {
 		return err
 	}
@@ -13,14 +13,20 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	accountapi "github.com/sentinel-official/explorer/api/account"
+	anomalyapi "github.com/sentinel-official/explorer/api/anomaly"
 	blockapi "github.com/sentinel-official/explorer/api/block"
+	countryapi "github.com/sentinel-official/explorer/api/country"
 	depositapi "github.com/sentinel-official/explorer/api/deposit"
 	nodeapi "github.com/sentinel-official/explorer/api/node"
+	planapi "github.com/sentinel-official/explorer/api/plan"
+	providerapi "github.com/sentinel-official/explorer/api/provider"
 	sessionapi "github.com/sentinel-official/explorer/api/session"
 	statisticsapi "github.com/sentinel-official/explorer/api/statistics"
 	subscriptionapi "github.com/sentinel-official/explorer/api/subscription"
 	txapi "github.com/sentinel-official/explorer/api/tx"
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
 
@@ -34,6 +40,7 @@ var (
 	dbUsername   string
 	dbPassword   string
 	excludeAddrs string
+	timezones    string
 )
 
 func init() {
@@ -44,6 +51,7 @@ func init() {
 	flag.StringVar(&dbUsername, "db-username", "", "")
 	flag.StringVar(&dbPassword, "db-password", "", "")
 	flag.StringVar(&excludeAddrs, "exclude-addrs", "sent1c4nvz43tlw6d0c9nfu6r957y5d9pgjk5czl3n3", "")
+	flag.StringVar(&timezones, "timezones", "", "")
 	flag.Parse()
 }
 
@@ -106,6 +114,13 @@ func main() {
 	excludeAddrs := strings.Split(excludeAddrs, ",")
 	sort.Strings(excludeAddrs)
 
+	locations, err := utils.ParseLocations(timezones)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	timezones := types.NewTimezones(locations)
+
 	db, err := utils.PrepareDatabase(context.TODO(), appName, dbUsername, dbPassword, dbAddress, dbName)
 	if err != nil {
 		log.Fatalln(err)
@@ -122,11 +137,16 @@ func main() {
 	router := gin.Default()
 	router.Use(cors.Default())
 
+	accountapi.RegisterRoutes(router, db, timezones)
+	anomalyapi.RegisterRoutes(router, db)
 	blockapi.RegisterRoutes(router, db)
+	countryapi.RegisterRoutes(router, db, timezones)
 	depositapi.RegisterRoutes(router, db)
-	nodeapi.RegisterRoutes(router, db, excludeAddrs)
+	nodeapi.RegisterRoutes(router, db, excludeAddrs, timezones)
+	planapi.RegisterRoutes(router, db, timezones)
+	providerapi.RegisterRoutes(router, db, timezones)
 	sessionapi.RegisterRoutes(router, db)
-	statisticsapi.RegisterRoutes(router, db, excludeAddrs)
+	statisticsapi.RegisterRoutes(router, db, excludeAddrs, timezones)
 	subscriptionapi.RegisterRoutes(router, db)
 	txapi.RegisterRoutes(router, db)
 
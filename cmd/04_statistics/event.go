@@ -3,12 +3,12 @@ package main
 import (
 	"context"
 	"log"
-	"sort"
 	"time"
 
 	hubtypes "github.com/sentinel-official/hub/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
 	"github.com/sentinel-official/explorer/types"
@@ -81,14 +81,21 @@ func (s *NodeEventStatistics) Result(timestamp time.Time) []bson.M {
 	}
 }
 
-func StatisticsFromSessionEvents(ctx context.Context, db *mongo.Database, excludeAddrs []string) (result []bson.M, err error) {
-	log.Println("StatisticsFromSessionEvents")
+func StatisticsFromSessionEvents(ctx context.Context, db *mongo.Database, fromTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSessionEvents", fromTimestamp, minHourTimestamp, loc)
+
+	match := bson.M{
+		"type": types.EventTypeSessionUpdateDetails,
+	}
+	if !fromTimestamp.IsZero() {
+		match["timestamp"] = bson.M{
+			"$gte": fromTimestamp.AddDate(-1, 0, 0),
+		}
+	}
 
 	pipeline := []bson.M{
 		{
-			"$match": bson.M{
-				"type": types.EventTypeSessionUpdateDetails,
-			},
+			"$match": match,
 		},
 		{
 			"$sort": bson.M{
@@ -110,14 +117,38 @@ func StatisticsFromSessionEvents(ctx context.Context, db *mongo.Database, exclud
 					"session_id": "$session_id",
 					"timestamp": bson.M{
 						"$dateFromParts": bson.M{
+							"timezone": loc.String(),
+							"hour": bson.M{
+								"$cond": bson.A{
+									bson.M{
+										"$gte": bson.A{"$timestamp", minHourTimestamp},
+									},
+									bson.M{
+										"$hour": bson.M{
+											"date":     "$timestamp",
+											"timezone": loc.String(),
+										},
+									},
+									0,
+								},
+							},
 							"day": bson.M{
-								"$dayOfMonth": "$timestamp",
+								"$dayOfMonth": bson.M{
+									"date":     "$timestamp",
+									"timezone": loc.String(),
+								},
 							},
 							"month": bson.M{
-								"$month": "$timestamp",
+								"$month": bson.M{
+									"date":     "$timestamp",
+									"timezone": loc.String(),
+								},
 							},
 							"year": bson.M{
-								"$year": "$timestamp",
+								"$year": bson.M{
+									"date":     "$timestamp",
+									"timezone": loc.String(),
+								},
 							},
 						},
 					},
@@ -169,30 +200,70 @@ func StatisticsFromSessionEvents(ctx context.Context, db *mongo.Database, exclud
 		},
 	}
 
-	cursor, err := database.EventAggregate(ctx, db, pipeline)
+	cursor, err := database.EventAggregate(ctx, db, pipeline, options.Aggregate().SetAllowDiskUse(true))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer cursor.Close(ctx)
 
 	var (
-		d = make(map[time.Time]*SessionEventStatistics)
-		w = make(map[time.Time]*SessionEventStatistics)
-		m = make(map[time.Time]*SessionEventStatistics)
-		y = make(map[time.Time]*SessionEventStatistics)
+		previous           = make(map[string]*SessionEventStatistics)
+		previousTimestamps = make(map[string]time.Time)
+	)
+
+	buckets := types.NewBuckets(func(timeframe string, t time.Time, v *SessionEventStatistics) error {
+		item := NewSessionEventStatistics(timeframe)
+		for u := range v.SessionBandwidth {
+			item.SessionBandwidth[u] = v.SessionBandwidth[u]
+			item.SessionDuration[u] = v.SessionDuration[u]
+
+			if p, ok := previous[timeframe]; ok && previousTimestamps[timeframe].Equal(utils.TimeframeAddDate(timeframe, t, -1)) {
+				if p, ok := p.SessionBandwidth[u]; ok {
+					item.SessionBandwidth[u] = v.SessionBandwidth[u].Copy().Sub(p)
+				}
+				if p, ok := p.SessionDuration[u]; ok {
+					item.SessionDuration[u] = v.SessionDuration[u] - p
+				}
+			}
+		}
+
+		previous[timeframe], previousTimestamps[timeframe] = v, t
+		return database.SendStatistics(ctx, out, loc, item.Result(t)...)
+	})
+
+	var (
+		h = buckets.Items("hour")
+		d = buckets.Items("day")
+		w = buckets.Items("week")
+		m = buckets.Items("month")
+		y = buckets.Items("year")
 	)
 
 	for cursor.Next(ctx) {
 		var item bson.M
 		if err := cursor.Decode(&item); err != nil {
-			return nil, err
+			return err
 		}
 
 		bandwidth := types.BandwidthFromInterface(item["bandwidth"])
 		duration := types.Int64FromInterface(item["duration"])
 		sessionID := types.Uint64FromInterface(item["session_id"])
-		timestamp := types.TimeFromInterface(item["timestamp"])
+		timestamp := types.TimeFromInterface(item["timestamp"]).In(loc)
+
+		if err := buckets.CloseBefore(timestamp); err != nil {
+			return err
+		}
+
+		hourTimestamp := utils.HourDate(timestamp)
+		if !hourTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourTimestamp]; !ok {
+				h[hourTimestamp] = NewSessionEventStatistics("hour")
+			}
+
+			h[hourTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
+			h[hourTimestamp].SessionDuration[sessionID] = duration
+		}
 
 		dayTimestamp := utils.DayDate(timestamp)
 		if _, ok := d[dayTimestamp]; !ok {
@@ -228,122 +299,28 @@ func StatisticsFromSessionEvents(ctx context.Context, db *mongo.Database, exclud
 	}
 
 	if err := cursor.Err(); err != nil {
-		return nil, err
-	}
-
-	var dKeys []time.Time
-	for t := range d {
-		dKeys = append(dKeys, t)
-	}
-
-	sort.Slice(dKeys, func(i, j int) bool {
-		return dKeys[i].After(dKeys[j])
-	})
-
-	for _, t := range dKeys {
-		for u := range d[t].SessionBandwidth {
-			if v, ok := d[t.AddDate(0, 0, -1)]; ok {
-				if v, ok := v.SessionBandwidth[u]; ok {
-					d[t].SessionBandwidth[u] = d[t].SessionBandwidth[u].Sub(v)
-				}
-				if v, ok := v.SessionDuration[u]; ok {
-					d[t].SessionDuration[u] = d[t].SessionDuration[u] - v
-				}
-			}
-		}
-	}
-
-	var wKeys []time.Time
-	for t := range w {
-		wKeys = append(wKeys, t)
-	}
-
-	sort.Slice(wKeys, func(i, j int) bool {
-		return wKeys[i].After(wKeys[j])
-	})
-
-	for _, t := range wKeys {
-		for u := range w[t].SessionBandwidth {
-			if v, ok := w[t.AddDate(0, 0, -7)]; ok {
-				if v, ok := v.SessionBandwidth[u]; ok {
-					w[t].SessionBandwidth[u] = w[t].SessionBandwidth[u].Sub(v)
-				}
-				if v, ok := v.SessionDuration[u]; ok {
-					w[t].SessionDuration[u] = w[t].SessionDuration[u] - v
-				}
-			}
-		}
+		return err
 	}
 
-	var mKeys []time.Time
-	for t := range m {
-		mKeys = append(mKeys, t)
-	}
+	return buckets.Close()
+}
 
-	sort.Slice(mKeys, func(i, j int) bool {
-		return mKeys[i].After(mKeys[j])
-	})
+func StatisticsFromNodeEvents(ctx context.Context, db *mongo.Database, fromTimestamp, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromNodeEvents", fromTimestamp, minHourTimestamp, loc)
 
-	for _, t := range mKeys {
-		for u := range m[t].SessionBandwidth {
-			if v, ok := m[t.AddDate(0, -1, 0)]; ok {
-				if v, ok := v.SessionBandwidth[u]; ok {
-					m[t].SessionBandwidth[u] = m[t].SessionBandwidth[u].Sub(v)
-				}
-				if v, ok := v.SessionDuration[u]; ok {
-					m[t].SessionDuration[u] = m[t].SessionDuration[u] - v
-				}
-			}
-		}
-	}
-
-	var yKeys []time.Time
-	for t := range y {
-		yKeys = append(yKeys, t)
+	match := bson.M{
+		"type":   types.EventTypeNodeUpdateStatus,
+		"status": hubtypes.StatusActive.String(),
 	}
-
-	sort.Slice(yKeys, func(i, j int) bool {
-		return yKeys[i].After(yKeys[j])
-	})
-
-	for _, t := range yKeys {
-		for u := range y[t].SessionBandwidth {
-			if v, ok := y[t.AddDate(-1, 0, 0)]; ok {
-				if v, ok := v.SessionBandwidth[u]; ok {
-					y[t].SessionBandwidth[u] = y[t].SessionBandwidth[u].Sub(v)
-				}
-				if v, ok := v.SessionDuration[u]; ok {
-					y[t].SessionDuration[u] = y[t].SessionDuration[u] - v
-				}
-			}
+	if !fromTimestamp.IsZero() {
+		match["timestamp"] = bson.M{
+			"$gte": fromTimestamp,
 		}
 	}
 
-	for t := range d {
-		result = append(result, d[t].Result(t)...)
-	}
-	for t := range w {
-		result = append(result, w[t].Result(t)...)
-	}
-	for t := range m {
-		result = append(result, m[t].Result(t)...)
-	}
-	for t := range y {
-		result = append(result, y[t].Result(t)...)
-	}
-
-	return result, nil
-}
-
-func StatisticsFromNodeEvents(ctx context.Context, db *mongo.Database) (result []bson.M, err error) {
-	log.Println("StatisticsFromNodeEvents")
-
 	pipeline := []bson.M{
 		{
-			"$match": bson.M{
-				"type":   types.EventTypeNodeUpdateStatus,
-				"status": hubtypes.StatusActive.String(),
-			},
+			"$match": match,
 		},
 		{
 			"$group": bson.M{
@@ -351,14 +328,38 @@ func StatisticsFromNodeEvents(ctx context.Context, db *mongo.Database) (result [
 					"node_addr": "$node_addr",
 					"timestamp": bson.M{
 						"$dateFromParts": bson.M{
+							"timezone": loc.String(),
+							"hour": bson.M{
+								"$cond": bson.A{
+									bson.M{
+										"$gte": bson.A{"$timestamp", minHourTimestamp},
+									},
+									bson.M{
+										"$hour": bson.M{
+											"date":     "$timestamp",
+											"timezone": loc.String(),
+										},
+									},
+									0,
+								},
+							},
 							"day": bson.M{
-								"$dayOfMonth": "$timestamp",
+								"$dayOfMonth": bson.M{
+									"date":     "$timestamp",
+									"timezone": loc.String(),
+								},
 							},
 							"month": bson.M{
-								"$month": "$timestamp",
+								"$month": bson.M{
+									"date":     "$timestamp",
+									"timezone": loc.String(),
+								},
 							},
 							"year": bson.M{
-								"$year": "$timestamp",
+								"$year": bson.M{
+									"date":     "$timestamp",
+									"timezone": loc.String(),
+								},
 							},
 						},
 					},
@@ -372,30 +373,53 @@ func StatisticsFromNodeEvents(ctx context.Context, db *mongo.Database) (result [
 				"timestamp": "$_id.timestamp",
 			},
 		},
+		{
+			"$sort": bson.M{
+				"timestamp": 1,
+			},
+		},
 	}
 
-	cursor, err := database.EventAggregate(ctx, db, pipeline)
+	cursor, err := database.EventAggregate(ctx, db, pipeline, options.Aggregate().SetAllowDiskUse(true))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer cursor.Close(ctx)
 
+	buckets := types.NewBuckets(func(_ string, t time.Time, v *NodeEventStatistics) error {
+		return database.SendStatistics(ctx, out, loc, v.Result(t)...)
+	})
+
 	var (
-		d = make(map[time.Time]*NodeEventStatistics)
-		w = make(map[time.Time]*NodeEventStatistics)
-		m = make(map[time.Time]*NodeEventStatistics)
-		y = make(map[time.Time]*NodeEventStatistics)
+		h = buckets.Items("hour")
+		d = buckets.Items("day")
+		w = buckets.Items("week")
+		m = buckets.Items("month")
+		y = buckets.Items("year")
 	)
 
 	for cursor.Next(ctx) {
 		var item bson.M
 		if err := cursor.Decode(&item); err != nil {
-			return nil, err
+			return err
 		}
 
 		nodeAddr := types.StringFromInterface(item["node_addr"])
-		timestamp := types.TimeFromInterface(item["timestamp"])
+		timestamp := types.TimeFromInterface(item["timestamp"]).In(loc)
+
+		if err := buckets.CloseBefore(timestamp); err != nil {
+			return err
+		}
+
+		hourTimestamp := utils.HourDate(timestamp)
+		if !hourTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourTimestamp]; !ok {
+				h[hourTimestamp] = NewNodeEventStatistics("hour")
+			}
+
+			h[hourTimestamp].ActiveNode[nodeAddr] = true
+		}
 
 		dayTimestamp := utils.DayDate(timestamp)
 		if _, ok := d[dayTimestamp]; !ok {
@@ -424,21 +448,8 @@ func StatisticsFromNodeEvents(ctx context.Context, db *mongo.Database) (result [
 	}
 
 	if err := cursor.Err(); err != nil {
-		return nil, err
-	}
-
-	for t := range d {
-		result = append(result, d[t].Result(t)...)
-	}
-	for t := range w {
-		result = append(result, w[t].Result(t)...)
-	}
-	for t := range m {
-		result = append(result, m[t].Result(t)...)
-	}
-	for t := range y {
-		result = append(result, y[t].Result(t)...)
+		return err
 	}
 
-	return result, nil
+	return buckets.Close()
 }
@@ -4,19 +4,19 @@ import (
 	"context"
 	"flag"
 	"log"
-	"runtime"
 	"sort"
 	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"go.mongodb.org/mongo-driver/mongo/readconcern"
-	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
 
@@ -25,21 +25,29 @@ const (
 )
 
 var (
+	batchSize    int
 	dbAddress    string
 	dbName       string
 	dbUsername   string
 	dbPassword   string
 	excludeAddrs string
+	hourWindow   time.Duration
+	incremental  bool
+	timezones    string
 )
 
 func init() {
 	log.SetFlags(0)
 
+	flag.IntVar(&batchSize, "batch-size", 25_000, "")
 	flag.StringVar(&dbAddress, "db-address", "mongodb://127.0.0.1:27017", "")
 	flag.StringVar(&dbName, "db-name", "sentinelhub-2", "")
 	flag.StringVar(&dbUsername, "db-username", "", "")
 	flag.StringVar(&dbPassword, "db-password", "", "")
 	flag.StringVar(&excludeAddrs, "exclude-addrs", "sent1c4nvz43tlw6d0c9nfu6r957y5d9pgjk5czl3n3", "")
+	flag.DurationVar(&hourWindow, "hour-window", 30*24*time.Hour, "")
+	flag.BoolVar(&incremental, "incremental", false, "")
+	flag.StringVar(&timezones, "timezones", "", "")
 	flag.Parse()
 }
 
@@ -58,6 +66,19 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 		return err
 	}
 
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "register_timestamp", Value: 1},
+			},
+		},
+	}
+
+	_, err = database.NodeIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
 	indexes = []mongo.IndexModel{
 		{
 			Keys: bson.D{
@@ -66,6 +87,11 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 			Options: options.Index().
 				SetUnique(true),
 		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "start_timestamp", Value: 1},
+			},
+		},
 	}
 
 	_, err = database.SessionIndexesCreateMany(ctx, db, indexes)
@@ -73,9 +99,112 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 		return err
 	}
 
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "start_timestamp", Value: 1},
+			},
+		},
+	}
+
+	_, err = database.SubscriptionIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "timestamp", Value: 1},
+			},
+		},
+	}
+
+	_, err = database.SubscriptionPayoutIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	if err := database.StatisticIndexesDropOne(ctx, db, "type_1_timeframe_1_timestamp_1"); err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "type", Value: 1},
+				bson.E{Key: "timeframe", Value: 1},
+				bson.E{Key: "tz", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true),
+		},
+	}
+
+	_, err = database.StatisticIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+func windowTimestamp(v time.Time) time.Time {
+	if v.IsZero() {
+		return v
+	}
+
+	t := utils.YearDate(v)
+	if w := utils.ISOWeekDate(v); w.Before(t) {
+		t = w
+	}
+
+	return t.AddDate(0, 0, -1)
+}
+
+func checkpoint(ctx context.Context, db *mongo.Database) (*models.SyncStatus, error) {
+	filter := bson.M{
+		"app_name": "03_sentinelhub",
+	}
+
+	dSyncStatus, err := database.SyncStatusFindOne(ctx, db, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filter = bson.M{}
+	if dSyncStatus != nil {
+		filter["height"] = dSyncStatus.Height
+	}
+
+	projection := bson.M{
+		"_id":    0,
+		"height": 1,
+		"time":   1,
+	}
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "height", Value: -1},
+		}).
+		SetLimit(1)
+
+	dBlocks, err := database.BlockFind(ctx, db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(dBlocks) == 0 {
+		return nil, nil
+	}
+
+	return &models.SyncStatus{
+		AppName:   appName,
+		Height:    dBlocks[0].Height,
+		Timestamp: dBlocks[0].Time,
+	}, nil
+}
+
 func main() {
 	db, err := utils.PrepareDatabase(context.TODO(), appName, dbUsername, dbPassword, dbAddress, dbName)
 	if err != nil {
@@ -87,6 +216,7 @@ func main() {
 	}
 
 	now := time.Now()
+	runID := primitive.NewObjectID()
 
 	if err := createIndexes(context.TODO(), db); err != nil {
 		log.Fatalln(err)
@@ -112,127 +242,192 @@ func main() {
 		maxTimestamp = dBlocks[0].Time
 	}
 
+	dCheckpoint, err := checkpoint(context.TODO(), db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var fromTimestamp time.Time
+	if incremental {
+		filter := bson.M{
+			"app_name": appName,
+		}
+
+		dSyncStatus, err := database.SyncStatusFindOne(context.TODO(), db, filter)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if dSyncStatus != nil {
+			fromTimestamp = dSyncStatus.Timestamp
+		}
+	}
+
+	minHourTimestamp := utils.HourDate(maxTimestamp.Add(-hourWindow))
+	if minHourTimestamp.Before(fromTimestamp) {
+		minHourTimestamp = utils.HourDate(fromTimestamp)
+	}
+
+	log.Println("FromTimestamp", fromTimestamp, "MinHourTimestamp", minHourTimestamp)
+	windowTimestamp := windowTimestamp(fromTimestamp)
+
 	excludeAddrs := strings.Split(excludeAddrs, ",")
 	sort.Strings(excludeAddrs)
 
+	locations, err := utils.ParseLocations(timezones)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	tzLocations := make(map[string]*time.Location)
+	for _, loc := range locations {
+		tzLocations[loc.String()] = loc
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
 	var (
-		m     []bson.M
-		group = errgroup.Group{}
+		out    = make(chan bson.M, batchSize)
+		writer = errgroup.Group{}
 	)
 
-	group.Go(func() error {
-		defer runtime.GC()
-
-		v, err := StatisticsFromNodeEvents(context.TODO(), db)
-		if err != nil {
-			return err
-		}
+	writer.Go(func() error {
+		defer cancel()
 
-		m = append(m, v...)
-		return nil
-	})
+		return database.WriteStatistics(ctx, db, out, batchSize, func(item bson.M) (string, mongo.WriteModel) {
+			tz := types.StringFromInterface(item["tz"])
+			loc, ok := tzLocations[tz]
+			if !ok {
+				loc = time.UTC
+			}
 
-	group.Go(func() error {
-		defer runtime.GC()
+			timeframe := types.StringFromInterface(item["timeframe"])
+			timestamp := types.TimeFromInterface(item["timestamp"])
+			if timestamp.Before(utils.TimeframeDate(timeframe, fromTimestamp.In(loc))) {
+				return "", nil
+			}
 
-		v, err := StatisticsFromSessionEvents(context.TODO(), db, excludeAddrs)
-		if err != nil {
-			return err
-		}
+			filter := bson.M{
+				"type":      item["type"],
+				"timeframe": item["timeframe"],
+				"timestamp": item["timestamp"],
+				"tz":        types.TimezoneFilter(tz),
+			}
+			update := bson.M{
+				"$set": bson.M{
+					"run_id": runID,
+					"value":  item["value"],
+				},
+			}
 
-		m = append(m, v...)
-		return nil
+			return database.StatisticCollectionName, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(update).
+				SetUpsert(true)
+		})
 	})
 
-	group.Go(func() error {
-		defer runtime.GC()
-
-		v, err := StatisticsFromNodes(context.TODO(), db)
-		if err != nil {
-			return err
+	produce := func(loc *time.Location) error {
+		hourTimestamp := minHourTimestamp
+		if loc != time.UTC {
+			hourTimestamp = maxTimestamp.Add(time.Hour)
 		}
 
-		m = append(m, v...)
-		return nil
-	})
+		group := errgroup.Group{}
 
-	group.Go(func() error {
-		defer runtime.GC()
+		group.Go(func() error {
+			return StatisticsFromNodeEvents(ctx, db, windowTimestamp, hourTimestamp, loc, out)
+		})
 
-		v, err := StatisticsFromSessions(context.TODO(), db, time.Time{}, maxTimestamp, excludeAddrs)
-		if err != nil {
-			return err
-		}
+		group.Go(func() error {
+			return StatisticsFromSessionEvents(ctx, db, windowTimestamp, hourTimestamp, excludeAddrs, loc, out)
+		})
 
-		m = append(m, v...)
-		return nil
-	})
+		group.Go(func() error {
+			return StatisticsFromNodes(ctx, db, windowTimestamp, hourTimestamp, loc, out)
+		})
 
-	group.Go(func() error {
-		defer runtime.GC()
+		group.Go(func() error {
+			return StatisticsFromSessions(ctx, db, windowTimestamp, time.Time{}, maxTimestamp, hourTimestamp, excludeAddrs, loc, out)
+		})
 
-		v, err := StatisticsFromSubscriptions(context.TODO(), db, time.Time{}, maxTimestamp, excludeAddrs)
-		if err != nil {
-			return err
-		}
+		group.Go(func() error {
+			return StatisticsFromSubscriptions(ctx, db, windowTimestamp, time.Time{}, maxTimestamp, hourTimestamp, excludeAddrs, loc, out)
+		})
 
-		m = append(m, v...)
-		return nil
-	})
+		group.Go(func() error {
+			return StatisticsFromSubscriptionPayouts(ctx, db, windowTimestamp, hourTimestamp, loc, out)
+		})
 
-	group.Go(func() error {
-		defer runtime.GC()
+		return group.Wait()
+	}
 
-		v, err := StatisticsFromSubscriptionPayouts(context.TODO(), db)
-		if err != nil {
-			return err
+	var producerErr error
+	for _, loc := range locations {
+		if producerErr = produce(loc); producerErr != nil {
+			cancel()
+			break
 		}
+	}
 
-		m = append(m, v...)
-		return nil
-	})
+	close(out)
 
-	if err := group.Wait(); err != nil {
+	if err := writer.Wait(); err != nil {
 		log.Fatalln(err)
 	}
-
-	var result bson.A
-	for i := 0; i < len(m); i++ {
-		result = append(result, m[i])
+	if producerErr != nil {
+		log.Fatalln(producerErr)
 	}
 
-	err = db.Client().UseSession(
-		context.TODO(),
-		func(ctx mongo.SessionContext) error {
-			err := ctx.StartTransaction(
-				options.Transaction().
-					SetReadConcern(readconcern.Snapshot()).
-					SetWriteConcern(writeconcern.Majority()),
-			)
-			if err != nil {
-				return err
-			}
+	if !incremental {
+		filter := bson.M{
+			"run_id": bson.M{
+				"$ne": runID,
+			},
+		}
 
-			abort := true
-			defer func() {
-				if abort {
-					_ = ctx.AbortTransaction(ctx)
+		if err := database.StatisticDeleteMany(context.TODO(), db, filter); err != nil {
+			log.Fatalln(err)
+		}
+	} else {
+		// Buckets from the checkpoint onward were recomputed in full, so any
+		// of them not written by this run are no longer produced and must go.
+		for _, loc := range locations {
+			for _, timeframe := range types.BucketTimeframes {
+				filter := bson.M{
+					"run_id": bson.M{
+						"$ne": runID,
+					},
+					"timeframe": timeframe,
+					"timestamp": bson.M{
+						"$gte": utils.TimeframeDate(timeframe, fromTimestamp.In(loc)),
+					},
+					"tz": types.TimezoneFilter(loc.String()),
 				}
-			}()
 
-			filter := bson.M{}
-			if err := database.StatisticDeleteMany(ctx, db, filter); err != nil {
-				return err
+				if err := database.StatisticDeleteMany(context.TODO(), db, filter); err != nil {
+					log.Fatalln(err)
+				}
 			}
+		}
+	}
 
-			if _, err := database.StatisticInsertMany(ctx, db, result); err != nil {
-				return err
-			}
+	if dCheckpoint != nil {
+		filter := bson.M{
+			"app_name": appName,
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"height":    dCheckpoint.Height,
+				"timestamp": dCheckpoint.Timestamp,
+			},
+		}
+		projection := bson.M{
+			"_id": 1,
+		}
 
-			abort = false
-			return ctx.CommitTransaction(ctx)
-		},
-	)
+		_, err = database.SyncStatusFindOneAndUpdate(context.TODO(), db, filter, update, options.FindOneAndUpdate().SetProjection(projection).SetUpsert(true))
+	}
 
 	log.Println("Duration", time.Since(now))
 	log.Println("")
@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
@@ -38,44 +39,79 @@ func (s *NodeStatistics) Result(timestamp time.Time) []bson.M {
 	}
 }
 
-func StatisticsFromNodes(ctx context.Context, db *mongo.Database) (result []bson.M, err error) {
-	log.Println("StatisticsFromNodes")
+func StatisticsFromNodes(ctx context.Context, db *mongo.Database, fromTimestamp, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromNodes", fromTimestamp, minHourTimestamp, loc)
 
 	filter := bson.M{}
+	if !fromTimestamp.IsZero() {
+		filter["register_timestamp"] = bson.M{
+			"$gte": fromTimestamp,
+		}
+	}
+
 	projection := bson.M{
 		"_id":                0,
 		"register_timestamp": 1,
 	}
 
-	items, err := database.NodeFind(ctx, db, filter, options.Find().SetProjection(projection))
+	_sort := bson.D{
+		bson.E{Key: "register_timestamp", Value: 1},
+	}
+
+	cursor, err := database.NodeFindCursor(ctx, db, filter, options.Find().SetProjection(projection).SetSort(_sort))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer cursor.Close(ctx)
+
+	buckets := types.NewBuckets(func(_ string, t time.Time, v *NodeStatistics) error {
+		return database.SendStatistics(ctx, out, loc, v.Result(t)...)
+	})
+
 	var (
-		d = make(map[time.Time]*NodeStatistics)
-		w = make(map[time.Time]*NodeStatistics)
-		m = make(map[time.Time]*NodeStatistics)
-		y = make(map[time.Time]*NodeStatistics)
+		h = buckets.Items("hour")
+		d = buckets.Items("day")
+		w = buckets.Items("week")
+		m = buckets.Items("month")
+		y = buckets.Items("year")
 	)
 
-	for i := 0; i < len(items); i++ {
-		dayRegisterTimestamp := utils.DayDate(items[i].RegisterTimestamp)
+	for cursor.Next(ctx) {
+		var item models.Node
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		if err := buckets.CloseBefore(item.RegisterTimestamp.In(loc)); err != nil {
+			return err
+		}
+
+		hourRegisterTimestamp := utils.HourDate(item.RegisterTimestamp.In(loc))
+		if !hourRegisterTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourRegisterTimestamp]; !ok {
+				h[hourRegisterTimestamp] = NewNodeStatistics("hour")
+			}
+
+			h[hourRegisterTimestamp].RegisterNode += 1
+		}
+
+		dayRegisterTimestamp := utils.DayDate(item.RegisterTimestamp.In(loc))
 		if _, ok := d[dayRegisterTimestamp]; !ok {
 			d[dayRegisterTimestamp] = NewNodeStatistics("day")
 		}
 
-		weekRegisterTimestamp := utils.ISOWeekDate(items[i].RegisterTimestamp)
+		weekRegisterTimestamp := utils.ISOWeekDate(item.RegisterTimestamp.In(loc))
 		if _, ok := w[weekRegisterTimestamp]; !ok {
 			w[weekRegisterTimestamp] = NewNodeStatistics("week")
 		}
 
-		monthRegisterTimestamp := utils.MonthDate(items[i].RegisterTimestamp)
+		monthRegisterTimestamp := utils.MonthDate(item.RegisterTimestamp.In(loc))
 		if _, ok := m[monthRegisterTimestamp]; !ok {
 			m[monthRegisterTimestamp] = NewNodeStatistics("month")
 		}
 
-		yearRegisterTimestamp := utils.YearDate(items[i].RegisterTimestamp)
+		yearRegisterTimestamp := utils.YearDate(item.RegisterTimestamp.In(loc))
 		if _, ok := y[yearRegisterTimestamp]; !ok {
 			y[yearRegisterTimestamp] = NewNodeStatistics("year")
 		}
@@ -86,18 +122,9 @@ func StatisticsFromNodes(ctx context.Context, db *mongo.Database) (result []bson
 		y[yearRegisterTimestamp].RegisterNode += 1
 	}
 
-	for t := range d {
-		result = append(result, d[t].Result(t)...)
-	}
-	for t := range w {
-		result = append(result, w[t].Result(t)...)
-	}
-	for t := range m {
-		result = append(result, m[t].Result(t)...)
-	}
-	for t := range y {
-		result = append(result, y[t].Result(t)...)
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	return result, nil
+	return buckets.Close()
 }
@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
@@ -21,6 +22,10 @@ type (
 		BytesPayment       types.Coins
 		BytesStakingReward types.Coins
 		EndSession         int64
+		SessionBytes       *types.Histogram
+		SessionDuration    *types.Histogram
+		SessionPayment     types.Histograms
+		SessionRating      *types.Histogram
 		SessionAddress     map[string]bool
 		SessionNode        map[string]bool
 		StartSession       int64
@@ -32,6 +37,10 @@ func NewSessionStatistics(timeframe string) *SessionStatistics {
 		Timeframe:          timeframe,
 		BytesPayment:       types.NewCoins(nil),
 		BytesStakingReward: types.NewCoins(nil),
+		SessionBytes:       types.NewHistogram(),
+		SessionDuration:    types.NewHistogram(),
+		SessionPayment:     make(types.Histograms),
+		SessionRating:      types.NewHistogram(),
 		SessionAddress:     make(map[string]bool),
 		SessionNode:        make(map[string]bool),
 	}
@@ -69,12 +78,36 @@ func (ss *SessionStatistics) Result(timestamp time.Time) []bson.M {
 			"timestamp": timestamp,
 			"value":     len(ss.SessionAddress),
 		},
+		{
+			"type":      types.StatisticTypeSessionBytesDistribution,
+			"timeframe": ss.Timeframe,
+			"timestamp": timestamp,
+			"value":     ss.SessionBytes.Result(),
+		},
+		{
+			"type":      types.StatisticTypeSessionDurationDistribution,
+			"timeframe": ss.Timeframe,
+			"timestamp": timestamp,
+			"value":     ss.SessionDuration.Result(),
+		},
 		{
 			"type":      types.StatisticTypeSessionNode,
 			"timeframe": ss.Timeframe,
 			"timestamp": timestamp,
 			"value":     len(ss.SessionNode),
 		},
+		{
+			"type":      types.StatisticTypeSessionPaymentDistribution,
+			"timeframe": ss.Timeframe,
+			"timestamp": timestamp,
+			"value":     ss.SessionPayment.Result(),
+		},
+		{
+			"type":      types.StatisticTypeSessionRatingDistribution,
+			"timeframe": ss.Timeframe,
+			"timestamp": timestamp,
+			"value":     ss.SessionRating.Result(),
+		},
 		{
 			"type":      types.StatisticTypeStartSession,
 			"timeframe": ss.Timeframe,
@@ -84,99 +117,230 @@ func (ss *SessionStatistics) Result(timestamp time.Time) []bson.M {
 	}
 }
 
-func StatisticsFromSessions(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp time.Time, excludeAddrs []string) (result []bson.M, err error) {
-	log.Println("StatisticsFromSessions", minTimestamp, maxTimestamp)
+func StatisticsFromSessions(ctx context.Context, db *mongo.Database, fromTimestamp, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSessions", fromTimestamp, minTimestamp, maxTimestamp, minHourTimestamp, loc)
 
 	filter := bson.M{}
+	if !fromTimestamp.IsZero() {
+		filter["$or"] = bson.A{
+			bson.M{
+				"end_timestamp": bson.M{
+					"$gte": fromTimestamp,
+				},
+			},
+			bson.M{
+				"end_timestamp": time.Time{},
+			},
+			bson.M{
+				"end_timestamp": bson.M{
+					"$exists": false,
+				},
+			},
+		}
+	}
+
 	projection := bson.M{
 		"_id":             0,
 		"acc_addr":        1,
+		"bandwidth":       1,
+		"duration":        1,
 		"node_addr":       1,
 		"end_timestamp":   1,
 		"payment":         1,
+		"rating":          1,
 		"staking_reward":  1,
 		"start_timestamp": 1,
 	}
 
-	items, err := database.SessionFind(ctx, db, filter, options.Find().SetProjection(projection))
+	_sort := bson.D{
+		bson.E{Key: "start_timestamp", Value: 1},
+	}
+
+	cursor, err := database.SessionFindCursor(ctx, db, filter, options.Find().SetProjection(projection).SetSort(_sort))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer cursor.Close(ctx)
+
+	buckets := types.NewBuckets(func(_ string, t time.Time, v *SessionStatistics) error {
+		return database.SendStatistics(ctx, out, loc, v.Result(t)...)
+	})
+
 	var (
-		d = make(map[time.Time]*SessionStatistics)
-		w = make(map[time.Time]*SessionStatistics)
-		m = make(map[time.Time]*SessionStatistics)
-		y = make(map[time.Time]*SessionStatistics)
+		h = buckets.Items("hour")
+		d = buckets.Items("day")
+		w = buckets.Items("week")
+		m = buckets.Items("month")
+		y = buckets.Items("year")
 	)
 
-	for i := 0; i < len(items); i++ {
-		exclude := utils.ContainsString(excludeAddrs, items[i].AccAddr)
+	for cursor.Next(ctx) {
+		var item models.Session
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
+		}
+
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
+		}
+
+		if err := buckets.CloseBefore(startTimestamp); err != nil {
+			return err
+		}
+
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		if !hourEndTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourEndTimestamp]; !ok {
+				h[hourEndTimestamp] = NewSessionStatistics("hour")
+			}
+		}
+
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
+		if _, ok := d[dayEndTimestamp]; !ok {
+			d[dayEndTimestamp] = NewSessionStatistics("day")
+		}
+
+		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
+		if _, ok := w[weekEndTimestamp]; !ok {
+			w[weekEndTimestamp] = NewSessionStatistics("week")
+		}
+
+		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
+		if _, ok := m[monthEndTimestamp]; !ok {
+			m[monthEndTimestamp] = NewSessionStatistics("month")
+		}
+
+		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
+		if _, ok := y[yearEndTimestamp]; !ok {
+			y[yearEndTimestamp] = NewSessionStatistics("year")
+		}
+
+		if item.Payment != nil {
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].BytesPayment = h[hourEndTimestamp].BytesPayment.Add(item.Payment)
+			}
+
+			d[dayEndTimestamp].BytesPayment = d[dayEndTimestamp].BytesPayment.Add(item.Payment)
+			w[weekEndTimestamp].BytesPayment = w[weekEndTimestamp].BytesPayment.Add(item.Payment)
+			m[monthEndTimestamp].BytesPayment = m[monthEndTimestamp].BytesPayment.Add(item.Payment)
+			y[yearEndTimestamp].BytesPayment = y[yearEndTimestamp].BytesPayment.Add(item.Payment)
+
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].SessionPayment.AddCoin(item.Payment)
+			}
+
+			d[dayEndTimestamp].SessionPayment.AddCoin(item.Payment)
+			w[weekEndTimestamp].SessionPayment.AddCoin(item.Payment)
+			m[monthEndTimestamp].SessionPayment.AddCoin(item.Payment)
+			y[yearEndTimestamp].SessionPayment.AddCoin(item.Payment)
+		}
+		if item.StakingReward != nil {
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].BytesStakingReward = h[hourEndTimestamp].BytesStakingReward.Add(item.StakingReward)
+			}
+
+			d[dayEndTimestamp].BytesStakingReward = d[dayEndTimestamp].BytesStakingReward.Add(item.StakingReward)
+			w[weekEndTimestamp].BytesStakingReward = w[weekEndTimestamp].BytesStakingReward.Add(item.StakingReward)
+			m[monthEndTimestamp].BytesStakingReward = m[monthEndTimestamp].BytesStakingReward.Add(item.StakingReward)
+			y[yearEndTimestamp].BytesStakingReward = y[yearEndTimestamp].BytesStakingReward.Add(item.StakingReward)
+		}
+
+		exclude := utils.ContainsString(excludeAddrs, item.AccAddr)
 		if exclude {
 			continue
 		}
 
-		startTimestamp := items[i].StartTimestamp
-		if items[i].StartTimestamp.IsZero() {
-			startTimestamp = minTimestamp
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
 		}
 
-		endTimestamp := items[i].EndTimestamp
-		if items[i].EndTimestamp.IsZero() {
-			endTimestamp = maxTimestamp
+		for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+			if _, ok := h[t]; !ok {
+				h[t] = NewSessionStatistics("hour")
+			}
+
+			h[t].ActiveSession += 1
+			h[t].SessionAddress[item.AccAddr] = true
+			h[t].SessionNode[item.NodeAddr] = true
 		}
 
-		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
 		for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
 			if _, ok := d[t]; !ok {
 				d[t] = NewSessionStatistics("day")
 			}
 
 			d[t].ActiveSession += 1
-			d[t].SessionAddress[items[i].AccAddr] = true
-			d[t].SessionNode[items[i].NodeAddr] = true
+			d[t].SessionAddress[item.AccAddr] = true
+			d[t].SessionNode[item.NodeAddr] = true
 		}
 
-		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
 		for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
 			if _, ok := w[t]; !ok {
 				w[t] = NewSessionStatistics("week")
 			}
 
 			w[t].ActiveSession += 1
-			w[t].SessionAddress[items[i].AccAddr] = true
-			w[t].SessionNode[items[i].NodeAddr] = true
+			w[t].SessionAddress[item.AccAddr] = true
+			w[t].SessionNode[item.NodeAddr] = true
 		}
 
-		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
 		for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
 			if _, ok := m[t]; !ok {
 				m[t] = NewSessionStatistics("month")
 			}
 
 			m[t].ActiveSession += 1
-			m[t].SessionAddress[items[i].AccAddr] = true
-			m[t].SessionNode[items[i].NodeAddr] = true
+			m[t].SessionAddress[item.AccAddr] = true
+			m[t].SessionNode[item.NodeAddr] = true
 		}
 
-		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
 		for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
 			if _, ok := y[t]; !ok {
 				y[t] = NewSessionStatistics("year")
 			}
 
 			y[t].ActiveSession += 1
-			y[t].SessionAddress[items[i].AccAddr] = true
-			y[t].SessionNode[items[i].NodeAddr] = true
+			y[t].SessionAddress[item.AccAddr] = true
+			y[t].SessionNode[item.NodeAddr] = true
 		}
 
-		if !items[i].EndTimestamp.IsZero() {
+		if !item.EndTimestamp.IsZero() {
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].EndSession += 1
+			}
+
 			d[dayEndTimestamp].EndSession += 1
 			w[weekEndTimestamp].EndSession += 1
 			m[monthEndTimestamp].EndSession += 1
 			y[yearEndTimestamp].EndSession += 1
+
+			for _, v := range []*SessionStatistics{h[hourEndTimestamp], d[dayEndTimestamp], w[weekEndTimestamp], m[monthEndTimestamp], y[yearEndTimestamp]} {
+				if v == nil {
+					continue
+				}
+
+				v.SessionDuration.Add(float64(item.Duration))
+				if item.Bandwidth != nil {
+					v.SessionBytes.AddString(item.Bandwidth.Sum())
+				}
+				if item.Rating != 0 {
+					v.SessionRating.Add(float64(item.Rating))
+				}
+			}
 		}
-		if !items[i].StartTimestamp.IsZero() {
+		if !item.StartTimestamp.IsZero() {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].StartSession += 1
+			}
+
 			d[dayStartTimestamp].StartSession += 1
 			w[weekStartTimestamp].StartSession += 1
 			m[monthStartTimestamp].StartSession += 1
@@ -184,58 +348,9 @@ func StatisticsFromSessions(ctx context.Context, db *mongo.Database, minTimestam
 		}
 	}
 
-	for i := 0; i < len(items); i++ {
-		endTimestamp := items[i].EndTimestamp
-		if items[i].EndTimestamp.IsZero() {
-			endTimestamp = maxTimestamp
-		}
-
-		dayEndTimestamp := utils.DayDate(endTimestamp)
-		if _, ok := d[dayEndTimestamp]; !ok {
-			d[dayEndTimestamp] = NewSessionStatistics("day")
-		}
-
-		weekEndTimestamp := utils.ISOWeekDate(endTimestamp)
-		if _, ok := w[weekEndTimestamp]; !ok {
-			w[weekEndTimestamp] = NewSessionStatistics("week")
-		}
-
-		monthEndTimestamp := utils.MonthDate(endTimestamp)
-		if _, ok := m[monthEndTimestamp]; !ok {
-			m[monthEndTimestamp] = NewSessionStatistics("month")
-		}
-
-		yearEndTimestamp := utils.YearDate(endTimestamp)
-		if _, ok := y[yearEndTimestamp]; !ok {
-			y[yearEndTimestamp] = NewSessionStatistics("year")
-		}
-
-		if items[i].Payment != nil {
-			d[dayEndTimestamp].BytesPayment = d[dayEndTimestamp].BytesPayment.Add(items[i].Payment)
-			w[weekEndTimestamp].BytesPayment = w[weekEndTimestamp].BytesPayment.Add(items[i].Payment)
-			m[monthEndTimestamp].BytesPayment = m[monthEndTimestamp].BytesPayment.Add(items[i].Payment)
-			y[yearEndTimestamp].BytesPayment = y[yearEndTimestamp].BytesPayment.Add(items[i].Payment)
-		}
-		if items[i].StakingReward != nil {
-			d[dayEndTimestamp].BytesStakingReward = d[dayEndTimestamp].BytesStakingReward.Add(items[i].StakingReward)
-			w[weekEndTimestamp].BytesStakingReward = w[weekEndTimestamp].BytesStakingReward.Add(items[i].StakingReward)
-			m[monthEndTimestamp].BytesStakingReward = m[monthEndTimestamp].BytesStakingReward.Add(items[i].StakingReward)
-			y[yearEndTimestamp].BytesStakingReward = y[yearEndTimestamp].BytesStakingReward.Add(items[i].StakingReward)
-		}
-	}
-
-	for t := range d {
-		result = append(result, d[t].Result(t)...)
-	}
-	for t := range w {
-		result = append(result, w[t].Result(t)...)
-	}
-	for t := range m {
-		result = append(result, m[t].Result(t)...)
-	}
-	for t := range y {
-		result = append(result, y[t].Result(t)...)
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	return result, nil
+	return buckets.Close()
 }
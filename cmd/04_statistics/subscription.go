@@ -11,35 +11,38 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
 
 type (
 	SubscriptionStatistics struct {
-		Timeframe           string
-		ActiveSubscription  int64
-		BytesSubscription   int64
-		EndSubscription     int64
-		HoursSubscription   int64
-		PlanPayment         types.Coins
-		PlanStakingReward   types.Coins
-		PlanSubscription    int64
-		StartSubscription   int64
-		SubscriptionBytes   string
-		SubscriptionDeposit types.Coins
-		SubscriptionHours   int64
-		SubscriptionRefund  types.Coins
+		Timeframe                       string
+		ActiveSubscription              int64
+		BytesSubscription               int64
+		EndSubscription                 int64
+		HoursSubscription               int64
+		PlanPayment                     types.Coins
+		PlanStakingReward               types.Coins
+		PlanSubscription                int64
+		StartSubscription               int64
+		SubscriptionBytes               string
+		SubscriptionDeposit             types.Coins
+		SubscriptionDepositDistribution types.Histograms
+		SubscriptionHours               int64
+		SubscriptionRefund              types.Coins
 	}
 )
 
 func NewSubscriptionStatistics(timeframe string) *SubscriptionStatistics {
 	return &SubscriptionStatistics{
-		Timeframe:           timeframe,
-		PlanPayment:         types.NewCoins(nil),
-		PlanStakingReward:   types.NewCoins(nil),
-		SubscriptionDeposit: types.NewCoins(nil),
-		SubscriptionRefund:  types.NewCoins(nil),
+		Timeframe:                       timeframe,
+		PlanPayment:                     types.NewCoins(nil),
+		PlanStakingReward:               types.NewCoins(nil),
+		SubscriptionDeposit:             types.NewCoins(nil),
+		SubscriptionDepositDistribution: make(types.Histograms),
+		SubscriptionRefund:              types.NewCoins(nil),
 	}
 }
 
@@ -105,6 +108,12 @@ func (s *SubscriptionStatistics) Result(timestamp time.Time) []bson.M {
 			"timestamp": timestamp,
 			"value":     s.SubscriptionDeposit,
 		},
+		{
+			"type":      types.StatisticTypeSubscriptionDepositDistribution,
+			"timeframe": s.Timeframe,
+			"timestamp": timestamp,
+			"value":     s.SubscriptionDepositDistribution.Result(),
+		},
 		{
 			"type":      types.StatisticTypeSubscriptionHours,
 			"timeframe": s.Timeframe,
@@ -120,10 +129,28 @@ func (s *SubscriptionStatistics) Result(timestamp time.Time) []bson.M {
 	}
 }
 
-func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp time.Time, excludeAddrs []string) (result []bson.M, err error) {
-	log.Println("StatisticsFromSubscriptions", minTimestamp, maxTimestamp)
+func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, fromTimestamp, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSubscriptions", fromTimestamp, minTimestamp, maxTimestamp, minHourTimestamp, loc)
 
 	filter := bson.M{}
+	if !fromTimestamp.IsZero() {
+		filter["$or"] = bson.A{
+			bson.M{
+				"end_timestamp": bson.M{
+					"$gte": fromTimestamp,
+				},
+			},
+			bson.M{
+				"end_timestamp": time.Time{},
+			},
+			bson.M{
+				"end_timestamp": bson.M{
+					"$exists": false,
+				},
+			},
+		}
+	}
+
 	projection := bson.M{
 		"_id":             0,
 		"acc_addr":        1,
@@ -138,35 +165,161 @@ func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTim
 		"start_timestamp": 1,
 	}
 
-	items, err := database.SubscriptionFind(ctx, db, filter, options.Find().SetProjection(projection))
+	_sort := bson.D{
+		bson.E{Key: "start_timestamp", Value: 1},
+	}
+
+	cursor, err := database.SubscriptionFindCursor(ctx, db, filter, options.Find().SetProjection(projection).SetSort(_sort))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer cursor.Close(ctx)
+
+	buckets := types.NewBuckets(func(_ string, t time.Time, v *SubscriptionStatistics) error {
+		return database.SendStatistics(ctx, out, loc, v.Result(t)...)
+	})
+
 	var (
-		d = make(map[time.Time]*SubscriptionStatistics)
-		w = make(map[time.Time]*SubscriptionStatistics)
-		m = make(map[time.Time]*SubscriptionStatistics)
-		y = make(map[time.Time]*SubscriptionStatistics)
+		h = buckets.Items("hour")
+		d = buckets.Items("day")
+		w = buckets.Items("week")
+		m = buckets.Items("month")
+		y = buckets.Items("year")
 	)
 
-	for i := 0; i < len(items); i++ {
-		exclude := utils.ContainsString(excludeAddrs, items[i].AccAddr)
+	for cursor.Next(ctx) {
+		var item models.Subscription
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
+		}
+
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
+		}
+
+		if err := buckets.CloseBefore(startTimestamp); err != nil {
+			return err
+		}
+
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		if !hourStartTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourStartTimestamp]; !ok {
+				h[hourStartTimestamp] = NewSubscriptionStatistics("hour")
+			}
+		}
+		if !hourEndTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourEndTimestamp]; !ok {
+				h[hourEndTimestamp] = NewSubscriptionStatistics("hour")
+			}
+		}
+
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
+		if _, ok := d[dayStartTimestamp]; !ok {
+			d[dayStartTimestamp] = NewSubscriptionStatistics("day")
+		}
+		if _, ok := d[dayEndTimestamp]; !ok {
+			d[dayEndTimestamp] = NewSubscriptionStatistics("day")
+		}
+
+		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
+		if _, ok := w[weekStartTimestamp]; !ok {
+			w[weekStartTimestamp] = NewSubscriptionStatistics("week")
+		}
+		if _, ok := w[weekEndTimestamp]; !ok {
+			w[weekEndTimestamp] = NewSubscriptionStatistics("week")
+		}
+
+		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
+		if _, ok := m[monthStartTimestamp]; !ok {
+			m[monthStartTimestamp] = NewSubscriptionStatistics("month")
+		}
+		if _, ok := m[monthEndTimestamp]; !ok {
+			m[monthEndTimestamp] = NewSubscriptionStatistics("month")
+		}
+
+		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
+		if _, ok := y[yearStartTimestamp]; !ok {
+			y[yearStartTimestamp] = NewSubscriptionStatistics("year")
+		}
+		if _, ok := y[yearEndTimestamp]; !ok {
+			y[yearEndTimestamp] = NewSubscriptionStatistics("year")
+		}
+
+		if item.Deposit != nil {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].SubscriptionDeposit = h[hourStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
+			}
+
+			d[dayStartTimestamp].SubscriptionDeposit = d[dayStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
+			w[weekStartTimestamp].SubscriptionDeposit = w[weekStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
+			m[monthStartTimestamp].SubscriptionDeposit = m[monthStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
+			y[yearStartTimestamp].SubscriptionDeposit = y[yearStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
+
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].SubscriptionDepositDistribution.AddCoin(item.Deposit)
+			}
+
+			d[dayStartTimestamp].SubscriptionDepositDistribution.AddCoin(item.Deposit)
+			w[weekStartTimestamp].SubscriptionDepositDistribution.AddCoin(item.Deposit)
+			m[monthStartTimestamp].SubscriptionDepositDistribution.AddCoin(item.Deposit)
+			y[yearStartTimestamp].SubscriptionDepositDistribution.AddCoin(item.Deposit)
+		}
+		if item.Payment != nil {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].PlanPayment = h[hourStartTimestamp].PlanPayment.Add(item.Payment)
+			}
+
+			d[dayStartTimestamp].PlanPayment = d[dayStartTimestamp].PlanPayment.Add(item.Payment)
+			w[weekStartTimestamp].PlanPayment = w[weekStartTimestamp].PlanPayment.Add(item.Payment)
+			m[monthStartTimestamp].PlanPayment = m[monthStartTimestamp].PlanPayment.Add(item.Payment)
+			y[yearStartTimestamp].PlanPayment = y[yearStartTimestamp].PlanPayment.Add(item.Payment)
+		}
+		if item.Refund != nil {
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].SubscriptionRefund = h[hourEndTimestamp].SubscriptionRefund.Add(item.Refund)
+			}
+
+			d[dayEndTimestamp].SubscriptionRefund = d[dayEndTimestamp].SubscriptionRefund.Add(item.Refund)
+			w[weekEndTimestamp].SubscriptionRefund = w[weekEndTimestamp].SubscriptionRefund.Add(item.Refund)
+			m[monthEndTimestamp].SubscriptionRefund = m[monthEndTimestamp].SubscriptionRefund.Add(item.Refund)
+			y[yearEndTimestamp].SubscriptionRefund = y[yearEndTimestamp].SubscriptionRefund.Add(item.Refund)
+		}
+		if item.StakingReward != nil {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].PlanStakingReward = h[hourStartTimestamp].PlanStakingReward.Add(item.StakingReward)
+			}
+
+			d[dayStartTimestamp].PlanStakingReward = d[dayStartTimestamp].PlanStakingReward.Add(item.StakingReward)
+			w[weekStartTimestamp].PlanStakingReward = w[weekStartTimestamp].PlanStakingReward.Add(item.StakingReward)
+			m[monthStartTimestamp].PlanStakingReward = m[monthStartTimestamp].PlanStakingReward.Add(item.StakingReward)
+			y[yearStartTimestamp].PlanStakingReward = y[yearStartTimestamp].PlanStakingReward.Add(item.StakingReward)
+		}
+
+		exclude := utils.ContainsString(excludeAddrs, item.AccAddr)
 		if exclude {
 			continue
 		}
 
-		startTimestamp := items[i].StartTimestamp
-		if items[i].StartTimestamp.IsZero() {
-			startTimestamp = minTimestamp
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
 		}
 
-		endTimestamp := items[i].EndTimestamp
-		if items[i].EndTimestamp.IsZero() {
-			endTimestamp = maxTimestamp
+		for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+			if _, ok := h[t]; !ok {
+				h[t] = NewSubscriptionStatistics("hour")
+			}
+
+			h[t].ActiveSubscription += 1
 		}
 
-		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
 		for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
 			if _, ok := d[t]; !ok {
 				d[t] = NewSubscriptionStatistics("day")
@@ -175,7 +328,6 @@ func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTim
 			d[t].ActiveSubscription += 1
 		}
 
-		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
 		for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
 			if _, ok := w[t]; !ok {
 				w[t] = NewSubscriptionStatistics("week")
@@ -184,7 +336,6 @@ func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTim
 			w[t].ActiveSubscription += 1
 		}
 
-		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
 		for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
 			if _, ok := m[t]; !ok {
 				m[t] = NewSubscriptionStatistics("month")
@@ -193,7 +344,6 @@ func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTim
 			m[t].ActiveSubscription += 1
 		}
 
-		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
 		for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
 			if _, ok := y[t]; !ok {
 				y[t] = NewSubscriptionStatistics("year")
@@ -202,42 +352,64 @@ func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTim
 			y[t].ActiveSubscription += 1
 		}
 
-		if !items[i].EndTimestamp.IsZero() {
+		if !item.EndTimestamp.IsZero() {
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].EndSubscription += 1
+			}
+
 			d[dayEndTimestamp].EndSubscription += 1
 			w[weekEndTimestamp].EndSubscription += 1
 			m[monthEndTimestamp].EndSubscription += 1
 			y[yearEndTimestamp].EndSubscription += 1
 		}
-		if items[i].Gigabytes != 0 {
+		if item.Gigabytes != 0 {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].BytesSubscription += 1
+				h[hourStartTimestamp].SubscriptionBytes = utils.MustIntFromString(h[hourStartTimestamp].SubscriptionBytes).Add(hubtypes.Gigabyte.MulRaw(item.Gigabytes)).String()
+			}
+
 			d[dayStartTimestamp].BytesSubscription += 1
 			w[weekStartTimestamp].BytesSubscription += 1
 			m[monthStartTimestamp].BytesSubscription += 1
 			y[yearStartTimestamp].BytesSubscription += 1
 
-			bytes := hubtypes.Gigabyte.MulRaw(items[i].Gigabytes)
+			bytes := hubtypes.Gigabyte.MulRaw(item.Gigabytes)
 			d[dayStartTimestamp].SubscriptionBytes = utils.MustIntFromString(d[dayStartTimestamp].SubscriptionBytes).Add(bytes).String()
 			w[weekStartTimestamp].SubscriptionBytes = utils.MustIntFromString(w[weekStartTimestamp].SubscriptionBytes).Add(bytes).String()
 			m[monthStartTimestamp].SubscriptionBytes = utils.MustIntFromString(m[monthStartTimestamp].SubscriptionBytes).Add(bytes).String()
 			y[yearStartTimestamp].SubscriptionBytes = utils.MustIntFromString(y[yearStartTimestamp].SubscriptionBytes).Add(bytes).String()
 		}
-		if items[i].Hours != 0 {
+		if item.Hours != 0 {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].HoursSubscription += 1
+				h[hourStartTimestamp].SubscriptionHours += item.Hours
+			}
+
 			d[dayStartTimestamp].HoursSubscription += 1
 			w[weekStartTimestamp].HoursSubscription += 1
 			m[monthStartTimestamp].HoursSubscription += 1
 			y[yearStartTimestamp].HoursSubscription += 1
 
-			d[dayStartTimestamp].SubscriptionHours += items[i].Hours
-			w[weekStartTimestamp].SubscriptionHours += items[i].Hours
-			m[monthStartTimestamp].SubscriptionHours += items[i].Hours
-			y[yearStartTimestamp].SubscriptionHours += items[i].Hours
+			d[dayStartTimestamp].SubscriptionHours += item.Hours
+			w[weekStartTimestamp].SubscriptionHours += item.Hours
+			m[monthStartTimestamp].SubscriptionHours += item.Hours
+			y[yearStartTimestamp].SubscriptionHours += item.Hours
 		}
-		if items[i].PlanID != 0 {
+		if item.PlanID != 0 {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].PlanSubscription += 1
+			}
+
 			d[dayStartTimestamp].PlanSubscription += 1
 			w[weekStartTimestamp].PlanSubscription += 1
 			m[monthStartTimestamp].PlanSubscription += 1
 			y[yearStartTimestamp].PlanSubscription += 1
 		}
-		if !items[i].StartTimestamp.IsZero() {
+		if !item.StartTimestamp.IsZero() {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].StartSubscription += 1
+			}
+
 			d[dayStartTimestamp].StartSubscription += 1
 			w[weekStartTimestamp].StartSubscription += 1
 			m[monthStartTimestamp].StartSubscription += 1
@@ -245,87 +417,9 @@ func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTim
 		}
 	}
 
-	for i := 0; i < len(items); i++ {
-		startTimestamp := items[i].StartTimestamp
-		if items[i].StartTimestamp.IsZero() {
-			startTimestamp = minTimestamp
-		}
-
-		endTimestamp := items[i].EndTimestamp
-		if items[i].EndTimestamp.IsZero() {
-			endTimestamp = maxTimestamp
-		}
-
-		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
-		if _, ok := d[dayStartTimestamp]; !ok {
-			d[dayStartTimestamp] = NewSubscriptionStatistics("day")
-		}
-		if _, ok := d[dayEndTimestamp]; !ok {
-			d[dayEndTimestamp] = NewSubscriptionStatistics("day")
-		}
-
-		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
-		if _, ok := w[weekStartTimestamp]; !ok {
-			w[weekStartTimestamp] = NewSubscriptionStatistics("week")
-		}
-		if _, ok := w[weekEndTimestamp]; !ok {
-			w[weekEndTimestamp] = NewSubscriptionStatistics("week")
-		}
-
-		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
-		if _, ok := m[monthStartTimestamp]; !ok {
-			m[monthStartTimestamp] = NewSubscriptionStatistics("month")
-		}
-		if _, ok := m[monthEndTimestamp]; !ok {
-			m[monthEndTimestamp] = NewSubscriptionStatistics("month")
-		}
-
-		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
-		if _, ok := y[yearStartTimestamp]; !ok {
-			y[yearStartTimestamp] = NewSubscriptionStatistics("year")
-		}
-		if _, ok := y[yearEndTimestamp]; !ok {
-			y[yearEndTimestamp] = NewSubscriptionStatistics("year")
-		}
-
-		if items[i].Deposit != nil {
-			d[dayStartTimestamp].SubscriptionDeposit = d[dayStartTimestamp].SubscriptionDeposit.Add(items[i].Deposit)
-			w[weekStartTimestamp].SubscriptionDeposit = w[weekStartTimestamp].SubscriptionDeposit.Add(items[i].Deposit)
-			m[monthStartTimestamp].SubscriptionDeposit = m[monthStartTimestamp].SubscriptionDeposit.Add(items[i].Deposit)
-			y[yearStartTimestamp].SubscriptionDeposit = y[yearStartTimestamp].SubscriptionDeposit.Add(items[i].Deposit)
-		}
-		if items[i].Payment != nil {
-			d[dayStartTimestamp].PlanPayment = d[dayStartTimestamp].PlanPayment.Add(items[i].Payment)
-			w[weekStartTimestamp].PlanPayment = w[weekStartTimestamp].PlanPayment.Add(items[i].Payment)
-			m[monthStartTimestamp].PlanPayment = m[monthStartTimestamp].PlanPayment.Add(items[i].Payment)
-			y[yearStartTimestamp].PlanPayment = y[yearStartTimestamp].PlanPayment.Add(items[i].Payment)
-		}
-		if items[i].Refund != nil {
-			d[dayEndTimestamp].SubscriptionRefund = d[dayEndTimestamp].SubscriptionRefund.Add(items[i].Refund)
-			w[weekEndTimestamp].SubscriptionRefund = w[weekEndTimestamp].SubscriptionRefund.Add(items[i].Refund)
-			m[monthEndTimestamp].SubscriptionRefund = m[monthEndTimestamp].SubscriptionRefund.Add(items[i].Refund)
-			y[yearEndTimestamp].SubscriptionRefund = y[yearEndTimestamp].SubscriptionRefund.Add(items[i].Refund)
-		}
-		if items[i].StakingReward != nil {
-			d[dayStartTimestamp].PlanStakingReward = d[dayStartTimestamp].PlanStakingReward.Add(items[i].StakingReward)
-			w[weekStartTimestamp].PlanStakingReward = w[weekStartTimestamp].PlanStakingReward.Add(items[i].StakingReward)
-			m[monthStartTimestamp].PlanStakingReward = m[monthStartTimestamp].PlanStakingReward.Add(items[i].StakingReward)
-			y[yearStartTimestamp].PlanStakingReward = y[yearStartTimestamp].PlanStakingReward.Add(items[i].StakingReward)
-		}
-	}
-
-	for t := range d {
-		result = append(result, d[t].Result(t)...)
-	}
-	for t := range w {
-		result = append(result, w[t].Result(t)...)
-	}
-	for t := range m {
-		result = append(result, m[t].Result(t)...)
-	}
-	for t := range y {
-		result = append(result, y[t].Result(t)...)
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	return result, nil
+	return buckets.Close()
 }
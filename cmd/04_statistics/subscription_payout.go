@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
@@ -47,10 +48,16 @@ func (s *SubscriptionPayoutStatistics) Result(timestamp time.Time) []bson.M {
 	}
 }
 
-func StatisticsFromSubscriptionPayouts(ctx context.Context, db *mongo.Database) (result []bson.M, err error) {
-	log.Println("StatisticsFromSubscriptionPayouts")
+func StatisticsFromSubscriptionPayouts(ctx context.Context, db *mongo.Database, fromTimestamp, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSubscriptionPayouts", fromTimestamp, minHourTimestamp, loc)
 
 	filter := bson.M{}
+	if !fromTimestamp.IsZero() {
+		filter["timestamp"] = bson.M{
+			"$gte": fromTimestamp,
+		}
+	}
+
 	projection := bson.M{
 		"_id":            0,
 		"payment":        1,
@@ -58,62 +65,83 @@ func StatisticsFromSubscriptionPayouts(ctx context.Context, db *mongo.Database)
 		"timestamp":      1,
 	}
 
-	items, err := database.SubscriptionPayoutFind(ctx, db, filter, options.Find().SetProjection(projection))
+	_sort := bson.D{
+		bson.E{Key: "timestamp", Value: 1},
+	}
+
+	cursor, err := database.SubscriptionPayoutFindCursor(ctx, db, filter, options.Find().SetProjection(projection).SetSort(_sort))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer cursor.Close(ctx)
+
+	buckets := types.NewBuckets(func(_ string, t time.Time, v *SubscriptionPayoutStatistics) error {
+		return database.SendStatistics(ctx, out, loc, v.Result(t)...)
+	})
+
 	var (
-		d = make(map[time.Time]*SubscriptionPayoutStatistics)
-		w = make(map[time.Time]*SubscriptionPayoutStatistics)
-		m = make(map[time.Time]*SubscriptionPayoutStatistics)
-		y = make(map[time.Time]*SubscriptionPayoutStatistics)
+		h = buckets.Items("hour")
+		d = buckets.Items("day")
+		w = buckets.Items("week")
+		m = buckets.Items("month")
+		y = buckets.Items("year")
 	)
 
-	for i := 0; i < len(items); i++ {
-		dayTimestamp := utils.DayDate(items[i].Timestamp)
+	for cursor.Next(ctx) {
+		var item models.SubscriptionPayout
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		if err := buckets.CloseBefore(item.Timestamp.In(loc)); err != nil {
+			return err
+		}
+
+		hourTimestamp := utils.HourDate(item.Timestamp.In(loc))
+		if !hourTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourTimestamp]; !ok {
+				h[hourTimestamp] = NewSubscriptionPayoutStatistics("hour")
+			}
+
+			h[hourTimestamp].HoursPayment = h[hourTimestamp].HoursPayment.Add(item.Payment)
+			h[hourTimestamp].HoursStakingReward = h[hourTimestamp].HoursStakingReward.Add(item.StakingReward)
+		}
+
+		dayTimestamp := utils.DayDate(item.Timestamp.In(loc))
 		if _, ok := d[dayTimestamp]; !ok {
 			d[dayTimestamp] = NewSubscriptionPayoutStatistics("day")
 		}
 
-		weekTimestamp := utils.ISOWeekDate(items[i].Timestamp)
+		weekTimestamp := utils.ISOWeekDate(item.Timestamp.In(loc))
 		if _, ok := w[weekTimestamp]; !ok {
 			w[weekTimestamp] = NewSubscriptionPayoutStatistics("week")
 		}
 
-		monthTimestamp := utils.MonthDate(items[i].Timestamp)
+		monthTimestamp := utils.MonthDate(item.Timestamp.In(loc))
 		if _, ok := m[monthTimestamp]; !ok {
 			m[monthTimestamp] = NewSubscriptionPayoutStatistics("month")
 		}
 
-		yearTimestamp := utils.YearDate(items[i].Timestamp)
+		yearTimestamp := utils.YearDate(item.Timestamp.In(loc))
 		if _, ok := y[yearTimestamp]; !ok {
 			y[yearTimestamp] = NewSubscriptionPayoutStatistics("year")
 		}
 
-		d[dayTimestamp].HoursPayment = d[dayTimestamp].HoursPayment.Add(items[i].Payment)
-		w[weekTimestamp].HoursPayment = w[weekTimestamp].HoursPayment.Add(items[i].Payment)
-		m[monthTimestamp].HoursPayment = m[monthTimestamp].HoursPayment.Add(items[i].Payment)
-		y[yearTimestamp].HoursPayment = y[yearTimestamp].HoursPayment.Add(items[i].Payment)
+		d[dayTimestamp].HoursPayment = d[dayTimestamp].HoursPayment.Add(item.Payment)
+		w[weekTimestamp].HoursPayment = w[weekTimestamp].HoursPayment.Add(item.Payment)
+		m[monthTimestamp].HoursPayment = m[monthTimestamp].HoursPayment.Add(item.Payment)
+		y[yearTimestamp].HoursPayment = y[yearTimestamp].HoursPayment.Add(item.Payment)
 
-		d[dayTimestamp].HoursStakingReward = d[dayTimestamp].HoursStakingReward.Add(items[i].StakingReward)
-		w[weekTimestamp].HoursStakingReward = w[weekTimestamp].HoursStakingReward.Add(items[i].StakingReward)
-		m[monthTimestamp].HoursStakingReward = m[monthTimestamp].HoursStakingReward.Add(items[i].StakingReward)
-		y[yearTimestamp].HoursStakingReward = y[yearTimestamp].HoursStakingReward.Add(items[i].StakingReward)
+		d[dayTimestamp].HoursStakingReward = d[dayTimestamp].HoursStakingReward.Add(item.StakingReward)
+		w[weekTimestamp].HoursStakingReward = w[weekTimestamp].HoursStakingReward.Add(item.StakingReward)
+		m[monthTimestamp].HoursStakingReward = m[monthTimestamp].HoursStakingReward.Add(item.StakingReward)
+		y[yearTimestamp].HoursStakingReward = y[yearTimestamp].HoursStakingReward.Add(item.StakingReward)
 	}
 
-	for t := range d {
-		result = append(result, d[t].Result(t)...)
-	}
-	for t := range w {
-		result = append(result, w[t].Result(t)...)
-	}
-	for t := range m {
-		result = append(result, m[t].Result(t)...)
-	}
-	for t := range y {
-		result = append(result, y[t].Result(t)...)
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	return result, nil
+	return buckets.Close()
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+func inspectNodeCertificate(ctx context.Context, db *mongo.Database, nodeAddr, remoteURL string) error {
+	set := bson.M{
+		"health.certificate_fetch_timestamp": time.Now().UTC(),
+	}
+	update := bson.M{
+		"$set": set,
+	}
+
+	certificate, err := nodetypes.FetchCertificate(ctx, remoteURL, timeout)
+	if err != nil {
+		// A certificate that can no longer be fetched must not keep matching
+		// the certificate filters.
+		set["health.certificate_fetch_error"] = err.Error()
+		update["$unset"] = bson.M{
+			"certificate": "",
+		}
+	} else {
+		set["certificate"] = certificate
+		set["health.certificate_fetch_error"] = ""
+	}
+
+	filter := bson.M{
+		"addr": nodeAddr,
+	}
+	projection := bson.M{
+		"_id": 1,
+	}
+
+	_, err = database.NodeFindOneAndUpdate(ctx, db, filter, update, options.FindOneAndUpdate().SetProjection(projection))
+	return err
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	hubtypes "github.com/sentinel-official/hub/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+func exchangeNodeConfig(ctx context.Context, db *mongo.Database, wallet *Wallet, nodeAddr, remoteURL string, nodeType uint64) (exchangeErr, err error) {
+	filter := bson.M{
+		"acc_addr":  wallet.AccAddr,
+		"node_addr": nodeAddr,
+		"status":    hubtypes.StatusActive.String(),
+	}
+	opts := options.FindOne().
+		SetSort(bson.D{
+			bson.E{Key: "id", Value: -1},
+		})
+
+	session, err := database.SessionFindOne(ctx, db, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// A node without a session for the wallet cannot be exchanged with, which
+	// says nothing about the node, so its health fields are left alone.
+	if session == nil {
+		return nil, nil
+	}
+
+	var (
+		serverConfig []byte
+		health       = bson.M{
+			"health.config_exchange_timestamp": time.Now().UTC(),
+			"health.session_id":                session.ID,
+			"health.subscription_id":           session.SubscriptionID,
+		}
+	)
+
+	clientConfig, exchangeErr := nodetypes.NewConfigExchangeKey(nodeType)
+	if exchangeErr == nil {
+		serverConfig, exchangeErr = nodetypes.ExchangeConfig(ctx, remoteURL, wallet.AccAddr, session.ID, clientConfig, wallet.PrivKey, timeout)
+	}
+
+	if exchangeErr != nil {
+		health["health.config_exchange_error"] = exchangeErr.Error()
+	} else {
+		health["health.client_config"] = clientConfig
+		health["health.config_exchange_error"] = ""
+		health["health.server_config"] = serverConfig
+	}
+
+	filter = bson.M{
+		"addr": nodeAddr,
+	}
+	update := bson.M{
+		"$set": health,
+	}
+	projection := bson.M{
+		"_id": 1,
+	}
+
+	if _, err := database.NodeFindOneAndUpdate(ctx, db, filter, update, options.FindOneAndUpdate().SetProjection(projection)); err != nil {
+		return exchangeErr, err
+	}
+
+	return exchangeErr, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"github.com/sentinel-official/explorer/database"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+const (
+	testMnemonic       = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testNodeAddr       = "sentnode1test"
+	testSessionID      = 42
+	testSubscriptionID = 7
+)
+
+func newExchangeServer(t *testing.T, status int, body interface{}) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+// nodeUpdate returns the $set document of the findAndModify sent to nodes.
+func nodeUpdate(mt *mtest.T) bson.Raw {
+	for e := mt.GetStartedEvent(); e != nil; e = mt.GetStartedEvent() {
+		if e.CommandName != "findAndModify" {
+			continue
+		}
+		if v := e.Command.Lookup("findAndModify").StringValue(); v != database.NodeCollectionName {
+			mt.Fatalf("findAndModify on %s, want %s", v, database.NodeCollectionName)
+		}
+
+		return e.Command.Lookup("update", "$set").Document()
+	}
+
+	mt.Fatal("no findAndModify command was sent")
+	return nil
+}
+
+func TestExchangeNodeConfig(t *testing.T) {
+	wallet, err := NewWalletFromMnemonic(testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := bson.D{
+		bson.E{Key: "id", Value: int64(testSessionID)},
+		bson.E{Key: "subscription_id", Value: int64(testSubscriptionID)},
+		bson.E{Key: "acc_addr", Value: wallet.AccAddr},
+		bson.E{Key: "node_addr", Value: testNodeAddr},
+		bson.E{Key: "status", Value: "active"},
+	}
+	sessionsNS := "db." + database.SessionCollectionName
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("no session", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, sessionsNS, mtest.FirstBatch),
+		)
+
+		exchangeErr, err := exchangeNodeConfig(context.TODO(), mt.DB, wallet, testNodeAddr, "https://127.0.0.1:1", nodetypes.TypeWireGuard)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if exchangeErr != nil {
+			mt.Fatalf("exchange error %v, want none", exchangeErr)
+		}
+
+		for e := mt.GetStartedEvent(); e != nil; e = mt.GetStartedEvent() {
+			if e.CommandName == "findAndModify" {
+				mt.Fatalf("findAndModify sent to %s without a session", e.Command.Lookup("findAndModify").StringValue())
+			}
+		}
+	})
+
+	mt.Run("exchange error", func(mt *mtest.T) {
+		server := newExchangeServer(t, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error": map[string]interface{}{
+				"code":    4,
+				"message": "invalid signature",
+			},
+		})
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, sessionsNS, mtest.FirstBatch, session),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{}}),
+		)
+
+		exchangeErr, err := exchangeNodeConfig(context.TODO(), mt.DB, wallet, testNodeAddr, server.URL, nodetypes.TypeWireGuard)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if exchangeErr == nil || !strings.Contains(exchangeErr.Error(), "invalid signature") {
+			mt.Fatalf("exchange error %v, want invalid signature", exchangeErr)
+		}
+
+		set := nodeUpdate(mt)
+		if v := set.Lookup("health.config_exchange_error").StringValue(); v != exchangeErr.Error() {
+			mt.Errorf("config_exchange_error %q, want %q", v, exchangeErr)
+		}
+		if v := set.Lookup("health.session_id").AsInt64(); v != testSessionID {
+			mt.Errorf("session_id %d, want %d", v, testSessionID)
+		}
+		if _, err := set.LookupErr("health.server_config"); err == nil {
+			mt.Error("server_config set after a failed exchange")
+		}
+	})
+
+	mt.Run("success", func(mt *mtest.T) {
+		server := newExchangeServer(t, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"result":  base64.StdEncoding.EncodeToString([]byte("server-config")),
+		})
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, sessionsNS, mtest.FirstBatch, session),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{}}),
+		)
+
+		exchangeErr, err := exchangeNodeConfig(context.TODO(), mt.DB, wallet, testNodeAddr, server.URL, nodetypes.TypeV2Ray)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if exchangeErr != nil {
+			mt.Fatal(exchangeErr)
+		}
+
+		set := nodeUpdate(mt)
+		if v := set.Lookup("health.config_exchange_error").StringValue(); v != "" {
+			mt.Errorf("config_exchange_error %q, want empty", v)
+		}
+		if _, v := set.Lookup("health.server_config").Binary(); string(v) != "server-config" {
+			mt.Errorf("server_config %q, want %q", v, "server-config")
+		}
+		if _, v := set.Lookup("health.client_config").Binary(); len(v) != 17 {
+			mt.Errorf("client_config length %d, want 17", len(v))
+		}
+		if v := set.Lookup("health.subscription_id").AsInt64(); v != testSubscriptionID {
+			mt.Errorf("subscription_id %d, want %d", v, testSubscriptionID)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+type GeoIP struct {
+	asn  *maxminddb.Reader
+	city *maxminddb.Reader
+}
+
+func NewGeoIP(cityPath, asnPath string) (*GeoIP, error) {
+	city, err := maxminddb.Open(cityPath)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GeoIP{
+		city: city,
+	}
+
+	if asnPath != "" {
+		g.asn, err = maxminddb.Open(asnPath)
+		if err != nil {
+			_ = city.Close()
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+func (g *GeoIP) Close() {
+	_ = g.city.Close()
+	if g.asn != nil {
+		_ = g.asn.Close()
+	}
+}
+
+func (g *GeoIP) Lookup(ip net.IP) (*nodetypes.VerifiedLocation, error) {
+	var city struct {
+		City struct {
+			Names map[string]string `maxminddb:"names"`
+		} `maxminddb:"city"`
+		Country struct {
+			ISOCode string            `maxminddb:"iso_code"`
+			Names   map[string]string `maxminddb:"names"`
+		} `maxminddb:"country"`
+		Location struct {
+			Latitude  float64 `maxminddb:"latitude"`
+			Longitude float64 `maxminddb:"longitude"`
+		} `maxminddb:"location"`
+	}
+
+	if err := g.city.Lookup(ip, &city); err != nil {
+		return nil, err
+	}
+	if city.Country.ISOCode == "" {
+		return nil, fmt.Errorf("no geoip record for %s", ip)
+	}
+
+	v := &nodetypes.VerifiedLocation{
+		City:        city.City.Names["en"],
+		Country:     city.Country.Names["en"],
+		CountryCode: city.Country.ISOCode,
+		IP:          ip.String(),
+		Latitude:    city.Location.Latitude,
+		Longitude:   city.Location.Longitude,
+	}
+
+	if g.asn != nil {
+		var asn struct {
+			AutonomousSystemNumber       uint64 `maxminddb:"autonomous_system_number"`
+			AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+		}
+
+		if err := g.asn.Lookup(ip, &asn); err != nil {
+			return nil, err
+		}
+
+		v.ASN = asn.AutonomousSystemNumber
+		v.ASOrganization = asn.AutonomousSystemOrganization
+	}
+
+	return v, nil
+}
+
+func resolveIP(ctx context.Context, remoteURL string) (net.IP, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip, nil
+		}
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no ip address found for " + host)
+	}
+
+	return ips[0], nil
+}
+
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := func(v float64) float64 {
+		return v * math.Pi / 180
+	}
+
+	dLat, dLon := rad(lat2-lat1), rad(lon2-lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 6371 * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+func verifyNodeLocation(ctx context.Context, db *mongo.Database, geoIP *GeoIP, nodeAddr, remoteURL string, claimed *nodetypes.Location) error {
+	set := bson.M{
+		"health.location_fetch_timestamp": time.Now().UTC(),
+	}
+	update := bson.M{
+		"$set": set,
+	}
+
+	v, err := func() (*nodetypes.VerifiedLocation, error) {
+		ip, err := resolveIP(ctx, remoteURL)
+		if err != nil {
+			return nil, err
+		}
+
+		return geoIP.Lookup(ip)
+	}()
+	if err != nil {
+		// A location that can no longer be verified must not keep matching
+		// the location filters.
+		set["health.location_fetch_error"] = err.Error()
+		update["$unset"] = bson.M{
+			"verified_location": "",
+		}
+	} else {
+		// The match flags are only set for nodes that report a location, so
+		// a node without one is not reported as a mismatch.
+		if claimed != nil {
+			countryMatch := nodetypes.CountryCode(claimed.Country) == v.CountryCode
+			cityMatch := v.City != "" && strings.EqualFold(claimed.City, v.City)
+
+			v.CountryMatch, v.CityMatch = &countryMatch, &cityMatch
+			if (claimed.Latitude != 0 || claimed.Longitude != 0) && (v.Latitude != 0 || v.Longitude != 0) {
+				v.Distance = distance(claimed.Latitude, claimed.Longitude, v.Latitude, v.Longitude)
+			}
+		}
+
+		set["health.location_fetch_error"] = ""
+		set["verified_location"] = v
+	}
+
+	filter := bson.M{
+		"addr": nodeAddr,
+	}
+	projection := bson.M{
+		"_id": 1,
+	}
+
+	_, err = database.NodeFindOneAndUpdate(ctx, db, filter, update, options.FindOneAndUpdate().SetProjection(projection))
+	return err
+}
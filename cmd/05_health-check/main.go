@@ -4,6 +4,10 @@ import (
 	"context"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,29 +16,64 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/sentinel-official/explorer/database"
-	nodetypes "github.com/sentinel-official/explorer/types/node"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/utils"
 )
 
 const appName = "05_health-check"
 
 var (
-	dbAddress  string
-	dbName     string
-	dbUsername string
-	dbPassword string
-	timeout    time.Duration
+	certificateInterval    time.Duration
+	concurrency            int
+	configExchangeInterval time.Duration
+	daemon                 bool
+	dbAddress              string
+	dbName                 string
+	dbUsername             string
+	dbPassword             string
+	geoIPASNDB             string
+	geoIPCityDB            string
+	historyRetention       time.Duration
+	interval               time.Duration
+	jitter                 float64
+	latencySamples         int
+	maxBackoff             time.Duration
+	refreshInterval        time.Duration
+	retryInterval          time.Duration
+	throughputBytes        int64
+	throughputInterval     time.Duration
+	throughputMinBytes     int64
+	throughputPath         string
+	timeout                time.Duration
+	walletMnemonicFile     string
 )
 
 func init() {
 	log.SetFlags(0)
 
+	flag.DurationVar(&certificateInterval, "certificate-interval", time.Hour, "")
+	flag.IntVar(&concurrency, "concurrency", 64, "")
+	flag.DurationVar(&configExchangeInterval, "config-exchange-interval", 6*time.Hour, "")
+	flag.BoolVar(&daemon, "daemon", false, "")
 	flag.StringVar(&dbAddress, "db-address", "mongodb://127.0.0.1:27017", "")
 	flag.StringVar(&dbName, "db-name", "sentinelhub-2", "")
 	flag.StringVar(&dbUsername, "db-username", "", "")
 	flag.StringVar(&dbPassword, "db-password", "", "")
+	flag.StringVar(&geoIPASNDB, "geoip-asn-db", "", "")
+	flag.StringVar(&geoIPCityDB, "geoip-city-db", "", "")
+	flag.DurationVar(&historyRetention, "history-retention", 90*24*time.Hour, "")
+	flag.DurationVar(&interval, "interval", 5*time.Minute, "")
+	flag.Float64Var(&jitter, "jitter", 0.2, "")
+	flag.IntVar(&latencySamples, "latency-samples", 3, "")
+	flag.DurationVar(&maxBackoff, "max-backoff", time.Hour, "")
+	flag.DurationVar(&refreshInterval, "refresh-interval", time.Minute, "")
+	flag.DurationVar(&retryInterval, "retry-interval", 30*time.Second, "")
+	flag.Int64Var(&throughputBytes, "throughput-bytes", 1<<20, "")
+	flag.DurationVar(&throughputInterval, "throughput-interval", time.Hour, "")
+	flag.Int64Var(&throughputMinBytes, "throughput-min-bytes", 256<<10, "")
+	flag.StringVar(&throughputPath, "throughput-path", "", "")
 	flag.DurationVar(&timeout, "timeout", 15*time.Second, "")
-	flag.Parse()
+	flag.StringVar(&walletMnemonicFile, "wallet-mnemonic-file", "", "")
 }
 
 func createIndexes(ctx context.Context, db *mongo.Database) error {
@@ -59,23 +98,39 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 		return err
 	}
 
-	return nil
-}
+	// Changing the options of an existing index fails index creation, so a
+	// new retention is applied to the TTL index in place first.
+	ttlKeys := bson.D{
+		bson.E{Key: "timestamp", Value: 1},
+	}
 
-func main() {
-	db, err := utils.PrepareDatabase(context.TODO(), appName, dbUsername, dbPassword, dbAddress, dbName)
-	if err != nil {
-		log.Fatalln(err)
+	if err := database.NodeHealthCheckIndexesSetExpireAfterSeconds(ctx, db, ttlKeys, int32(historyRetention.Seconds())); err != nil {
+		return err
 	}
 
-	if err := db.Client().Ping(context.TODO(), nil); err != nil {
-		log.Fatalln(err)
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "addr", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+		},
+		{
+			Keys: ttlKeys,
+			Options: options.Index().
+				SetExpireAfterSeconds(int32(historyRetention.Seconds())),
+		},
 	}
 
-	if err := createIndexes(context.TODO(), db); err != nil {
-		log.Fatalln(err)
+	_, err = database.NodeHealthCheckIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
 	}
 
+	return nil
+}
+
+func findNodes(ctx context.Context, db *mongo.Database) ([]*models.Node, error) {
 	filter := bson.M{
 		"remote_url": bson.M{
 			"$exists": true,
@@ -89,72 +144,193 @@ func main() {
 	opts := options.Find().
 		SetProjection(projection)
 
-	nodes, err := database.NodeFind(context.TODO(), db, filter, opts)
-	if err != nil {
-		log.Fatalln(err)
+	return database.NodeFind(ctx, db, filter, opts)
+}
+
+type Cycle struct {
+	Probed   int64
+	Failed   int64
+	Errored  int64
+	Duration time.Duration
+}
+
+// Cycles accumulates the probe cycles run by the daemon.
+type Cycles struct {
+	Cycle
+	Count int64
+	Last  time.Duration
+}
+
+func (c *Cycles) Add(v *Cycle) {
+	c.Count++
+	c.Probed += v.Probed
+	c.Failed += v.Failed
+	c.Errored += v.Errored
+	c.Duration += v.Duration
+	c.Last = v.Duration
+}
+
+func (c *Cycles) Average() time.Duration {
+	if c.Count == 0 {
+		return 0
 	}
 
-	group := &errgroup.Group{}
-	group.SetLimit(64)
+	return c.Duration / time.Duration(c.Count)
+}
+
+func runCycle(ctx context.Context, prober *Prober, items []*Schedule) (*Cycle, error) {
+	var (
+		now   = time.Now()
+		cycle = &Cycle{}
+		group = &errgroup.Group{}
+	)
+
+	group.SetLimit(concurrency)
 
-	for i := 0; i < len(nodes); i++ {
-		var (
-			nodeAddr  = nodes[i].Addr
-			remoteURL = nodes[i].RemoteURL
-		)
+	for i := 0; i < len(items); i++ {
+		item := items[i]
 
 		group.Go(func() error {
-			filter := bson.M{
-				"addr": nodeAddr,
-			}
-			update := bson.M{}
-			projection := bson.M{
-				"_id": 1,
+			probeErr, err := prober.Probe(ctx, item)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				log.Println("Probe", item.NodeAddr, err)
+				atomic.AddInt64(&cycle.Errored, 1)
+
+				probeErr = err
+			} else if probeErr != nil {
+				atomic.AddInt64(&cycle.Failed, 1)
 			}
-			opts := options.FindOneAndUpdate().
-				SetProjection(projection)
 
-			info, err := nodetypes.FetchNewInfo(remoteURL, timeout)
+			atomic.AddInt64(&cycle.Probed, 1)
+			item.Done(probeErr, time.Now())
+
+			return nil
+		})
+	}
+
+	err := group.Wait()
+	cycle.Duration = time.Since(now)
+
+	log.Println("Cycle", "probed", cycle.Probed, "failed", cycle.Failed, "errored", cycle.Errored, "duration", cycle.Duration)
+	return cycle, err
+}
+
+func runDaemon(ctx context.Context, prober *Prober) error {
+	var (
+		scheduler        = NewScheduler()
+		refreshTimestamp time.Time
+		total            = &Cycles{}
+	)
+
+	for {
+		now := time.Now()
+		if now.Sub(refreshTimestamp) >= refreshInterval {
+			nodes, err := findNodes(ctx, prober.DB)
 			if err != nil {
-				update = bson.M{
-					"$set": bson.M{
-						"health.status_fetch_error":     err.Error(),
-						"health.status_fetch_timestamp": time.Now().UTC(),
-					},
-				}
-			} else {
-				update = bson.M{
-					"$set": bson.M{
-						"handshake_dns":                 info.Handshake,
-						"health.status_fetch_error":     "",
-						"health.status_fetch_timestamp": time.Now().UTC(),
-						"internet_speed": bson.M{
-							"download": utils.MustStringFromInt64(info.Bandwidth.Download),
-							"upload":   utils.MustStringFromInt64(info.Bandwidth.Upload),
-						},
-						"interval_set_sessions":    info.IntervalSetSessions,
-						"interval_update_sessions": info.IntervalUpdateStatus,
-						"interval_update_status":   info.IntervalUpdateStatus,
-						"location":                 info.Location,
-						"moniker":                  info.Moniker,
-						"peers":                    info.Peers,
-						"qos":                      info.QOS,
-						"type":                     info.Type,
-						"version":                  info.Version,
-					},
-				}
+				return err
 			}
 
-			_, err = database.NodeFindOneAndUpdate(context.TODO(), db, filter, update, opts)
+			scheduler.Refresh(nodes, now)
+			refreshTimestamp = now
+
+			log.Println("Nodes", len(nodes), "failing", scheduler.Failing())
+		}
+
+		if items := scheduler.Due(now); len(items) > 0 {
+			cycle, err := runCycle(ctx, prober, items)
+			total.Add(cycle)
+
+			log.Println("Total", "cycles", total.Count, "probed", total.Probed, "failed", total.Failed, "errored", total.Errored, "last", total.Last, "average", total.Average())
 			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
 				return err
 			}
+		}
+
+		wait := time.Until(refreshTimestamp.Add(refreshInterval))
+		if t, ok := scheduler.Next(); ok && time.Until(t) < wait {
+			wait = time.Until(t)
+		}
+		if wait < time.Second {
+			wait = time.Second
+		}
 
+		select {
+		case <-ctx.Done():
 			return nil
-		})
+		case <-time.After(wait):
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	if throughputPath != "" && throughputMinBytes > throughputBytes {
+		log.Fatalln("throughput-min-bytes must not exceed throughput-bytes")
+	}
+
+	db, err := utils.PrepareDatabase(context.TODO(), appName, dbUsername, dbPassword, dbAddress, dbName)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	if err := group.Wait(); err != nil {
+	if err := db.Client().Ping(context.TODO(), nil); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := createIndexes(context.TODO(), db); err != nil {
+		log.Fatalln(err)
+	}
+
+	prober := &Prober{
+		DB: db,
+	}
+
+	if geoIPCityDB != "" {
+		prober.GeoIP, err = NewGeoIP(geoIPCityDB, geoIPASNDB)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		defer prober.GeoIP.Close()
+	}
+	if walletMnemonicFile != "" {
+		prober.Wallet, err = NewWalletFromMnemonicFile(walletMnemonicFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		log.Println("Wallet", prober.Wallet.AccAddr)
+	}
+
+	if daemon {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		if err := runDaemon(ctx, prober); err != nil {
+			log.Fatalln(err)
+		}
+
+		return
+	}
+
+	nodes, err := findNodes(context.TODO(), db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	scheduler := NewScheduler()
+	scheduler.Refresh(nodes, time.Now())
+
+	if _, err := runCycle(context.TODO(), prober, scheduler.Due(time.Now())); err != nil {
 		log.Fatalln(err)
 	}
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type Prober struct {
+	DB     *mongo.Database
+	GeoIP  *GeoIP
+	Wallet *Wallet
+}
+
+func (p *Prober) Probe(ctx context.Context, item *Schedule) (probeErr, err error) {
+	info, probeErr, err := probeNode(ctx, p.DB, item.NodeAddr, item.RemoteURL)
+	if err != nil {
+		return probeErr, err
+	}
+
+	if time.Since(item.CertificateTimestamp) >= certificateInterval {
+		if err := inspectNodeCertificate(ctx, p.DB, item.NodeAddr, item.RemoteURL); err != nil {
+			return probeErr, err
+		}
+
+		item.CertificateTimestamp = time.Now()
+	}
+	if probeErr != nil {
+		return probeErr, nil
+	}
+
+	if p.GeoIP != nil {
+		if err := verifyNodeLocation(ctx, p.DB, p.GeoIP, item.NodeAddr, item.RemoteURL, info.Location); err != nil {
+			return nil, err
+		}
+	}
+	if p.Wallet != nil && time.Since(item.ConfigExchangeTimestamp) >= configExchangeInterval {
+		if _, err := exchangeNodeConfig(ctx, p.DB, p.Wallet, item.NodeAddr, item.RemoteURL, info.Type); err != nil {
+			return nil, err
+		}
+
+		item.ConfigExchangeTimestamp = time.Now()
+	}
+	if throughputPath != "" && throughputBytes > 0 && time.Since(item.ThroughputTimestamp) >= throughputInterval {
+		if err := measureNodeThroughput(ctx, p.DB, item.NodeAddr, item.RemoteURL); err != nil {
+			return nil, err
+		}
+
+		item.ThroughputTimestamp = time.Now()
+	}
+
+	return nil, nil
+}
+
+func probeNode(ctx context.Context, db *mongo.Database, nodeAddr, remoteURL string) (info *nodetypes.Info, probeErr, err error) {
+	filter := bson.M{
+		"addr": nodeAddr,
+	}
+	update := bson.M{}
+	projection := bson.M{
+		"_id": 1,
+	}
+	opts := options.FindOneAndUpdate().
+		SetProjection(projection)
+
+	now := time.Now()
+	info, probeErr = nodetypes.FetchNewInfo(remoteURL, timeout)
+
+	check := bson.M{
+		"addr":      nodeAddr,
+		"latency":   time.Since(now).Milliseconds(),
+		"success":   probeErr == nil,
+		"timestamp": now.UTC(),
+	}
+
+	if probeErr != nil {
+		check["error"] = probeErr.Error()
+		check["error_class"] = nodetypes.ErrorClass(probeErr)
+
+		update = bson.M{
+			"$set": bson.M{
+				"health.status_fetch_error":     probeErr.Error(),
+				"health.status_fetch_timestamp": time.Now().UTC(),
+			},
+		}
+	} else {
+		check["peers"] = info.Peers
+		if info.QOS != nil {
+			check["max_peers"] = info.QOS.MaxPeers
+		}
+		check["type"] = info.Type
+		check["version"] = info.Version
+
+		set := bson.M{
+			"handshake_dns":                 info.Handshake,
+			"health.status_fetch_error":     "",
+			"health.status_fetch_timestamp": time.Now().UTC(),
+			"internet_speed": bson.M{
+				"download": utils.MustStringFromInt64(info.Bandwidth.Download),
+				"upload":   utils.MustStringFromInt64(info.Bandwidth.Upload),
+			},
+			"interval_set_sessions":    info.IntervalSetSessions,
+			"interval_update_sessions": info.IntervalUpdateStatus,
+			"interval_update_status":   info.IntervalUpdateStatus,
+			"location":                 info.Location,
+			"moniker":                  info.Moniker,
+			"peers":                    info.Peers,
+			"qos":                      info.QOS,
+			"type":                     info.Type,
+			"version":                  info.Version,
+		}
+
+		if latencySamples > 0 {
+			latency, err := nodetypes.MeasureLatency(ctx, remoteURL, latencySamples, timeout)
+			if err != nil {
+				check["latency_error"] = err.Error()
+			} else {
+				check["rtt"] = latency.RTT.Avg
+				check["ttfb"] = latency.TTFB.Avg
+
+				set["measured_speed.latency"] = latency
+				set["measured_speed.latency_timestamp"] = time.Now().UTC()
+			}
+		}
+
+		update = bson.M{
+			"$set": set,
+		}
+	}
+
+	if _, err := database.NodeFindOneAndUpdate(ctx, db, filter, update, opts); err != nil {
+		return info, probeErr, err
+	}
+	if _, err := database.NodeHealthCheckInsertOne(ctx, db, check); err != nil {
+		return info, probeErr, err
+	}
+
+	return info, probeErr, nil
+}
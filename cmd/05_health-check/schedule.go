@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/sentinel-official/explorer/models"
+)
+
+type Schedule struct {
+	NodeAddr                string
+	RemoteURL               string
+	Failures                int
+	NextTimestamp           time.Time
+	CertificateTimestamp    time.Time
+	ConfigExchangeTimestamp time.Time
+	ThroughputTimestamp     time.Time
+}
+
+func (s *Schedule) Done(probeErr error, now time.Time) {
+	if probeErr != nil {
+		s.Failures = s.Failures + 1
+	} else {
+		s.Failures = 0
+	}
+
+	s.NextTimestamp = now.Add(nextDelay(s.Failures))
+}
+
+func nextDelay(failures int) time.Duration {
+	delay := interval
+	if failures > 0 {
+		delay = retryInterval
+		for i := 1; i < failures && delay < maxBackoff; i++ {
+			delay = delay * 2
+		}
+		if delay > maxBackoff {
+			delay = maxBackoff
+		}
+	}
+
+	return delay + time.Duration(float64(delay)*jitter*(2*rand.Float64()-1))
+}
+
+type Scheduler struct {
+	items map[string]*Schedule
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		items: make(map[string]*Schedule),
+	}
+}
+
+func (s *Scheduler) Refresh(nodes []*models.Node, now time.Time) {
+	addrs := make(map[string]bool)
+	for _, node := range nodes {
+		addrs[node.Addr] = true
+
+		item, ok := s.items[node.Addr]
+		if !ok {
+			s.items[node.Addr] = &Schedule{
+				NodeAddr:      node.Addr,
+				RemoteURL:     node.RemoteURL,
+				NextTimestamp: now,
+			}
+
+			continue
+		}
+
+		if item.RemoteURL != node.RemoteURL {
+			item.RemoteURL = node.RemoteURL
+			item.Failures = 0
+			item.NextTimestamp = now
+			item.CertificateTimestamp = time.Time{}
+			item.ConfigExchangeTimestamp = time.Time{}
+			item.ThroughputTimestamp = time.Time{}
+		}
+	}
+
+	for addr := range s.items {
+		if !addrs[addr] {
+			delete(s.items, addr)
+		}
+	}
+}
+
+func (s *Scheduler) Due(now time.Time) (items []*Schedule) {
+	for _, item := range s.items {
+		if !item.NextTimestamp.After(now) {
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].NextTimestamp.Before(items[j].NextTimestamp)
+	})
+
+	return items
+}
+
+func (s *Scheduler) Next() (t time.Time, ok bool) {
+	for _, item := range s.items {
+		if !ok || item.NextTimestamp.Before(t) {
+			t, ok = item.NextTimestamp, true
+		}
+	}
+
+	return t, ok
+}
+
+func (s *Scheduler) Failing() (n int) {
+	for _, item := range s.items {
+		if item.Failures > 0 {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+func measureNodeThroughput(ctx context.Context, db *mongo.Database, nodeAddr, remoteURL string) error {
+	now := time.Now().UTC()
+
+	var update bson.M
+	throughput, err := nodetypes.MeasureThroughput(ctx, remoteURL, throughputPath, throughputMinBytes, throughputBytes, timeout)
+	if err != nil {
+		update = bson.M{
+			"$set": bson.M{
+				"health.speed_test_error":     err.Error(),
+				"health.speed_test_timestamp": now,
+			},
+			"$unset": bson.M{
+				"measured_speed.throughput":           1,
+				"measured_speed.throughput_timestamp": 1,
+			},
+		}
+	} else {
+		update = bson.M{
+			"$set": bson.M{
+				"health.speed_test_error":             "",
+				"health.speed_test_timestamp":         now,
+				"measured_speed.throughput":           throughput,
+				"measured_speed.throughput_timestamp": now,
+			},
+		}
+	}
+
+	filter := bson.M{
+		"addr": nodeAddr,
+	}
+	projection := bson.M{
+		"_id": 1,
+	}
+
+	_, err = database.NodeFindOneAndUpdate(ctx, db, filter, update, options.FindOneAndUpdate().SetProjection(projection))
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	hubtypes "github.com/sentinel-official/hub/types"
+)
+
+type Wallet struct {
+	AccAddr string
+	PrivKey cryptotypes.PrivKey
+}
+
+func NewWalletFromMnemonic(mnemonic string) (*Wallet, error) {
+	bz, err := hd.Secp256k1.Derive()(mnemonic, "", hd.CreateHDPath(118, 0, 0).String())
+	if err != nil {
+		return nil, err
+	}
+
+	privKey := hd.Secp256k1.Generate()(bz)
+
+	accAddr, err := bech32.ConvertAndEncode(hubtypes.Bech32PrefixAccAddr, privKey.PubKey().Address())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Wallet{
+		AccAddr: accAddr,
+		PrivKey: privKey,
+	}, nil
+}
+
+// NewWalletFromMnemonicFile reads the mnemonic from a file so that it does not
+// show up in the process arguments or the shell history.
+func NewWalletFromMnemonicFile(name string) (*Wallet, error) {
+	bz, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWalletFromMnemonic(strings.TrimSpace(string(bz)))
+}
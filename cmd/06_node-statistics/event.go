@@ -3,11 +3,11 @@ package main
 import (
 	"context"
 	"log"
-	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
 	"github.com/sentinel-official/explorer/types"
@@ -57,8 +57,8 @@ func (s *EventStatistics) Result(addr string, timestamp time.Time) bson.M {
 	return res
 }
 
-func StatisticsFromEvents(ctx context.Context, db *mongo.Database) (result []bson.M, err error) {
-	log.Println("StatisticsFromEvents")
+func StatisticsFromEvents(ctx context.Context, db *mongo.Database, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromEvents", minHourTimestamp, loc)
 
 	pipeline := []bson.M{
 		{
@@ -84,9 +84,17 @@ func StatisticsFromEvents(ctx context.Context, db *mongo.Database) (result []bso
 			"$addFields": bson.M{
 				"timestamp": bson.M{
 					"$dateFromParts": bson.M{
-						"day":   bson.M{"$dayOfMonth": "$timestamp"},
-						"month": bson.M{"$month": "$timestamp"},
-						"year":  bson.M{"$year": "$timestamp"},
+						"timezone": loc.String(),
+						"hour": bson.M{
+							"$cond": bson.A{
+								bson.M{"$gte": bson.A{"$timestamp", minHourTimestamp}},
+								bson.M{"$hour": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+								0,
+							},
+						},
+						"day":   bson.M{"$dayOfMonth": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+						"month": bson.M{"$month": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+						"year":  bson.M{"$year": bson.M{"date": "$timestamp", "timezone": loc.String()}},
 					},
 				},
 			},
@@ -127,200 +135,123 @@ func StatisticsFromEvents(ctx context.Context, db *mongo.Database) (result []bso
 				"timestamp":  "$_id.timestamp",
 			},
 		},
+		{
+			"$sort": bson.D{
+				bson.E{Key: "node_addr", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+		},
 	}
 
-	cursor, err := database.EventAggregate(ctx, db, pipeline)
+	cursor, err := database.EventAggregate(ctx, db, pipeline, options.Aggregate().SetAllowDiskUse(true))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer cursor.Close(ctx)
 
 	var (
-		d = make(map[string]map[time.Time]*EventStatistics)
-		w = make(map[string]map[time.Time]*EventStatistics)
-		m = make(map[string]map[time.Time]*EventStatistics)
-		y = make(map[string]map[time.Time]*EventStatistics)
+		nodeAddr           string
+		previous           = make(map[string]*EventStatistics)
+		previousTimestamps = make(map[string]time.Time)
+	)
+
+	buckets := types.NewBuckets(func(timeframe string, t time.Time, v *EventStatistics) error {
+		item := NewStatistics(timeframe)
+		for u := range v.SessionBandwidth {
+			item.SessionBandwidth[u] = v.SessionBandwidth[u]
+			item.SessionDuration[u] = v.SessionDuration[u]
+
+			if p, ok := previous[timeframe]; ok && previousTimestamps[timeframe].Equal(utils.TimeframeAddDate(timeframe, t, -1)) {
+				if p, ok := p.SessionBandwidth[u]; ok {
+					item.SessionBandwidth[u] = v.SessionBandwidth[u].Copy().Sub(p)
+				}
+				if p, ok := p.SessionDuration[u]; ok {
+					item.SessionDuration[u] = v.SessionDuration[u] - p
+				}
+			}
+		}
+
+		previous[timeframe], previousTimestamps[timeframe] = v, t
+		return database.SendStatistics(ctx, out, loc, item.Result(nodeAddr, t))
+	})
+
+	var (
+		h = buckets.Items("hour")
+		d = buckets.Items("day")
+		w = buckets.Items("week")
+		m = buckets.Items("month")
+		y = buckets.Items("year")
 	)
 
 	for cursor.Next(ctx) {
 		var item bson.M
 		if err := cursor.Decode(&item); err != nil {
-			return nil, err
+			return err
 		}
 
 		bandwidth := types.BandwidthFromInterface(item["bandwidth"])
 		duration := types.Int64FromInterface(item["duration"])
-		nodeAddr := types.StringFromInterface(item["node_addr"])
 		sessionID := types.Uint64FromInterface(item["session_id"])
-		timestamp := types.TimeFromInterface(item["timestamp"])
+		timestamp := types.TimeFromInterface(item["timestamp"]).In(loc)
 
-		if _, ok := d[nodeAddr]; !ok {
-			d[nodeAddr] = make(map[time.Time]*EventStatistics)
-		}
-		if _, ok := w[nodeAddr]; !ok {
-			w[nodeAddr] = make(map[time.Time]*EventStatistics)
-		}
-		if _, ok := m[nodeAddr]; !ok {
-			m[nodeAddr] = make(map[time.Time]*EventStatistics)
-		}
-		if _, ok := y[nodeAddr]; !ok {
-			y[nodeAddr] = make(map[time.Time]*EventStatistics)
-		}
+		if addr := types.StringFromInterface(item["node_addr"]); addr != nodeAddr {
+			if err := buckets.Close(); err != nil {
+				return err
+			}
 
-		dayTimestamp := utils.DayDate(timestamp)
-		if _, ok := d[nodeAddr][dayTimestamp]; !ok {
-			d[nodeAddr][dayTimestamp] = NewStatistics("day")
-		}
-		weekTimestamp := utils.ISOWeekDate(timestamp)
-		if _, ok := w[nodeAddr][weekTimestamp]; !ok {
-			w[nodeAddr][weekTimestamp] = NewStatistics("week")
-		}
-		monthTimestamp := utils.MonthDate(timestamp)
-		if _, ok := m[nodeAddr][monthTimestamp]; !ok {
-			m[nodeAddr][monthTimestamp] = NewStatistics("month")
+			nodeAddr = addr
+			previous = make(map[string]*EventStatistics)
+			previousTimestamps = make(map[string]time.Time)
 		}
-		yearTimestamp := utils.YearDate(timestamp)
-		if _, ok := y[nodeAddr][yearTimestamp]; !ok {
-			y[nodeAddr][yearTimestamp] = NewStatistics("year")
-		}
-
-		d[nodeAddr][dayTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
-		d[nodeAddr][dayTimestamp].SessionDuration[sessionID] = duration
-
-		w[nodeAddr][weekTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
-		w[nodeAddr][weekTimestamp].SessionDuration[sessionID] = duration
-
-		m[nodeAddr][monthTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
-		m[nodeAddr][monthTimestamp].SessionDuration[sessionID] = duration
-
-		y[nodeAddr][yearTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
-		y[nodeAddr][yearTimestamp].SessionDuration[sessionID] = duration
-	}
 
-	if err := cursor.Err(); err != nil {
-		return nil, err
-	}
-
-	for s := range d {
-		var tKeys []time.Time
-		for t := range d[s] {
-			tKeys = append(tKeys, t)
+		if err := buckets.CloseBefore(timestamp); err != nil {
+			return err
 		}
 
-		sort.Slice(tKeys, func(i, j int) bool {
-			return tKeys[i].After(tKeys[j])
-		})
-
-		for _, t := range tKeys {
-			for u := range d[s][t].SessionBandwidth {
-				if v, ok := d[s][t.AddDate(0, 0, -1)]; ok {
-					if v, ok := v.SessionBandwidth[u]; ok {
-						d[s][t].SessionBandwidth[u] = d[s][t].SessionBandwidth[u].Sub(v)
-					}
-					if v, ok := v.SessionDuration[u]; ok {
-						d[s][t].SessionDuration[u] = d[s][t].SessionDuration[u] - v
-					}
-				}
+		hourTimestamp := utils.HourDate(timestamp)
+		if !hourTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourTimestamp]; !ok {
+				h[hourTimestamp] = NewStatistics("hour")
 			}
-		}
-	}
 
-	for s := range w {
-		var tKeys []time.Time
-		for t := range w[s] {
-			tKeys = append(tKeys, t)
+			h[hourTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
+			h[hourTimestamp].SessionDuration[sessionID] = duration
 		}
 
-		sort.Slice(tKeys, func(i, j int) bool {
-			return tKeys[i].After(tKeys[j])
-		})
-
-		for _, t := range tKeys {
-			for u := range w[s][t].SessionBandwidth {
-				if v, ok := w[s][t.AddDate(0, 0, -7)]; ok {
-					if v, ok := v.SessionBandwidth[u]; ok {
-						w[s][t].SessionBandwidth[u] = w[s][t].SessionBandwidth[u].Sub(v)
-					}
-					if v, ok := v.SessionDuration[u]; ok {
-						w[s][t].SessionDuration[u] = w[s][t].SessionDuration[u] - v
-					}
-				}
-			}
+		dayTimestamp := utils.DayDate(timestamp)
+		if _, ok := d[dayTimestamp]; !ok {
+			d[dayTimestamp] = NewStatistics("day")
 		}
-	}
-
-	for s := range m {
-		var tKeys []time.Time
-		for t := range m[s] {
-			tKeys = append(tKeys, t)
+		weekTimestamp := utils.ISOWeekDate(timestamp)
+		if _, ok := w[weekTimestamp]; !ok {
+			w[weekTimestamp] = NewStatistics("week")
 		}
-
-		sort.Slice(tKeys, func(i, j int) bool {
-			return tKeys[i].After(tKeys[j])
-		})
-
-		for _, t := range tKeys {
-			for u := range m[s][t].SessionBandwidth {
-				if v, ok := m[s][t.AddDate(0, -1, 0)]; ok {
-					if v, ok := v.SessionBandwidth[u]; ok {
-						m[s][t].SessionBandwidth[u] = m[s][t].SessionBandwidth[u].Sub(v)
-					}
-					if v, ok := v.SessionDuration[u]; ok {
-						m[s][t].SessionDuration[u] = m[s][t].SessionDuration[u] - v
-					}
-				}
-			}
+		monthTimestamp := utils.MonthDate(timestamp)
+		if _, ok := m[monthTimestamp]; !ok {
+			m[monthTimestamp] = NewStatistics("month")
 		}
-	}
-
-	for s := range y {
-		var tKeys []time.Time
-		for t := range y[s] {
-			tKeys = append(tKeys, t)
+		yearTimestamp := utils.YearDate(timestamp)
+		if _, ok := y[yearTimestamp]; !ok {
+			y[yearTimestamp] = NewStatistics("year")
 		}
 
-		sort.Slice(tKeys, func(i, j int) bool {
-			return tKeys[i].After(tKeys[j])
-		})
-
-		for _, t := range tKeys {
-			for u := range y[s][t].SessionBandwidth {
-				if v, ok := y[s][t.AddDate(-1, 0, 0)]; ok {
-					if v, ok := v.SessionBandwidth[u]; ok {
-						y[s][t].SessionBandwidth[u] = y[s][t].SessionBandwidth[u].Sub(v)
-					}
-					if v, ok := v.SessionDuration[u]; ok {
-						y[s][t].SessionDuration[u] = y[s][t].SessionDuration[u] - v
-					}
-				}
-			}
-		}
-	}
+		d[dayTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
+		d[dayTimestamp].SessionDuration[sessionID] = duration
 
-	for s := range d {
-		for t := range d[s] {
-			result = append(result, d[s][t].Result(s, t))
-		}
-	}
+		w[weekTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
+		w[weekTimestamp].SessionDuration[sessionID] = duration
 
-	for s := range w {
-		for t := range w[s] {
-			result = append(result, w[s][t].Result(s, t))
-		}
-	}
+		m[monthTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
+		m[monthTimestamp].SessionDuration[sessionID] = duration
 
-	for s := range m {
-		for t := range m[s] {
-			result = append(result, m[s][t].Result(s, t))
-		}
+		y[yearTimestamp].SessionBandwidth[sessionID] = bandwidth.Copy()
+		y[yearTimestamp].SessionDuration[sessionID] = duration
 	}
 
-	for s := range y {
-		for t := range y[s] {
-			result = append(result, y[s][t].Result(s, t))
-		}
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	return result, nil
+	return buckets.Close()
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"flag"
 	"log"
-	"runtime"
 	"sort"
 	"strings"
 	"time"
@@ -29,6 +28,8 @@ var (
 	dbUsername   string
 	dbPassword   string
 	excludeAddrs string
+	hourWindow   time.Duration
+	timezones    string
 )
 
 func init() {
@@ -40,6 +41,8 @@ func init() {
 	flag.StringVar(&dbUsername, "db-username", "", "")
 	flag.StringVar(&dbPassword, "db-password", "", "")
 	flag.StringVar(&excludeAddrs, "exclude-addrs", "sent1c4nvz43tlw6d0c9nfu6r957y5d9pgjk5czl3n3", "")
+	flag.DurationVar(&hourWindow, "hour-window", 30*24*time.Hour, "")
+	flag.StringVar(&timezones, "timezones", "", "")
 	flag.Parse()
 }
 
@@ -66,6 +69,12 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 			Options: options.Index().
 				SetUnique(true),
 		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "node_addr", Value: 1},
+				bson.E{Key: "start_timestamp", Value: 1},
+			},
+		},
 	}
 
 	_, err = database.SessionIndexesCreateMany(ctx, db, indexes)
@@ -73,11 +82,16 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 		return err
 	}
 
+	if err := database.NodeStatisticIndexesDropOne(ctx, db, "addr_1_timeframe_1_timestamp_1"); err != nil {
+		return err
+	}
+
 	indexes = []mongo.IndexModel{
 		{
 			Keys: bson.D{
 				bson.E{Key: "addr", Value: 1},
 				bson.E{Key: "timeframe", Value: 1},
+				bson.E{Key: "tz", Value: 1},
 				bson.E{Key: "timestamp", Value: 1},
 			},
 			Options: options.Index().
@@ -131,118 +145,76 @@ func main() {
 		maxTimestamp = dBlocks[0].Time
 	}
 
+	minHourTimestamp := utils.HourDate(maxTimestamp.Add(-hourWindow))
+	log.Println("MinHourTimestamp", minHourTimestamp)
+
 	excludeAddrs := strings.Split(excludeAddrs, ",")
 	sort.Strings(excludeAddrs)
 
-	var (
-		models []mongo.WriteModel
-		group  = errgroup.Group{}
-	)
-
-	addModels := func(items []bson.M) error {
-		for _, item := range items {
-			filter := bson.M{
-				"addr":      item["addr"],
-				"timeframe": item["timeframe"],
-				"timestamp": item["timestamp"],
-			}
-
-			delete(item, "addr")
-			delete(item, "timeframe")
-			delete(item, "timestamp")
-
-			update := bson.M{
-				"$set": item,
-			}
-			model := mongo.NewUpdateOneModel().
-				SetFilter(filter).
-				SetUpdate(update).
-				SetUpsert(true)
-
-			models = append(models, model)
-		}
-
-		return nil
+	locations, err := utils.ParseLocations(timezones)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	group.Go(func() error {
-		defer runtime.GC()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
 
-		items, err := StatisticsFromEvents(context.TODO(), db)
-		if err != nil {
-			return err
-		}
-
-		return addModels(items)
-	})
-
-	group.Go(func() error {
-		defer runtime.GC()
-
-		items, err := StatisticsFromSessions(context.TODO(), db, time.Time{}, maxTimestamp, excludeAddrs)
-		if err != nil {
-			return err
-		}
-
-		return addModels(items)
-	})
-
-	group.Go(func() error {
-		defer runtime.GC()
+	var (
+		out    = make(chan bson.M, batchSize)
+		writer = errgroup.Group{}
+	)
 
-		items, err := StatisticsFromSubscriptions(context.TODO(), db, time.Time{}, maxTimestamp, excludeAddrs)
-		if err != nil {
-			return err
-		}
+	writer.Go(func() error {
+		defer cancel()
 
-		return addModels(items)
+		return database.WriteStatistics(ctx, db, out, batchSize, func(item bson.M) (string, mongo.WriteModel) {
+			return database.NodeStatisticCollectionName, database.NewStatisticUpsertModel(item, "addr")
+		})
 	})
 
-	group.Go(func() error {
-		defer runtime.GC()
-
-		items, err := StatisticsFromSubscriptionPayouts(context.TODO(), db)
-		if err != nil {
-			return err
+	produce := func(loc *time.Location) error {
+		hourTimestamp := minHourTimestamp
+		if loc != time.UTC {
+			hourTimestamp = maxTimestamp.Add(time.Hour)
 		}
 
-		return addModels(items)
-	})
-
-	if err := group.Wait(); err != nil {
-		log.Fatalln(err)
-	}
-
-	group = errgroup.Group{}
-	group.SetLimit(runtime.NumCPU() / 2)
+		group := errgroup.Group{}
 
-	log.Println("Models", len(models))
-	for i := 0; ; {
-		from, to := i, i+batchSize
-		if to > len(models) {
-			to = len(models)
-		}
+		group.Go(func() error {
+			return StatisticsFromEvents(ctx, db, hourTimestamp, loc, out)
+		})
 
 		group.Go(func() error {
-			defer runtime.GC()
+			return StatisticsFromSessions(ctx, db, time.Time{}, maxTimestamp, hourTimestamp, excludeAddrs, loc, out)
+		})
 
-			opts := options.BulkWrite().
-				SetBypassDocumentValidation(false).
-				SetOrdered(false)
+		group.Go(func() error {
+			return StatisticsFromSubscriptions(ctx, db, time.Time{}, maxTimestamp, hourTimestamp, excludeAddrs, loc, out)
+		})
 
-			_, err = database.NodeStatisticBulkWrite(context.TODO(), db, models[from:to], opts)
-			return err
+		group.Go(func() error {
+			return StatisticsFromSubscriptionPayouts(ctx, db, hourTimestamp, loc, out)
 		})
 
-		i = i + batchSize
-		if to == len(models) {
+		return group.Wait()
+	}
+
+	var producerErr error
+	for _, loc := range locations {
+		if producerErr = produce(loc); producerErr != nil {
+			cancel()
 			break
 		}
 	}
 
-	if err := group.Wait(); err != nil {
+	close(out)
+
+	if err := writer.Wait(); err != nil {
 		log.Fatalln(err)
 	}
+	if producerErr != nil {
+		log.Fatalln(producerErr)
+	}
 
 	log.Println("Duration", time.Since(now))
 	log.Println("")
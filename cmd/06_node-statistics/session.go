@@ -10,26 +10,35 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
 
 type (
 	SessionStatistics struct {
-		Timeframe      string
-		ActiveSession  int64
-		BytesEarning   types.Coins
-		EndSession     int64
-		SessionAddress map[string]bool
-		StartSession   int64
+		Timeframe       string
+		ActiveSession   int64
+		BytesEarning    types.Coins
+		EndSession      int64
+		SessionAddress  map[string]bool
+		SessionBytes    *types.Histogram
+		SessionDuration *types.Histogram
+		SessionPayment  types.Histograms
+		SessionRating   *types.Histogram
+		StartSession    int64
 	}
 )
 
 func NewSessionStatistics(timeframe string) *SessionStatistics {
 	return &SessionStatistics{
-		Timeframe:      timeframe,
-		BytesEarning:   types.NewCoins(nil),
-		SessionAddress: make(map[string]bool),
+		Timeframe:       timeframe,
+		BytesEarning:    types.NewCoins(nil),
+		SessionAddress:  make(map[string]bool),
+		SessionBytes:    types.NewHistogram(),
+		SessionDuration: types.NewHistogram(),
+		SessionPayment:  make(types.Histograms),
+		SessionRating:   types.NewHistogram(),
 	}
 }
 
@@ -52,6 +61,18 @@ func (s *SessionStatistics) Result(addr string, timestamp time.Time) bson.M {
 	if len(s.SessionAddress) != 0 {
 		res["session_address"] = len(s.SessionAddress)
 	}
+	if !s.SessionBytes.IsZero() {
+		res["session_bytes_distribution"] = s.SessionBytes.Result()
+	}
+	if !s.SessionDuration.IsZero() {
+		res["session_duration_distribution"] = s.SessionDuration.Result()
+	}
+	if len(s.SessionPayment) != 0 {
+		res["session_payment_distribution"] = s.SessionPayment.Result()
+	}
+	if !s.SessionRating.IsZero() {
+		res["session_rating_distribution"] = s.SessionRating.Result()
+	}
 	if s.StartSession != 0 {
 		res["start_session"] = s.StartSession
 	}
@@ -59,171 +80,213 @@ func (s *SessionStatistics) Result(addr string, timestamp time.Time) bson.M {
 	return res
 }
 
-func StatisticsFromSessions(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp time.Time, excludeAddrs []string) (result []bson.M, err error) {
-	log.Println("StatisticsFromSessions", minTimestamp, maxTimestamp)
+func StatisticsFromSessions(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSessions", minTimestamp, maxTimestamp, minHourTimestamp, loc)
 
 	filter := bson.M{}
 	projection := bson.M{
 		"_id":             0,
 		"acc_addr":        1,
+		"bandwidth":       1,
+		"duration":        1,
 		"end_timestamp":   1,
 		"node_addr":       1,
 		"payment":         1,
+		"rating":          1,
 		"start_timestamp": 1,
 	}
 
-	items, err := database.SessionFind(ctx, db, filter, options.Find().SetProjection(projection))
+	_sort := bson.D{
+		bson.E{Key: "node_addr", Value: 1},
+		bson.E{Key: "start_timestamp", Value: 1},
+	}
+
+	cursor, err := database.SessionFindCursor(ctx, db, filter, options.Find().SetProjection(projection).SetSort(_sort))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer cursor.Close(ctx)
+
+	var nodeAddr string
+	buckets := types.NewBuckets(func(_ string, t time.Time, v *SessionStatistics) error {
+		return database.SendStatistics(ctx, out, loc, v.Result(nodeAddr, t))
+	})
+
 	var (
-		d = make(map[string]map[time.Time]*SessionStatistics)
-		w = make(map[string]map[time.Time]*SessionStatistics)
-		m = make(map[string]map[time.Time]*SessionStatistics)
-		y = make(map[string]map[time.Time]*SessionStatistics)
+		h = buckets.Items("hour")
+		d = buckets.Items("day")
+		w = buckets.Items("week")
+		m = buckets.Items("month")
+		y = buckets.Items("year")
 	)
 
-	for i := 0; i < len(items); i++ {
-		if _, ok := d[items[i].NodeAddr]; !ok {
-			d[items[i].NodeAddr] = make(map[time.Time]*SessionStatistics)
-		}
-		if _, ok := w[items[i].NodeAddr]; !ok {
-			w[items[i].NodeAddr] = make(map[time.Time]*SessionStatistics)
-		}
-		if _, ok := m[items[i].NodeAddr]; !ok {
-			m[items[i].NodeAddr] = make(map[time.Time]*SessionStatistics)
-		}
-		if _, ok := y[items[i].NodeAddr]; !ok {
-			y[items[i].NodeAddr] = make(map[time.Time]*SessionStatistics)
+	for cursor.Next(ctx) {
+		var item models.Session
+		if err := cursor.Decode(&item); err != nil {
+			return err
 		}
 
-		exclude := utils.ContainsString(excludeAddrs, items[i].AccAddr)
-		if exclude {
-			continue
-		}
+		if item.NodeAddr != nodeAddr {
+			if err := buckets.Close(); err != nil {
+				return err
+			}
 
-		startTimestamp := items[i].StartTimestamp
-		if items[i].StartTimestamp.IsZero() {
-			startTimestamp = minTimestamp
+			nodeAddr = item.NodeAddr
 		}
 
-		endTimestamp := items[i].EndTimestamp
-		if items[i].EndTimestamp.IsZero() {
-			endTimestamp = maxTimestamp
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
 		}
 
-		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
-		for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
-			if _, ok := d[items[i].NodeAddr][t]; !ok {
-				d[items[i].NodeAddr][t] = NewSessionStatistics("day")
-			}
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
+		}
 
-			d[items[i].NodeAddr][t].ActiveSession += 1
-			d[items[i].NodeAddr][t].SessionAddress[items[i].AccAddr] = true
+		if err := buckets.CloseBefore(startTimestamp); err != nil {
+			return err
 		}
 
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
 		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
-		for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
-			if _, ok := w[items[i].NodeAddr][t]; !ok {
-				w[items[i].NodeAddr][t] = NewSessionStatistics("week")
-			}
+		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
+		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
 
-			w[items[i].NodeAddr][t].ActiveSession += 1
-			w[items[i].NodeAddr][t].SessionAddress[items[i].AccAddr] = true
+		if !hourEndTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[hourEndTimestamp]; !ok {
+				h[hourEndTimestamp] = NewSessionStatistics("hour")
+			}
+		}
+		if _, ok := d[dayEndTimestamp]; !ok {
+			d[dayEndTimestamp] = NewSessionStatistics("day")
+		}
+		if _, ok := w[weekEndTimestamp]; !ok {
+			w[weekEndTimestamp] = NewSessionStatistics("week")
+		}
+		if _, ok := m[monthEndTimestamp]; !ok {
+			m[monthEndTimestamp] = NewSessionStatistics("month")
+		}
+		if _, ok := y[yearEndTimestamp]; !ok {
+			y[yearEndTimestamp] = NewSessionStatistics("year")
 		}
 
-		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
-		for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
-			if _, ok := m[items[i].NodeAddr][t]; !ok {
-				m[items[i].NodeAddr][t] = NewSessionStatistics("month")
+		if item.Payment != nil {
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].BytesEarning = h[hourEndTimestamp].BytesEarning.Add(item.Payment)
 			}
 
-			m[items[i].NodeAddr][t].ActiveSession += 1
-			m[items[i].NodeAddr][t].SessionAddress[items[i].AccAddr] = true
-		}
+			d[dayEndTimestamp].BytesEarning = d[dayEndTimestamp].BytesEarning.Add(item.Payment)
+			w[weekEndTimestamp].BytesEarning = w[weekEndTimestamp].BytesEarning.Add(item.Payment)
+			m[monthEndTimestamp].BytesEarning = m[monthEndTimestamp].BytesEarning.Add(item.Payment)
+			y[yearEndTimestamp].BytesEarning = y[yearEndTimestamp].BytesEarning.Add(item.Payment)
 
-		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
-		for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
-			if _, ok := y[items[i].NodeAddr][t]; !ok {
-				y[items[i].NodeAddr][t] = NewSessionStatistics("year")
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].SessionPayment.AddCoin(item.Payment)
 			}
 
-			y[items[i].NodeAddr][t].ActiveSession += 1
-			y[items[i].NodeAddr][t].SessionAddress[items[i].AccAddr] = true
+			d[dayEndTimestamp].SessionPayment.AddCoin(item.Payment)
+			w[weekEndTimestamp].SessionPayment.AddCoin(item.Payment)
+			m[monthEndTimestamp].SessionPayment.AddCoin(item.Payment)
+			y[yearEndTimestamp].SessionPayment.AddCoin(item.Payment)
 		}
 
-		if !items[i].EndTimestamp.IsZero() {
-			d[items[i].NodeAddr][dayEndTimestamp].EndSession += 1
-			w[items[i].NodeAddr][weekEndTimestamp].EndSession += 1
-			m[items[i].NodeAddr][monthEndTimestamp].EndSession += 1
-			y[items[i].NodeAddr][yearEndTimestamp].EndSession += 1
-		}
-		if !items[i].StartTimestamp.IsZero() {
-			d[items[i].NodeAddr][dayStartTimestamp].StartSession += 1
-			w[items[i].NodeAddr][weekStartTimestamp].StartSession += 1
-			m[items[i].NodeAddr][monthStartTimestamp].StartSession += 1
-			y[items[i].NodeAddr][yearStartTimestamp].StartSession += 1
+		exclude := utils.ContainsString(excludeAddrs, item.AccAddr)
+		if exclude {
+			continue
 		}
-	}
 
-	for i := 0; i < len(items); i++ {
-		endTimestamp := items[i].EndTimestamp
-		if items[i].EndTimestamp.IsZero() {
-			endTimestamp = maxTimestamp
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
 		}
 
-		dayEndTimestamp := utils.DayDate(endTimestamp)
-		if _, ok := d[items[i].NodeAddr][dayEndTimestamp]; !ok {
-			d[items[i].NodeAddr][dayEndTimestamp] = NewSessionStatistics("day")
-		}
+		for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+			if _, ok := h[t]; !ok {
+				h[t] = NewSessionStatistics("hour")
+			}
 
-		weekEndTimestamp := utils.ISOWeekDate(endTimestamp)
-		if _, ok := w[items[i].NodeAddr][weekEndTimestamp]; !ok {
-			w[items[i].NodeAddr][weekEndTimestamp] = NewSessionStatistics("week")
+			h[t].ActiveSession += 1
+			h[t].SessionAddress[item.AccAddr] = true
 		}
 
-		monthEndTimestamp := utils.MonthDate(endTimestamp)
-		if _, ok := m[items[i].NodeAddr][monthEndTimestamp]; !ok {
-			m[items[i].NodeAddr][monthEndTimestamp] = NewSessionStatistics("month")
+		for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
+			if _, ok := d[t]; !ok {
+				d[t] = NewSessionStatistics("day")
+			}
+
+			d[t].ActiveSession += 1
+			d[t].SessionAddress[item.AccAddr] = true
 		}
 
-		yearEndTimestamp := utils.YearDate(endTimestamp)
-		if _, ok := y[items[i].NodeAddr][yearEndTimestamp]; !ok {
-			y[items[i].NodeAddr][yearEndTimestamp] = NewSessionStatistics("year")
+		for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
+			if _, ok := w[t]; !ok {
+				w[t] = NewSessionStatistics("week")
+			}
+
+			w[t].ActiveSession += 1
+			w[t].SessionAddress[item.AccAddr] = true
 		}
 
-		if items[i].Payment != nil {
-			d[items[i].NodeAddr][dayEndTimestamp].BytesEarning = d[items[i].NodeAddr][dayEndTimestamp].BytesEarning.Add(items[i].Payment)
-			w[items[i].NodeAddr][weekEndTimestamp].BytesEarning = w[items[i].NodeAddr][weekEndTimestamp].BytesEarning.Add(items[i].Payment)
-			m[items[i].NodeAddr][monthEndTimestamp].BytesEarning = m[items[i].NodeAddr][monthEndTimestamp].BytesEarning.Add(items[i].Payment)
-			y[items[i].NodeAddr][yearEndTimestamp].BytesEarning = y[items[i].NodeAddr][yearEndTimestamp].BytesEarning.Add(items[i].Payment)
+		for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
+			if _, ok := m[t]; !ok {
+				m[t] = NewSessionStatistics("month")
+			}
+
+			m[t].ActiveSession += 1
+			m[t].SessionAddress[item.AccAddr] = true
 		}
-	}
 
-	for s := range d {
-		for t := range d[s] {
-			result = append(result, d[s][t].Result(s, t))
+		for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
+			if _, ok := y[t]; !ok {
+				y[t] = NewSessionStatistics("year")
+			}
+
+			y[t].ActiveSession += 1
+			y[t].SessionAddress[item.AccAddr] = true
 		}
-	}
 
-	for s := range w {
-		for t := range w[s] {
-			result = append(result, w[s][t].Result(s, t))
+		if !item.EndTimestamp.IsZero() {
+			if _, ok := h[hourEndTimestamp]; ok {
+				h[hourEndTimestamp].EndSession += 1
+			}
+
+			d[dayEndTimestamp].EndSession += 1
+			w[weekEndTimestamp].EndSession += 1
+			m[monthEndTimestamp].EndSession += 1
+			y[yearEndTimestamp].EndSession += 1
+
+			for _, v := range []*SessionStatistics{h[hourEndTimestamp], d[dayEndTimestamp], w[weekEndTimestamp], m[monthEndTimestamp], y[yearEndTimestamp]} {
+				if v == nil {
+					continue
+				}
+
+				v.SessionDuration.Add(float64(item.Duration))
+				if item.Bandwidth != nil {
+					v.SessionBytes.AddString(item.Bandwidth.Sum())
+				}
+				if item.Rating != 0 {
+					v.SessionRating.Add(float64(item.Rating))
+				}
+			}
 		}
-	}
+		if !item.StartTimestamp.IsZero() {
+			if _, ok := h[hourStartTimestamp]; ok {
+				h[hourStartTimestamp].StartSession += 1
+			}
 
-	for s := range m {
-		for t := range m[s] {
-			result = append(result, m[s][t].Result(s, t))
+			d[dayStartTimestamp].StartSession += 1
+			w[weekStartTimestamp].StartSession += 1
+			m[monthStartTimestamp].StartSession += 1
+			y[yearStartTimestamp].StartSession += 1
 		}
 	}
 
-	for s := range y {
-		for t := range y[s] {
-			result = append(result, y[s][t].Result(s, t))
-		}
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	return result, nil
+	return buckets.Close()
 }
@@ -11,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
@@ -76,8 +77,8 @@ func (s *SubscriptionStatistics) Result(addr string, timestamp time.Time) bson.M
 	return res
 }
 
-func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp time.Time, excludeAddrs []string) (result []bson.M, err error) {
-	log.Println("StatisticsFromSubscriptions", minTimestamp, maxTimestamp)
+func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSubscriptions", minTimestamp, maxTimestamp, minHourTimestamp, loc)
 
 	filter := bson.M{}
 	projection := bson.M{
@@ -92,200 +93,237 @@ func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTim
 		"start_timestamp": 1,
 	}
 
-	items, err := database.SubscriptionFind(ctx, db, filter, options.Find().SetProjection(projection))
+	cursor, err := database.SubscriptionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer cursor.Close(ctx)
+
 	var (
+		h = make(map[string]map[time.Time]*SubscriptionStatistics)
 		d = make(map[string]map[time.Time]*SubscriptionStatistics)
 		w = make(map[string]map[time.Time]*SubscriptionStatistics)
 		m = make(map[string]map[time.Time]*SubscriptionStatistics)
 		y = make(map[string]map[time.Time]*SubscriptionStatistics)
 	)
 
-	for i := 0; i < len(items); i++ {
-		if _, ok := d[items[i].NodeAddr]; !ok {
-			d[items[i].NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
+	for cursor.Next(ctx) {
+		var item models.Subscription
+		if err := cursor.Decode(&item); err != nil {
+			return err
 		}
-		if _, ok := w[items[i].NodeAddr]; !ok {
-			w[items[i].NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
+
+		if _, ok := h[item.NodeAddr]; !ok {
+			h[item.NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
 		}
-		if _, ok := m[items[i].NodeAddr]; !ok {
-			m[items[i].NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
+		if _, ok := d[item.NodeAddr]; !ok {
+			d[item.NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
 		}
-		if _, ok := y[items[i].NodeAddr]; !ok {
-			y[items[i].NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
+		if _, ok := w[item.NodeAddr]; !ok {
+			w[item.NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
 		}
-
-		exclude := utils.ContainsString(excludeAddrs, items[i].AccAddr)
-		if exclude {
-			continue
+		if _, ok := m[item.NodeAddr]; !ok {
+			m[item.NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
+		}
+		if _, ok := y[item.NodeAddr]; !ok {
+			y[item.NodeAddr] = make(map[time.Time]*SubscriptionStatistics)
 		}
 
-		startTimestamp := items[i].StartTimestamp
-		if items[i].StartTimestamp.IsZero() {
-			startTimestamp = minTimestamp
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
 		}
 
-		endTimestamp := items[i].EndTimestamp
-		if items[i].EndTimestamp.IsZero() {
-			endTimestamp = maxTimestamp
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
 		}
 
-		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
-		for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
-			if _, ok := d[items[i].NodeAddr][t]; !ok {
-				d[items[i].NodeAddr][t] = NewSubscriptionStatistics("day")
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		if !hourStartTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[item.NodeAddr][hourStartTimestamp]; !ok {
+				h[item.NodeAddr][hourStartTimestamp] = NewSubscriptionStatistics("hour")
 			}
+		}
+		if !hourEndTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[item.NodeAddr][hourEndTimestamp]; !ok {
+				h[item.NodeAddr][hourEndTimestamp] = NewSubscriptionStatistics("hour")
+			}
+		}
 
-			d[items[i].NodeAddr][t].ActiveSubscription += 1
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
+		if _, ok := d[item.NodeAddr][dayStartTimestamp]; !ok {
+			d[item.NodeAddr][dayStartTimestamp] = NewSubscriptionStatistics("day")
+		}
+		if _, ok := d[item.NodeAddr][dayEndTimestamp]; !ok {
+			d[item.NodeAddr][dayEndTimestamp] = NewSubscriptionStatistics("day")
 		}
 
 		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
-		for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
-			if _, ok := w[items[i].NodeAddr][t]; !ok {
-				w[items[i].NodeAddr][t] = NewSubscriptionStatistics("week")
-			}
-
-			w[items[i].NodeAddr][t].ActiveSubscription += 1
+		if _, ok := w[item.NodeAddr][weekStartTimestamp]; !ok {
+			w[item.NodeAddr][weekStartTimestamp] = NewSubscriptionStatistics("week")
+		}
+		if _, ok := w[item.NodeAddr][weekEndTimestamp]; !ok {
+			w[item.NodeAddr][weekEndTimestamp] = NewSubscriptionStatistics("week")
 		}
 
 		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
-		for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
-			if _, ok := m[items[i].NodeAddr][t]; !ok {
-				m[items[i].NodeAddr][t] = NewSubscriptionStatistics("month")
-			}
-
-			m[items[i].NodeAddr][t].ActiveSubscription += 1
+		if _, ok := m[item.NodeAddr][monthStartTimestamp]; !ok {
+			m[item.NodeAddr][monthStartTimestamp] = NewSubscriptionStatistics("month")
+		}
+		if _, ok := m[item.NodeAddr][monthEndTimestamp]; !ok {
+			m[item.NodeAddr][monthEndTimestamp] = NewSubscriptionStatistics("month")
 		}
 
 		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
-		for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
-			if _, ok := y[items[i].NodeAddr][t]; !ok {
-				y[items[i].NodeAddr][t] = NewSubscriptionStatistics("year")
+		if _, ok := y[item.NodeAddr][yearStartTimestamp]; !ok {
+			y[item.NodeAddr][yearStartTimestamp] = NewSubscriptionStatistics("year")
+		}
+		if _, ok := y[item.NodeAddr][yearEndTimestamp]; !ok {
+			y[item.NodeAddr][yearEndTimestamp] = NewSubscriptionStatistics("year")
+		}
+
+		if item.Deposit != nil {
+			if _, ok := h[item.NodeAddr][hourStartTimestamp]; ok {
+				h[item.NodeAddr][hourStartTimestamp].SubscriptionDeposit = h[item.NodeAddr][hourStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
 			}
 
-			y[items[i].NodeAddr][t].ActiveSubscription += 1
+			d[item.NodeAddr][dayStartTimestamp].SubscriptionDeposit = d[item.NodeAddr][dayStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
+			w[item.NodeAddr][weekStartTimestamp].SubscriptionDeposit = w[item.NodeAddr][weekStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
+			m[item.NodeAddr][monthStartTimestamp].SubscriptionDeposit = m[item.NodeAddr][monthStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
+			y[item.NodeAddr][yearStartTimestamp].SubscriptionDeposit = y[item.NodeAddr][yearStartTimestamp].SubscriptionDeposit.Add(item.Deposit)
 		}
+		if item.Refund != nil {
+			if _, ok := h[item.NodeAddr][hourEndTimestamp]; ok {
+				h[item.NodeAddr][hourEndTimestamp].SubscriptionRefund = h[item.NodeAddr][hourEndTimestamp].SubscriptionRefund.Add(item.Refund)
+			}
 
-		if !items[i].EndTimestamp.IsZero() {
-			d[items[i].NodeAddr][dayEndTimestamp].EndSubscription += 1
-			w[items[i].NodeAddr][weekEndTimestamp].EndSubscription += 1
-			m[items[i].NodeAddr][monthEndTimestamp].EndSubscription += 1
-			y[items[i].NodeAddr][yearEndTimestamp].EndSubscription += 1
+			d[item.NodeAddr][dayEndTimestamp].SubscriptionRefund = d[item.NodeAddr][dayEndTimestamp].SubscriptionRefund.Add(item.Refund)
+			w[item.NodeAddr][weekEndTimestamp].SubscriptionRefund = w[item.NodeAddr][weekEndTimestamp].SubscriptionRefund.Add(item.Refund)
+			m[item.NodeAddr][monthEndTimestamp].SubscriptionRefund = m[item.NodeAddr][monthEndTimestamp].SubscriptionRefund.Add(item.Refund)
+			y[item.NodeAddr][yearEndTimestamp].SubscriptionRefund = y[item.NodeAddr][yearEndTimestamp].SubscriptionRefund.Add(item.Refund)
 		}
-		if items[i].Gigabytes != 0 {
-			d[items[i].NodeAddr][dayStartTimestamp].BytesSubscription += 1
-			w[items[i].NodeAddr][weekStartTimestamp].BytesSubscription += 1
-			m[items[i].NodeAddr][monthStartTimestamp].BytesSubscription += 1
-			y[items[i].NodeAddr][yearStartTimestamp].BytesSubscription += 1
 
-			bytes := hubtypes.Gigabyte.MulRaw(items[i].Gigabytes)
-			d[items[i].NodeAddr][dayStartTimestamp].SubscriptionBytes = utils.MustIntFromString(d[items[i].NodeAddr][dayStartTimestamp].SubscriptionBytes).Add(bytes).String()
-			w[items[i].NodeAddr][weekStartTimestamp].SubscriptionBytes = utils.MustIntFromString(w[items[i].NodeAddr][weekStartTimestamp].SubscriptionBytes).Add(bytes).String()
-			m[items[i].NodeAddr][monthStartTimestamp].SubscriptionBytes = utils.MustIntFromString(m[items[i].NodeAddr][monthStartTimestamp].SubscriptionBytes).Add(bytes).String()
-			y[items[i].NodeAddr][yearStartTimestamp].SubscriptionBytes = utils.MustIntFromString(y[items[i].NodeAddr][yearStartTimestamp].SubscriptionBytes).Add(bytes).String()
+		exclude := utils.ContainsString(excludeAddrs, item.AccAddr)
+		if exclude {
+			continue
 		}
-		if items[i].Hours != 0 {
-			d[items[i].NodeAddr][dayStartTimestamp].HoursSubscription += 1
-			w[items[i].NodeAddr][weekStartTimestamp].HoursSubscription += 1
-			m[items[i].NodeAddr][monthStartTimestamp].HoursSubscription += 1
-			y[items[i].NodeAddr][yearStartTimestamp].HoursSubscription += 1
 
-			d[items[i].NodeAddr][dayStartTimestamp].SubscriptionHours += items[i].Hours
-			w[items[i].NodeAddr][weekStartTimestamp].SubscriptionHours += items[i].Hours
-			m[items[i].NodeAddr][monthStartTimestamp].SubscriptionHours += items[i].Hours
-			y[items[i].NodeAddr][yearStartTimestamp].SubscriptionHours += items[i].Hours
-		}
-		if !items[i].StartTimestamp.IsZero() {
-			d[items[i].NodeAddr][dayStartTimestamp].StartSubscription += 1
-			w[items[i].NodeAddr][weekStartTimestamp].StartSubscription += 1
-			m[items[i].NodeAddr][monthStartTimestamp].StartSubscription += 1
-			y[items[i].NodeAddr][yearStartTimestamp].StartSubscription += 1
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
 		}
-	}
 
-	for i := 0; i < len(items); i++ {
-		startTimestamp := items[i].StartTimestamp
-		if items[i].StartTimestamp.IsZero() {
-			startTimestamp = minTimestamp
-		}
+		for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+			if _, ok := h[item.NodeAddr][t]; !ok {
+				h[item.NodeAddr][t] = NewSubscriptionStatistics("hour")
+			}
 
-		endTimestamp := items[i].EndTimestamp
-		if items[i].EndTimestamp.IsZero() {
-			endTimestamp = maxTimestamp
+			h[item.NodeAddr][t].ActiveSubscription += 1
 		}
 
-		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
-		if _, ok := d[items[i].NodeAddr][dayStartTimestamp]; !ok {
-			d[items[i].NodeAddr][dayStartTimestamp] = NewSubscriptionStatistics("day")
-		}
-		if _, ok := d[items[i].NodeAddr][dayEndTimestamp]; !ok {
-			d[items[i].NodeAddr][dayEndTimestamp] = NewSubscriptionStatistics("day")
-		}
+		for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
+			if _, ok := d[item.NodeAddr][t]; !ok {
+				d[item.NodeAddr][t] = NewSubscriptionStatistics("day")
+			}
 
-		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
-		if _, ok := w[items[i].NodeAddr][weekStartTimestamp]; !ok {
-			w[items[i].NodeAddr][weekStartTimestamp] = NewSubscriptionStatistics("week")
-		}
-		if _, ok := w[items[i].NodeAddr][weekEndTimestamp]; !ok {
-			w[items[i].NodeAddr][weekEndTimestamp] = NewSubscriptionStatistics("week")
+			d[item.NodeAddr][t].ActiveSubscription += 1
 		}
 
-		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
-		if _, ok := m[items[i].NodeAddr][monthStartTimestamp]; !ok {
-			m[items[i].NodeAddr][monthStartTimestamp] = NewSubscriptionStatistics("month")
-		}
-		if _, ok := m[items[i].NodeAddr][monthEndTimestamp]; !ok {
-			m[items[i].NodeAddr][monthEndTimestamp] = NewSubscriptionStatistics("month")
+		for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
+			if _, ok := w[item.NodeAddr][t]; !ok {
+				w[item.NodeAddr][t] = NewSubscriptionStatistics("week")
+			}
+
+			w[item.NodeAddr][t].ActiveSubscription += 1
 		}
 
-		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
-		if _, ok := y[items[i].NodeAddr][yearStartTimestamp]; !ok {
-			y[items[i].NodeAddr][yearStartTimestamp] = NewSubscriptionStatistics("year")
+		for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
+			if _, ok := m[item.NodeAddr][t]; !ok {
+				m[item.NodeAddr][t] = NewSubscriptionStatistics("month")
+			}
+
+			m[item.NodeAddr][t].ActiveSubscription += 1
 		}
-		if _, ok := y[items[i].NodeAddr][yearEndTimestamp]; !ok {
-			y[items[i].NodeAddr][yearEndTimestamp] = NewSubscriptionStatistics("year")
+
+		for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
+			if _, ok := y[item.NodeAddr][t]; !ok {
+				y[item.NodeAddr][t] = NewSubscriptionStatistics("year")
+			}
+
+			y[item.NodeAddr][t].ActiveSubscription += 1
 		}
 
-		if items[i].Deposit != nil {
-			d[items[i].NodeAddr][dayStartTimestamp].SubscriptionDeposit = d[items[i].NodeAddr][dayStartTimestamp].SubscriptionDeposit.Add(items[i].Deposit)
-			w[items[i].NodeAddr][weekStartTimestamp].SubscriptionDeposit = w[items[i].NodeAddr][weekStartTimestamp].SubscriptionDeposit.Add(items[i].Deposit)
-			m[items[i].NodeAddr][monthStartTimestamp].SubscriptionDeposit = m[items[i].NodeAddr][monthStartTimestamp].SubscriptionDeposit.Add(items[i].Deposit)
-			y[items[i].NodeAddr][yearStartTimestamp].SubscriptionDeposit = y[items[i].NodeAddr][yearStartTimestamp].SubscriptionDeposit.Add(items[i].Deposit)
+		if !item.EndTimestamp.IsZero() {
+			if _, ok := h[item.NodeAddr][hourEndTimestamp]; ok {
+				h[item.NodeAddr][hourEndTimestamp].EndSubscription += 1
+			}
+
+			d[item.NodeAddr][dayEndTimestamp].EndSubscription += 1
+			w[item.NodeAddr][weekEndTimestamp].EndSubscription += 1
+			m[item.NodeAddr][monthEndTimestamp].EndSubscription += 1
+			y[item.NodeAddr][yearEndTimestamp].EndSubscription += 1
 		}
-		if items[i].Refund != nil {
-			d[items[i].NodeAddr][dayEndTimestamp].SubscriptionRefund = d[items[i].NodeAddr][dayEndTimestamp].SubscriptionRefund.Add(items[i].Refund)
-			w[items[i].NodeAddr][weekEndTimestamp].SubscriptionRefund = w[items[i].NodeAddr][weekEndTimestamp].SubscriptionRefund.Add(items[i].Refund)
-			m[items[i].NodeAddr][monthEndTimestamp].SubscriptionRefund = m[items[i].NodeAddr][monthEndTimestamp].SubscriptionRefund.Add(items[i].Refund)
-			y[items[i].NodeAddr][yearEndTimestamp].SubscriptionRefund = y[items[i].NodeAddr][yearEndTimestamp].SubscriptionRefund.Add(items[i].Refund)
+		if item.Gigabytes != 0 {
+			if _, ok := h[item.NodeAddr][hourStartTimestamp]; ok {
+				h[item.NodeAddr][hourStartTimestamp].BytesSubscription += 1
+				h[item.NodeAddr][hourStartTimestamp].SubscriptionBytes = utils.MustIntFromString(h[item.NodeAddr][hourStartTimestamp].SubscriptionBytes).Add(hubtypes.Gigabyte.MulRaw(item.Gigabytes)).String()
+			}
+
+			d[item.NodeAddr][dayStartTimestamp].BytesSubscription += 1
+			w[item.NodeAddr][weekStartTimestamp].BytesSubscription += 1
+			m[item.NodeAddr][monthStartTimestamp].BytesSubscription += 1
+			y[item.NodeAddr][yearStartTimestamp].BytesSubscription += 1
+
+			bytes := hubtypes.Gigabyte.MulRaw(item.Gigabytes)
+			d[item.NodeAddr][dayStartTimestamp].SubscriptionBytes = utils.MustIntFromString(d[item.NodeAddr][dayStartTimestamp].SubscriptionBytes).Add(bytes).String()
+			w[item.NodeAddr][weekStartTimestamp].SubscriptionBytes = utils.MustIntFromString(w[item.NodeAddr][weekStartTimestamp].SubscriptionBytes).Add(bytes).String()
+			m[item.NodeAddr][monthStartTimestamp].SubscriptionBytes = utils.MustIntFromString(m[item.NodeAddr][monthStartTimestamp].SubscriptionBytes).Add(bytes).String()
+			y[item.NodeAddr][yearStartTimestamp].SubscriptionBytes = utils.MustIntFromString(y[item.NodeAddr][yearStartTimestamp].SubscriptionBytes).Add(bytes).String()
 		}
-	}
+		if item.Hours != 0 {
+			if _, ok := h[item.NodeAddr][hourStartTimestamp]; ok {
+				h[item.NodeAddr][hourStartTimestamp].HoursSubscription += 1
+				h[item.NodeAddr][hourStartTimestamp].SubscriptionHours += item.Hours
+			}
 
-	for s := range d {
-		for t := range d[s] {
-			result = append(result, d[s][t].Result(s, t))
+			d[item.NodeAddr][dayStartTimestamp].HoursSubscription += 1
+			w[item.NodeAddr][weekStartTimestamp].HoursSubscription += 1
+			m[item.NodeAddr][monthStartTimestamp].HoursSubscription += 1
+			y[item.NodeAddr][yearStartTimestamp].HoursSubscription += 1
+
+			d[item.NodeAddr][dayStartTimestamp].SubscriptionHours += item.Hours
+			w[item.NodeAddr][weekStartTimestamp].SubscriptionHours += item.Hours
+			m[item.NodeAddr][monthStartTimestamp].SubscriptionHours += item.Hours
+			y[item.NodeAddr][yearStartTimestamp].SubscriptionHours += item.Hours
 		}
-	}
+		if !item.StartTimestamp.IsZero() {
+			if _, ok := h[item.NodeAddr][hourStartTimestamp]; ok {
+				h[item.NodeAddr][hourStartTimestamp].StartSubscription += 1
+			}
 
-	for s := range w {
-		for t := range w[s] {
-			result = append(result, w[s][t].Result(s, t))
+			d[item.NodeAddr][dayStartTimestamp].StartSubscription += 1
+			w[item.NodeAddr][weekStartTimestamp].StartSubscription += 1
+			m[item.NodeAddr][monthStartTimestamp].StartSubscription += 1
+			y[item.NodeAddr][yearStartTimestamp].StartSubscription += 1
 		}
+
 	}
 
-	for s := range m {
-		for t := range m[s] {
-			result = append(result, m[s][t].Result(s, t))
-		}
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	for s := range y {
-		for t := range y[s] {
-			result = append(result, y[s][t].Result(s, t))
+	for _, v := range []map[string]map[time.Time]*SubscriptionStatistics{h, d, w, m, y} {
+		for s := range v {
+			for t := range v[s] {
+				if err := database.SendStatistics(ctx, out, loc, v[s][t].Result(s, t)); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
-	return result, nil
+	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
 	"github.com/sentinel-official/explorer/types"
 	"github.com/sentinel-official/explorer/utils"
 )
@@ -42,8 +43,8 @@ func (s *SubscriptionPayoutStatistics) Result(addr string, timestamp time.Time)
 	return res
 }
 
-func StatisticsFromSubscriptionPayouts(ctx context.Context, db *mongo.Database) (result []bson.M, err error) {
-	log.Println("StatisticsFromSubscriptionPayouts")
+func StatisticsFromSubscriptionPayouts(ctx context.Context, db *mongo.Database, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSubscriptionPayouts", minHourTimestamp, loc)
 
 	filter := bson.M{}
 	projection := bson.M{
@@ -53,81 +54,91 @@ func StatisticsFromSubscriptionPayouts(ctx context.Context, db *mongo.Database)
 		"timestamp": 1,
 	}
 
-	items, err := database.SubscriptionPayoutFind(ctx, db, filter, options.Find().SetProjection(projection))
+	cursor, err := database.SubscriptionPayoutFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer cursor.Close(ctx)
+
 	var (
+		h = make(map[string]map[time.Time]*SubscriptionPayoutStatistics)
 		d = make(map[string]map[time.Time]*SubscriptionPayoutStatistics)
 		w = make(map[string]map[time.Time]*SubscriptionPayoutStatistics)
 		m = make(map[string]map[time.Time]*SubscriptionPayoutStatistics)
 		y = make(map[string]map[time.Time]*SubscriptionPayoutStatistics)
 	)
 
-	for i := 0; i < len(items); i++ {
-		if _, ok := d[items[i].NodeAddr]; !ok {
-			d[items[i].NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
+	for cursor.Next(ctx) {
+		var item models.SubscriptionPayout
+		if err := cursor.Decode(&item); err != nil {
+			return err
 		}
-		if _, ok := w[items[i].NodeAddr]; !ok {
-			w[items[i].NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
+
+		if _, ok := h[item.NodeAddr]; !ok {
+			h[item.NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
 		}
-		if _, ok := m[items[i].NodeAddr]; !ok {
-			m[items[i].NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
+		if _, ok := d[item.NodeAddr]; !ok {
+			d[item.NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
 		}
-		if _, ok := y[items[i].NodeAddr]; !ok {
-			y[items[i].NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
+		if _, ok := w[item.NodeAddr]; !ok {
+			w[item.NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
 		}
-
-		dayTimestamp := utils.DayDate(items[i].Timestamp)
-		if _, ok := d[items[i].NodeAddr][dayTimestamp]; !ok {
-			d[items[i].NodeAddr][dayTimestamp] = NewSubscriptionPayoutStatistics("day")
+		if _, ok := m[item.NodeAddr]; !ok {
+			m[item.NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
 		}
-
-		weekTimestamp := utils.ISOWeekDate(items[i].Timestamp)
-		if _, ok := w[items[i].NodeAddr][weekTimestamp]; !ok {
-			w[items[i].NodeAddr][weekTimestamp] = NewSubscriptionPayoutStatistics("week")
+		if _, ok := y[item.NodeAddr]; !ok {
+			y[item.NodeAddr] = make(map[time.Time]*SubscriptionPayoutStatistics)
 		}
 
-		monthTimestamp := utils.MonthDate(items[i].Timestamp)
-		if _, ok := m[items[i].NodeAddr][monthTimestamp]; !ok {
-			m[items[i].NodeAddr][monthTimestamp] = NewSubscriptionPayoutStatistics("month")
+		hourTimestamp := utils.HourDate(item.Timestamp.In(loc))
+		if !hourTimestamp.Before(minHourTimestamp) {
+			if _, ok := h[item.NodeAddr][hourTimestamp]; !ok {
+				h[item.NodeAddr][hourTimestamp] = NewSubscriptionPayoutStatistics("hour")
+			}
+
+			h[item.NodeAddr][hourTimestamp].HoursEarning = h[item.NodeAddr][hourTimestamp].HoursEarning.Add(item.Payment)
 		}
 
-		yearTimestamp := utils.YearDate(items[i].Timestamp)
-		if _, ok := y[items[i].NodeAddr][yearTimestamp]; !ok {
-			y[items[i].NodeAddr][yearTimestamp] = NewSubscriptionPayoutStatistics("year")
+		dayTimestamp := utils.DayDate(item.Timestamp.In(loc))
+		if _, ok := d[item.NodeAddr][dayTimestamp]; !ok {
+			d[item.NodeAddr][dayTimestamp] = NewSubscriptionPayoutStatistics("day")
 		}
 
-		d[items[i].NodeAddr][dayTimestamp].HoursEarning = d[items[i].NodeAddr][dayTimestamp].HoursEarning.Add(items[i].Payment)
-		w[items[i].NodeAddr][weekTimestamp].HoursEarning = w[items[i].NodeAddr][weekTimestamp].HoursEarning.Add(items[i].Payment)
-		m[items[i].NodeAddr][monthTimestamp].HoursEarning = m[items[i].NodeAddr][monthTimestamp].HoursEarning.Add(items[i].Payment)
-		y[items[i].NodeAddr][yearTimestamp].HoursEarning = y[items[i].NodeAddr][yearTimestamp].HoursEarning.Add(items[i].Payment)
-	}
+		weekTimestamp := utils.ISOWeekDate(item.Timestamp.In(loc))
+		if _, ok := w[item.NodeAddr][weekTimestamp]; !ok {
+			w[item.NodeAddr][weekTimestamp] = NewSubscriptionPayoutStatistics("week")
+		}
 
-	for s := range d {
-		for t := range d[s] {
-			result = append(result, d[s][t].Result(s, t))
+		monthTimestamp := utils.MonthDate(item.Timestamp.In(loc))
+		if _, ok := m[item.NodeAddr][monthTimestamp]; !ok {
+			m[item.NodeAddr][monthTimestamp] = NewSubscriptionPayoutStatistics("month")
 		}
-	}
 
-	for s := range w {
-		for t := range w[s] {
-			result = append(result, w[s][t].Result(s, t))
+		yearTimestamp := utils.YearDate(item.Timestamp.In(loc))
+		if _, ok := y[item.NodeAddr][yearTimestamp]; !ok {
+			y[item.NodeAddr][yearTimestamp] = NewSubscriptionPayoutStatistics("year")
 		}
+
+		d[item.NodeAddr][dayTimestamp].HoursEarning = d[item.NodeAddr][dayTimestamp].HoursEarning.Add(item.Payment)
+		w[item.NodeAddr][weekTimestamp].HoursEarning = w[item.NodeAddr][weekTimestamp].HoursEarning.Add(item.Payment)
+		m[item.NodeAddr][monthTimestamp].HoursEarning = m[item.NodeAddr][monthTimestamp].HoursEarning.Add(item.Payment)
+		y[item.NodeAddr][yearTimestamp].HoursEarning = y[item.NodeAddr][yearTimestamp].HoursEarning.Add(item.Payment)
 	}
 
-	for s := range m {
-		for t := range m[s] {
-			result = append(result, m[s][t].Result(s, t))
-		}
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	for s := range y {
-		for t := range y[s] {
-			result = append(result, y[s][t].Result(s, t))
+	for _, v := range []map[string]map[time.Time]*SubscriptionPayoutStatistics{h, d, w, m, y} {
+		for s := range v {
+			for t := range v[s] {
+				if err := database.SendStatistics(ctx, out, loc, v[s][t].Result(s, t)); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
-	return result, nil
+	return nil
 }
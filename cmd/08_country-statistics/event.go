@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	hubtypes "github.com/sentinel-official/hub/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	NodeEventStatistics struct {
+		Timeframe  string
+		ActiveNode map[string]bool
+	}
+
+	SessionEventStatistics struct {
+		Timeframe        string
+		SessionBandwidth map[uint64]*types.Bandwidth
+	}
+)
+
+func NewNodeEventStatistics(timeframe string) *NodeEventStatistics {
+	return &NodeEventStatistics{
+		Timeframe:  timeframe,
+		ActiveNode: make(map[string]bool),
+	}
+}
+
+func (s *NodeEventStatistics) Result(region Region, timestamp time.Time) bson.M {
+	res := region.Result(s.Timeframe, timestamp)
+
+	if len(s.ActiveNode) != 0 {
+		res["active_node"] = len(s.ActiveNode)
+	}
+
+	return res
+}
+
+func NewSessionEventStatistics(timeframe string) *SessionEventStatistics {
+	return &SessionEventStatistics{
+		Timeframe:        timeframe,
+		SessionBandwidth: make(map[uint64]*types.Bandwidth),
+	}
+}
+
+func (s *SessionEventStatistics) Result(region Region, timestamp time.Time) bson.M {
+	var sessionBandwidth = &types.Bandwidth{}
+	for i := range s.SessionBandwidth {
+		sessionBandwidth = sessionBandwidth.Add(s.SessionBandwidth[i])
+	}
+
+	res := region.Result(s.Timeframe, timestamp)
+
+	if !sessionBandwidth.IsZero() {
+		res["session_bandwidth"] = sessionBandwidth
+	}
+
+	return res
+}
+
+func bucketTimestampStage(minHourTimestamp time.Time, loc *time.Location) bson.M {
+	return bson.M{
+		"$dateFromParts": bson.M{
+			"timezone": loc.String(),
+			"hour": bson.M{
+				"$cond": bson.A{
+					bson.M{"$gte": bson.A{"$timestamp", minHourTimestamp}},
+					bson.M{"$hour": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+					0,
+				},
+			},
+			"day":   bson.M{"$dayOfMonth": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+			"month": bson.M{"$month": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+			"year":  bson.M{"$year": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+		},
+	}
+}
+
+func StatisticsFromNodeEvents(ctx context.Context, db *mongo.Database, regions map[string][]Region, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromNodeEvents", minHourTimestamp, loc)
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"type":   types.EventTypeNodeUpdateStatus,
+				"status": hubtypes.StatusActive.String(),
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"node_addr": "$node_addr",
+					"timestamp": bucketTimestampStage(minHourTimestamp, loc),
+				},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":       0,
+				"node_addr": "$_id.node_addr",
+				"timestamp": "$_id.timestamp",
+			},
+		},
+	}
+
+	cursor, err := database.EventAggregate(ctx, db, pipeline)
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[Region]map[time.Time]*NodeEventStatistics)
+		d = make(map[Region]map[time.Time]*NodeEventStatistics)
+		w = make(map[Region]map[time.Time]*NodeEventStatistics)
+		m = make(map[Region]map[time.Time]*NodeEventStatistics)
+		y = make(map[Region]map[time.Time]*NodeEventStatistics)
+	)
+
+	bucket := func(v map[Region]map[time.Time]*NodeEventStatistics, region Region, timeframe string, t time.Time) *NodeEventStatistics {
+		if _, ok := v[region]; !ok {
+			v[region] = make(map[time.Time]*NodeEventStatistics)
+		}
+		if _, ok := v[region][t]; !ok {
+			v[region][t] = NewNodeEventStatistics(timeframe)
+		}
+
+		return v[region][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item bson.M
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		nodeAddr := types.StringFromInterface(item["node_addr"])
+		timestamp := types.TimeFromInterface(item["timestamp"]).In(loc)
+
+		for _, region := range regions[nodeAddr] {
+			if hourTimestamp := utils.HourDate(timestamp); !hourTimestamp.Before(minHourTimestamp) {
+				bucket(h, region, "hour", hourTimestamp).ActiveNode[nodeAddr] = true
+			}
+
+			bucket(d, region, "day", utils.DayDate(timestamp)).ActiveNode[nodeAddr] = true
+			bucket(w, region, "week", utils.ISOWeekDate(timestamp)).ActiveNode[nodeAddr] = true
+			bucket(m, region, "month", utils.MonthDate(timestamp)).ActiveNode[nodeAddr] = true
+			bucket(y, region, "year", utils.YearDate(timestamp)).ActiveNode[nodeAddr] = true
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[Region]map[time.Time]*NodeEventStatistics{h, d, w, m, y} {
+		for r := range v {
+			for t := range v[r] {
+				if err := database.SendStatistics(ctx, out, loc, v[r][t].Result(r, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func StatisticsFromSessionEvents(ctx context.Context, db *mongo.Database, regions map[string][]Region, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSessionEvents", minHourTimestamp, loc)
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"type": types.EventTypeSessionUpdateDetails,
+			},
+		},
+		{
+			"$sort": bson.M{
+				"timestamp": -1,
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":        0,
+				"bandwidth":  1,
+				"session_id": 1,
+				"timestamp":  1,
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"session_id": "$session_id",
+					"timestamp":  bucketTimestampStage(minHourTimestamp, loc),
+				},
+				"bandwidth": bson.M{"$first": "$bandwidth"},
+			},
+		},
+		{
+			"$lookup": bson.M{
+				"from":         database.SessionCollectionName,
+				"localField":   "_id.session_id",
+				"foreignField": "id",
+				"as":           "session",
+			},
+		},
+		{
+			"$addFields": bson.M{
+				"node_addr": "$session.node_addr",
+			},
+		},
+		{
+			"$unwind": "$node_addr",
+		},
+		{
+			"$project": bson.M{
+				"_id":        0,
+				"bandwidth":  "$bandwidth",
+				"node_addr":  "$node_addr",
+				"session_id": "$_id.session_id",
+				"timestamp":  "$_id.timestamp",
+			},
+		},
+		{
+			"$sort": bson.M{
+				"timestamp": 1,
+			},
+		},
+	}
+
+	cursor, err := database.EventAggregate(ctx, db, pipeline)
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[Region]map[time.Time]*SessionEventStatistics)
+		d = make(map[Region]map[time.Time]*SessionEventStatistics)
+		w = make(map[Region]map[time.Time]*SessionEventStatistics)
+		m = make(map[Region]map[time.Time]*SessionEventStatistics)
+		y = make(map[Region]map[time.Time]*SessionEventStatistics)
+	)
+
+	bucket := func(v map[Region]map[time.Time]*SessionEventStatistics, region Region, timeframe string, t time.Time) *SessionEventStatistics {
+		if _, ok := v[region]; !ok {
+			v[region] = make(map[time.Time]*SessionEventStatistics)
+		}
+		if _, ok := v[region][t]; !ok {
+			v[region][t] = NewSessionEventStatistics(timeframe)
+		}
+
+		return v[region][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item bson.M
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		bandwidth := types.BandwidthFromInterface(item["bandwidth"])
+		nodeAddr := types.StringFromInterface(item["node_addr"])
+		sessionID := types.Uint64FromInterface(item["session_id"])
+		timestamp := types.TimeFromInterface(item["timestamp"]).In(loc)
+
+		for _, region := range regions[nodeAddr] {
+			if hourTimestamp := utils.HourDate(timestamp); !hourTimestamp.Before(minHourTimestamp) {
+				bucket(h, region, "hour", hourTimestamp).SessionBandwidth[sessionID] = bandwidth.Copy()
+			}
+
+			bucket(d, region, "day", utils.DayDate(timestamp)).SessionBandwidth[sessionID] = bandwidth.Copy()
+			bucket(w, region, "week", utils.ISOWeekDate(timestamp)).SessionBandwidth[sessionID] = bandwidth.Copy()
+			bucket(m, region, "month", utils.MonthDate(timestamp)).SessionBandwidth[sessionID] = bandwidth.Copy()
+			bucket(y, region, "year", utils.YearDate(timestamp)).SessionBandwidth[sessionID] = bandwidth.Copy()
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, x := range []struct {
+		v    map[Region]map[time.Time]*SessionEventStatistics
+		prev func(time.Time) time.Time
+	}{
+		{h, func(t time.Time) time.Time { return t.Add(-time.Hour) }},
+		{d, func(t time.Time) time.Time { return t.AddDate(0, 0, -1) }},
+		{w, func(t time.Time) time.Time { return t.AddDate(0, 0, -7) }},
+		{m, func(t time.Time) time.Time { return t.AddDate(0, -1, 0) }},
+		{y, func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) }},
+	} {
+		for r := range x.v {
+			var tKeys []time.Time
+			for t := range x.v[r] {
+				tKeys = append(tKeys, t)
+			}
+
+			sort.Slice(tKeys, func(i, j int) bool {
+				return tKeys[i].After(tKeys[j])
+			})
+
+			for _, t := range tKeys {
+				v, ok := x.v[r][x.prev(t)]
+				if !ok {
+					continue
+				}
+
+				for u := range x.v[r][t].SessionBandwidth {
+					if v, ok := v.SessionBandwidth[u]; ok {
+						x.v[r][t].SessionBandwidth[u] = x.v[r][t].SessionBandwidth[u].Sub(v)
+					}
+				}
+			}
+		}
+	}
+
+	for _, v := range []map[Region]map[time.Time]*SessionEventStatistics{h, d, w, m, y} {
+		for r := range v {
+			for t := range v[r] {
+				if err := database.SendStatistics(ctx, out, loc, v[r][t].Result(r, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+type (
+	Region struct {
+		Country string
+		City    string
+	}
+)
+
+func (r Region) Result(timeframe string, timestamp time.Time) bson.M {
+	res := bson.M{
+		"country":   r.Country,
+		"timeframe": timeframe,
+		"timestamp": timestamp,
+	}
+
+	if r.City != "" {
+		res["city"] = r.City
+	}
+
+	return res
+}
+
+// RegionsFromNodes maps each node address to the region it is located in now.
+// The whole history of a node is attributed to that region, so a node that
+// moved has its past statistics counted under its current country and city.
+func RegionsFromNodes(ctx context.Context, db *mongo.Database) (map[string][]Region, error) {
+	log.Println("RegionsFromNodes")
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{
+				"verified_location.country_code": bson.M{
+					"$exists": true,
+					"$ne":     "",
+				},
+			},
+			bson.M{
+				"location.country": bson.M{
+					"$exists": true,
+					"$ne":     "",
+				},
+			},
+		},
+	}
+	projection := bson.M{
+		"_id":               0,
+		"addr":              1,
+		"location":          1,
+		"verified_location": 1,
+	}
+
+	cursor, err := database.NodeFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	regions := make(map[string][]Region)
+	for cursor.Next(ctx) {
+		var item models.Node
+		if err := cursor.Decode(&item); err != nil {
+			return nil, err
+		}
+
+		country, city := nodetypes.CountryCode(item.Location.Country), item.Location.City
+		if item.VerifiedLocation != nil && item.VerifiedLocation.CountryCode != "" {
+			country = nodetypes.CountryCode(item.VerifiedLocation.CountryCode)
+			if item.VerifiedLocation.City != "" {
+				city = item.VerifiedLocation.City
+			}
+		}
+		if country == "" {
+			continue
+		}
+
+		regions[item.Addr] = append(regions[item.Addr], Region{Country: country})
+		if city != "" {
+			regions[item.Addr] = append(regions[item.Addr], Region{Country: country, City: city})
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return regions, nil
+}
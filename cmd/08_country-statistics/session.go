@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	SessionStatistics struct {
+		Timeframe      string
+		ActiveSession  int64
+		BytesEarning   types.Coins
+		EndSession     int64
+		SessionAddress map[string]bool
+		StartSession   int64
+	}
+)
+
+func NewSessionStatistics(timeframe string) *SessionStatistics {
+	return &SessionStatistics{
+		Timeframe:      timeframe,
+		BytesEarning:   types.NewCoins(nil),
+		SessionAddress: make(map[string]bool),
+	}
+}
+
+func (s *SessionStatistics) Result(region Region, timestamp time.Time) bson.M {
+	res := region.Result(s.Timeframe, timestamp)
+
+	if s.ActiveSession != 0 {
+		res["active_session"] = s.ActiveSession
+	}
+	if s.BytesEarning.Len() != 0 {
+		res["bytes_earning"] = s.BytesEarning
+	}
+	if s.EndSession != 0 {
+		res["end_session"] = s.EndSession
+	}
+	if len(s.SessionAddress) != 0 {
+		res["session_address"] = len(s.SessionAddress)
+	}
+	if s.StartSession != 0 {
+		res["start_session"] = s.StartSession
+	}
+
+	return res
+}
+
+func StatisticsFromSessions(ctx context.Context, db *mongo.Database, regions map[string][]Region, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSessions", minTimestamp, maxTimestamp, minHourTimestamp, loc)
+
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":             0,
+		"acc_addr":        1,
+		"end_timestamp":   1,
+		"node_addr":       1,
+		"payment":         1,
+		"start_timestamp": 1,
+	}
+
+	cursor, err := database.SessionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[Region]map[time.Time]*SessionStatistics)
+		d = make(map[Region]map[time.Time]*SessionStatistics)
+		w = make(map[Region]map[time.Time]*SessionStatistics)
+		m = make(map[Region]map[time.Time]*SessionStatistics)
+		y = make(map[Region]map[time.Time]*SessionStatistics)
+	)
+
+	bucket := func(v map[Region]map[time.Time]*SessionStatistics, region Region, timeframe string, t time.Time) *SessionStatistics {
+		if _, ok := v[region]; !ok {
+			v[region] = make(map[time.Time]*SessionStatistics)
+		}
+		if _, ok := v[region][t]; !ok {
+			v[region][t] = NewSessionStatistics(timeframe)
+		}
+
+		return v[region][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item models.Session
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		if len(regions[item.NodeAddr]) == 0 {
+			continue
+		}
+
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
+		}
+
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
+		}
+
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
+		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
+		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
+		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
+
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
+		}
+
+		exclude := utils.ContainsString(excludeAddrs, item.AccAddr)
+		for _, region := range regions[item.NodeAddr] {
+			if item.Payment != nil {
+				if !hourEndTimestamp.Before(minHourTimestamp) {
+					v := bucket(h, region, "hour", hourEndTimestamp)
+					v.BytesEarning = v.BytesEarning.Add(item.Payment)
+				}
+
+				for _, v := range []*SessionStatistics{
+					bucket(d, region, "day", dayEndTimestamp),
+					bucket(w, region, "week", weekEndTimestamp),
+					bucket(m, region, "month", monthEndTimestamp),
+					bucket(y, region, "year", yearEndTimestamp),
+				} {
+					v.BytesEarning = v.BytesEarning.Add(item.Payment)
+				}
+			}
+
+			if exclude {
+				continue
+			}
+
+			for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+				v := bucket(h, region, "hour", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+			}
+
+			for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
+				v := bucket(d, region, "day", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+			}
+
+			for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
+				v := bucket(w, region, "week", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+			}
+
+			for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
+				v := bucket(m, region, "month", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+			}
+
+			for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
+				v := bucket(y, region, "year", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+			}
+
+			if !item.EndTimestamp.IsZero() {
+				if !hourEndTimestamp.Before(minHourTimestamp) {
+					bucket(h, region, "hour", hourEndTimestamp).EndSession += 1
+				}
+
+				bucket(d, region, "day", dayEndTimestamp).EndSession += 1
+				bucket(w, region, "week", weekEndTimestamp).EndSession += 1
+				bucket(m, region, "month", monthEndTimestamp).EndSession += 1
+				bucket(y, region, "year", yearEndTimestamp).EndSession += 1
+			}
+			if !item.StartTimestamp.IsZero() {
+				if !hourStartTimestamp.Before(minHourTimestamp) {
+					bucket(h, region, "hour", hourStartTimestamp).StartSession += 1
+				}
+
+				bucket(d, region, "day", dayStartTimestamp).StartSession += 1
+				bucket(w, region, "week", weekStartTimestamp).StartSession += 1
+				bucket(m, region, "month", monthStartTimestamp).StartSession += 1
+				bucket(y, region, "year", yearStartTimestamp).StartSession += 1
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[Region]map[time.Time]*SessionStatistics{h, d, w, m, y} {
+		for r := range v {
+			for t := range v[r] {
+				if err := database.SendStatistics(ctx, out, loc, v[r][t].Result(r, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	SubscriptionPayoutStatistics struct {
+		Timeframe    string
+		HoursEarning types.Coins
+	}
+)
+
+func NewSubscriptionPayoutStatistics(timeframe string) *SubscriptionPayoutStatistics {
+	return &SubscriptionPayoutStatistics{
+		Timeframe:    timeframe,
+		HoursEarning: types.NewCoins(nil),
+	}
+}
+
+func (s *SubscriptionPayoutStatistics) Result(region Region, timestamp time.Time) bson.M {
+	res := region.Result(s.Timeframe, timestamp)
+
+	if s.HoursEarning.Len() != 0 {
+		res["hours_earning"] = s.HoursEarning
+	}
+
+	return res
+}
+
+func StatisticsFromSubscriptionPayouts(ctx context.Context, db *mongo.Database, regions map[string][]Region, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSubscriptionPayouts", minHourTimestamp, loc)
+
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":       0,
+		"node_addr": 1,
+		"payment":   1,
+		"timestamp": 1,
+	}
+
+	cursor, err := database.SubscriptionPayoutFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[Region]map[time.Time]*SubscriptionPayoutStatistics)
+		d = make(map[Region]map[time.Time]*SubscriptionPayoutStatistics)
+		w = make(map[Region]map[time.Time]*SubscriptionPayoutStatistics)
+		m = make(map[Region]map[time.Time]*SubscriptionPayoutStatistics)
+		y = make(map[Region]map[time.Time]*SubscriptionPayoutStatistics)
+	)
+
+	bucket := func(v map[Region]map[time.Time]*SubscriptionPayoutStatistics, region Region, timeframe string, t time.Time) *SubscriptionPayoutStatistics {
+		if _, ok := v[region]; !ok {
+			v[region] = make(map[time.Time]*SubscriptionPayoutStatistics)
+		}
+		if _, ok := v[region][t]; !ok {
+			v[region][t] = NewSubscriptionPayoutStatistics(timeframe)
+		}
+
+		return v[region][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item models.SubscriptionPayout
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		if item.Payment == nil {
+			continue
+		}
+
+		timestamp := item.Timestamp.In(loc)
+		for _, region := range regions[item.NodeAddr] {
+			if hourTimestamp := utils.HourDate(timestamp); !hourTimestamp.Before(minHourTimestamp) {
+				v := bucket(h, region, "hour", hourTimestamp)
+				v.HoursEarning = v.HoursEarning.Add(item.Payment)
+			}
+
+			for _, v := range []*SubscriptionPayoutStatistics{
+				bucket(d, region, "day", utils.DayDate(timestamp)),
+				bucket(w, region, "week", utils.ISOWeekDate(timestamp)),
+				bucket(m, region, "month", utils.MonthDate(timestamp)),
+				bucket(y, region, "year", utils.YearDate(timestamp)),
+			} {
+				v.HoursEarning = v.HoursEarning.Add(item.Payment)
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[Region]map[time.Time]*SubscriptionPayoutStatistics{h, d, w, m, y} {
+		for r := range v {
+			for t := range v[r] {
+				if err := database.SendStatistics(ctx, out, loc, v[r][t].Result(r, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	PlanEventStatistics struct {
+		Timeframe  string
+		LinkedNode map[string]bool
+	}
+
+	PlanNode struct {
+		PlanID   uint64
+		NodeAddr string
+	}
+
+	PlanNodeInterval struct {
+		PlanNode
+		StartTimestamp time.Time
+		EndTimestamp   time.Time
+	}
+)
+
+func NewPlanEventStatistics(timeframe string) *PlanEventStatistics {
+	return &PlanEventStatistics{
+		Timeframe:  timeframe,
+		LinkedNode: make(map[string]bool),
+	}
+}
+
+func (s *PlanEventStatistics) Result(owner Owner, timestamp time.Time) bson.M {
+	res := owner.Result(s.Timeframe, timestamp)
+
+	if len(s.LinkedNode) != 0 {
+		res["linked_node"] = len(s.LinkedNode)
+	}
+
+	return res
+}
+
+func planNodeIntervals(ctx context.Context, db *mongo.Database, maxTimestamp time.Time) ([]PlanNodeInterval, error) {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"type": bson.M{
+					"$in": bson.A{
+						types.EventTypePlanLinkNode,
+						types.EventTypePlanUnlinkNode,
+					},
+				},
+			},
+		},
+		{
+			"$sort": bson.D{
+				bson.E{Key: "timestamp", Value: 1},
+				bson.E{Key: "height", Value: 1},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":       0,
+				"node_addr": 1,
+				"plan_id":   1,
+				"timestamp": 1,
+				"type":      1,
+			},
+		},
+	}
+
+	cursor, err := database.EventAggregate(ctx, db, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		items []PlanNodeInterval
+		open  = make(map[PlanNode]time.Time)
+	)
+
+	for cursor.Next(ctx) {
+		var item bson.M
+		if err := cursor.Decode(&item); err != nil {
+			return nil, err
+		}
+
+		key := PlanNode{
+			PlanID:   types.Uint64FromInterface(item["plan_id"]),
+			NodeAddr: types.StringFromInterface(item["node_addr"]),
+		}
+		timestamp := types.TimeFromInterface(item["timestamp"])
+
+		switch types.StringFromInterface(item["type"]) {
+		case types.EventTypePlanLinkNode:
+			if _, ok := open[key]; !ok {
+				open[key] = timestamp
+			}
+		case types.EventTypePlanUnlinkNode:
+			if startTimestamp, ok := open[key]; ok {
+				items = append(items, PlanNodeInterval{
+					PlanNode:       key,
+					StartTimestamp: startTimestamp,
+					EndTimestamp:   timestamp,
+				})
+
+				delete(open, key)
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	for key, startTimestamp := range open {
+		items = append(items, PlanNodeInterval{
+			PlanNode:       key,
+			StartTimestamp: startTimestamp,
+			EndTimestamp:   maxTimestamp,
+		})
+	}
+
+	return items, nil
+}
+
+func StatisticsFromPlanEvents(ctx context.Context, db *mongo.Database, owners map[uint64][]Owner, maxTimestamp, minHourTimestamp time.Time, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromPlanEvents", maxTimestamp, minHourTimestamp, loc)
+
+	intervals, err := planNodeIntervals(ctx, db, maxTimestamp)
+	if err != nil {
+		return err
+	}
+
+	var (
+		h = make(map[Owner]map[time.Time]*PlanEventStatistics)
+		d = make(map[Owner]map[time.Time]*PlanEventStatistics)
+		w = make(map[Owner]map[time.Time]*PlanEventStatistics)
+		m = make(map[Owner]map[time.Time]*PlanEventStatistics)
+		y = make(map[Owner]map[time.Time]*PlanEventStatistics)
+	)
+
+	bucket := func(v map[Owner]map[time.Time]*PlanEventStatistics, owner Owner, timeframe string, t time.Time) *PlanEventStatistics {
+		if _, ok := v[owner]; !ok {
+			v[owner] = make(map[time.Time]*PlanEventStatistics)
+		}
+		if _, ok := v[owner][t]; !ok {
+			v[owner][t] = NewPlanEventStatistics(timeframe)
+		}
+
+		return v[owner][t]
+	}
+
+	for _, item := range intervals {
+		startTimestamp, endTimestamp := item.StartTimestamp.In(loc), item.EndTimestamp.In(loc)
+
+		hourFromTimestamp := utils.HourDate(startTimestamp)
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
+		}
+
+		for _, owner := range owners[item.PlanID] {
+			for t := hourFromTimestamp; !t.After(utils.HourDate(endTimestamp)); t = t.Add(time.Hour) {
+				bucket(h, owner, "hour", t).LinkedNode[item.NodeAddr] = true
+			}
+			for t := utils.DayDate(startTimestamp); !t.After(utils.DayDate(endTimestamp)); t = t.AddDate(0, 0, 1) {
+				bucket(d, owner, "day", t).LinkedNode[item.NodeAddr] = true
+			}
+			for t := utils.ISOWeekDate(startTimestamp); !t.After(utils.ISOWeekDate(endTimestamp)); t = t.AddDate(0, 0, 7) {
+				bucket(w, owner, "week", t).LinkedNode[item.NodeAddr] = true
+			}
+			for t := utils.MonthDate(startTimestamp); !t.After(utils.MonthDate(endTimestamp)); t = t.AddDate(0, 1, 0) {
+				bucket(m, owner, "month", t).LinkedNode[item.NodeAddr] = true
+			}
+			for t := utils.YearDate(startTimestamp); !t.After(utils.YearDate(endTimestamp)); t = t.AddDate(1, 0, 0) {
+				bucket(y, owner, "year", t).LinkedNode[item.NodeAddr] = true
+			}
+		}
+	}
+
+	for _, v := range []map[Owner]map[time.Time]*PlanEventStatistics{h, d, w, m, y} {
+		for o := range v {
+			for t := range v[o] {
+				if err := database.SendStatistics(ctx, out, loc, v[o][t].Result(o, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
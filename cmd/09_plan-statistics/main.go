@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+const (
+	appName = "09_plan-statistics"
+)
+
+var (
+	batchSize    int
+	dbAddress    string
+	dbName       string
+	dbUsername   string
+	dbPassword   string
+	excludeAddrs string
+	hourWindow   time.Duration
+	timezones    string
+)
+
+func init() {
+	log.SetFlags(0)
+
+	flag.IntVar(&batchSize, "batch-size", 25_000, "")
+	flag.StringVar(&dbAddress, "db-address", "mongodb://127.0.0.1:27017", "")
+	flag.StringVar(&dbName, "db-name", "sentinelhub-2", "")
+	flag.StringVar(&dbUsername, "db-username", "", "")
+	flag.StringVar(&dbPassword, "db-password", "", "")
+	flag.StringVar(&excludeAddrs, "exclude-addrs", "sent1c4nvz43tlw6d0c9nfu6r957y5d9pgjk5czl3n3", "")
+	flag.DurationVar(&hourWindow, "hour-window", 30*24*time.Hour, "")
+	flag.StringVar(&timezones, "timezones", "", "")
+	flag.Parse()
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "type", Value: 1},
+				bson.E{Key: "timestamp", Value: -1},
+			},
+		},
+	}
+
+	_, err := database.EventIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "id", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true),
+		},
+	}
+
+	_, err = database.SessionIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "plan_id", Value: 1},
+				bson.E{Key: "timeframe", Value: 1},
+				bson.E{Key: "tz", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true),
+		},
+	}
+
+	_, err = database.PlanStatisticIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "prov_addr", Value: 1},
+				bson.E{Key: "timeframe", Value: 1},
+				bson.E{Key: "tz", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true),
+		},
+	}
+
+	_, err = database.ProviderStatisticIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func main() {
+	db, err := utils.PrepareDatabase(context.TODO(), appName, dbUsername, dbPassword, dbAddress, dbName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := db.Client().Ping(context.TODO(), nil); err != nil {
+		log.Fatalln(err)
+	}
+
+	now := time.Now()
+	runID := primitive.NewObjectID()
+	if err := createIndexes(context.TODO(), db); err != nil {
+		log.Fatalln(err)
+	}
+
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":    0,
+		"height": 1,
+		"time":   1,
+	}
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "height", Value: -1},
+		}).
+		SetLimit(1)
+
+	dBlocks, err := database.BlockFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	maxTimestamp := time.Now().UTC()
+	if len(dBlocks) > 0 {
+		maxTimestamp = dBlocks[0].Time
+	}
+
+	minHourTimestamp := utils.HourDate(maxTimestamp.Add(-hourWindow))
+	log.Println("MinHourTimestamp", minHourTimestamp)
+
+	excludeAddrs := strings.Split(excludeAddrs, ",")
+	sort.Strings(excludeAddrs)
+
+	locations, err := utils.ParseLocations(timezones)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	owners, err := OwnersFromPlans(context.TODO(), db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	subscriptionPlans, err := PlansFromSubscriptions(context.TODO(), db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	var (
+		out    = make(chan bson.M, batchSize)
+		writer = errgroup.Group{}
+	)
+
+	writer.Go(func() error {
+		defer cancel()
+
+		return database.WriteStatistics(ctx, db, out, batchSize, func(item bson.M) (string, mongo.WriteModel) {
+			item["run_id"] = runID
+			if item["plan_id"] != nil {
+				return database.PlanStatisticCollectionName, database.NewStatisticUpsertModel(item, "plan_id")
+			}
+
+			return database.ProviderStatisticCollectionName, database.NewStatisticUpsertModel(item, "prov_addr")
+		})
+	})
+
+	produce := func(loc *time.Location) error {
+		hourTimestamp := minHourTimestamp
+		if loc != time.UTC {
+			hourTimestamp = maxTimestamp.Add(time.Hour)
+		}
+
+		group := errgroup.Group{}
+
+		group.Go(func() error {
+			return StatisticsFromPlanEvents(ctx, db, owners, maxTimestamp, hourTimestamp, loc, out)
+		})
+
+		group.Go(func() error {
+			return StatisticsFromSessions(ctx, db, owners, subscriptionPlans, time.Time{}, maxTimestamp, hourTimestamp, excludeAddrs, loc, out)
+		})
+
+		group.Go(func() error {
+			return StatisticsFromSubscriptions(ctx, db, owners, time.Time{}, maxTimestamp, hourTimestamp, excludeAddrs, loc, out)
+		})
+
+		return group.Wait()
+	}
+
+	var producerErr error
+	for _, loc := range locations {
+		if producerErr = produce(loc); producerErr != nil {
+			cancel()
+			break
+		}
+	}
+
+	close(out)
+
+	if err := writer.Wait(); err != nil {
+		log.Fatalln(err)
+	}
+	if producerErr != nil {
+		log.Fatalln(producerErr)
+	}
+
+	filter = bson.M{
+		"run_id": bson.M{
+			"$ne": runID,
+		},
+	}
+
+	if err := database.PlanStatisticDeleteMany(context.TODO(), db, filter); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := database.ProviderStatisticDeleteMany(context.TODO(), db, filter); err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Println("Duration", time.Since(now))
+	log.Println("")
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
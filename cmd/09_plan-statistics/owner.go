@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+)
+
+type (
+	Owner struct {
+		PlanID   uint64
+		ProvAddr string
+	}
+)
+
+func (o Owner) Result(timeframe string, timestamp time.Time) bson.M {
+	res := bson.M{
+		"timeframe": timeframe,
+		"timestamp": timestamp,
+	}
+
+	if o.PlanID != 0 {
+		res["plan_id"] = o.PlanID
+	} else {
+		res["prov_addr"] = o.ProvAddr
+	}
+
+	return res
+}
+
+func OwnersFromPlans(ctx context.Context, db *mongo.Database) (map[uint64][]Owner, error) {
+	log.Println("OwnersFromPlans")
+
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":       0,
+		"id":        1,
+		"prov_addr": 1,
+	}
+
+	dPlans, err := database.PlanFind(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[uint64][]Owner)
+	for _, item := range dPlans {
+		owners[item.ID] = append(owners[item.ID], Owner{PlanID: item.ID})
+		if item.ProvAddr != "" {
+			owners[item.ID] = append(owners[item.ID], Owner{ProvAddr: item.ProvAddr})
+		}
+	}
+
+	return owners, nil
+}
+
+func PlansFromSubscriptions(ctx context.Context, db *mongo.Database) (map[uint64]uint64, error) {
+	log.Println("PlansFromSubscriptions")
+
+	filter := bson.M{
+		"plan_id": bson.M{
+			"$gt": 0,
+		},
+	}
+	projection := bson.M{
+		"_id":     0,
+		"id":      1,
+		"plan_id": 1,
+	}
+
+	cursor, err := database.SubscriptionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	plans := make(map[uint64]uint64)
+	for cursor.Next(ctx) {
+		var item models.Subscription
+		if err := cursor.Decode(&item); err != nil {
+			return nil, err
+		}
+
+		plans[item.ID] = item.PlanID
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
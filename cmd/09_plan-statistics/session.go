@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	SessionStatistics struct {
+		Timeframe        string
+		ActiveSession    int64
+		EndSession       int64
+		SessionAddress   map[string]bool
+		SessionBandwidth *types.Bandwidth
+		SessionNode      map[string]bool
+		StartSession     int64
+	}
+)
+
+func NewSessionStatistics(timeframe string) *SessionStatistics {
+	return &SessionStatistics{
+		Timeframe:        timeframe,
+		SessionAddress:   make(map[string]bool),
+		SessionBandwidth: &types.Bandwidth{},
+		SessionNode:      make(map[string]bool),
+	}
+}
+
+func (s *SessionStatistics) Result(owner Owner, timestamp time.Time) bson.M {
+	res := owner.Result(s.Timeframe, timestamp)
+
+	if s.ActiveSession != 0 {
+		res["active_session"] = s.ActiveSession
+	}
+	if s.EndSession != 0 {
+		res["end_session"] = s.EndSession
+	}
+	if len(s.SessionAddress) != 0 {
+		res["session_address"] = len(s.SessionAddress)
+	}
+	if !s.SessionBandwidth.IsZero() {
+		res["session_bandwidth"] = s.SessionBandwidth
+	}
+	if len(s.SessionNode) != 0 {
+		res["session_node"] = len(s.SessionNode)
+	}
+	if s.StartSession != 0 {
+		res["start_session"] = s.StartSession
+	}
+
+	return res
+}
+
+func StatisticsFromSessions(ctx context.Context, db *mongo.Database, owners map[uint64][]Owner, subscriptionPlans map[uint64]uint64, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSessions", minTimestamp, maxTimestamp, minHourTimestamp, loc)
+
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":             0,
+		"acc_addr":        1,
+		"bandwidth":       1,
+		"end_timestamp":   1,
+		"node_addr":       1,
+		"start_timestamp": 1,
+		"subscription_id": 1,
+	}
+
+	cursor, err := database.SessionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[Owner]map[time.Time]*SessionStatistics)
+		d = make(map[Owner]map[time.Time]*SessionStatistics)
+		w = make(map[Owner]map[time.Time]*SessionStatistics)
+		m = make(map[Owner]map[time.Time]*SessionStatistics)
+		y = make(map[Owner]map[time.Time]*SessionStatistics)
+	)
+
+	bucket := func(v map[Owner]map[time.Time]*SessionStatistics, owner Owner, timeframe string, t time.Time) *SessionStatistics {
+		if _, ok := v[owner]; !ok {
+			v[owner] = make(map[time.Time]*SessionStatistics)
+		}
+		if _, ok := v[owner][t]; !ok {
+			v[owner][t] = NewSessionStatistics(timeframe)
+		}
+
+		return v[owner][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item models.Session
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		planID, ok := subscriptionPlans[item.SubscriptionID]
+		if !ok {
+			continue
+		}
+
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
+		}
+
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
+		}
+
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
+		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
+		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
+		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
+
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
+		}
+
+		if utils.ContainsString(excludeAddrs, item.AccAddr) {
+			continue
+		}
+
+		for _, owner := range owners[planID] {
+			for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+				v := bucket(h, owner, "hour", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+				v.SessionNode[item.NodeAddr] = true
+			}
+
+			for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
+				v := bucket(d, owner, "day", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+				v.SessionNode[item.NodeAddr] = true
+			}
+
+			for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
+				v := bucket(w, owner, "week", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+				v.SessionNode[item.NodeAddr] = true
+			}
+
+			for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
+				v := bucket(m, owner, "month", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+				v.SessionNode[item.NodeAddr] = true
+			}
+
+			for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
+				v := bucket(y, owner, "year", t)
+				v.ActiveSession += 1
+				v.SessionAddress[item.AccAddr] = true
+				v.SessionNode[item.NodeAddr] = true
+			}
+
+			if !item.EndTimestamp.IsZero() {
+				if !hourEndTimestamp.Before(minHourTimestamp) {
+					bucket(h, owner, "hour", hourEndTimestamp).EndSession += 1
+				}
+
+				bucket(d, owner, "day", dayEndTimestamp).EndSession += 1
+				bucket(w, owner, "week", weekEndTimestamp).EndSession += 1
+				bucket(m, owner, "month", monthEndTimestamp).EndSession += 1
+				bucket(y, owner, "year", yearEndTimestamp).EndSession += 1
+
+				if item.Bandwidth != nil {
+					if !hourEndTimestamp.Before(minHourTimestamp) {
+						bucket(h, owner, "hour", hourEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+					}
+
+					bucket(d, owner, "day", dayEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+					bucket(w, owner, "week", weekEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+					bucket(m, owner, "month", monthEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+					bucket(y, owner, "year", yearEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+				}
+			}
+			if !item.StartTimestamp.IsZero() {
+				if !hourStartTimestamp.Before(minHourTimestamp) {
+					bucket(h, owner, "hour", hourStartTimestamp).StartSession += 1
+				}
+
+				bucket(d, owner, "day", dayStartTimestamp).StartSession += 1
+				bucket(w, owner, "week", weekStartTimestamp).StartSession += 1
+				bucket(m, owner, "month", monthStartTimestamp).StartSession += 1
+				bucket(y, owner, "year", yearStartTimestamp).StartSession += 1
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[Owner]map[time.Time]*SessionStatistics{h, d, w, m, y} {
+		for o := range v {
+			for t := range v[o] {
+				if err := database.SendStatistics(ctx, out, loc, v[o][t].Result(o, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
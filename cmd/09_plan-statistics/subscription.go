@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	SubscriptionStatistics struct {
+		Timeframe           string
+		ActiveSubscription  int64
+		EndSubscription     int64
+		PlanPayment         types.Coins
+		PlanStakingReward   types.Coins
+		StartSubscription   int64
+		SubscriptionAddress map[string]bool
+	}
+)
+
+func NewSubscriptionStatistics(timeframe string) *SubscriptionStatistics {
+	return &SubscriptionStatistics{
+		Timeframe:           timeframe,
+		PlanPayment:         types.NewCoins(nil),
+		PlanStakingReward:   types.NewCoins(nil),
+		SubscriptionAddress: make(map[string]bool),
+	}
+}
+
+func (s *SubscriptionStatistics) Result(owner Owner, timestamp time.Time) bson.M {
+	res := owner.Result(s.Timeframe, timestamp)
+
+	if s.ActiveSubscription != 0 {
+		res["active_subscription"] = s.ActiveSubscription
+	}
+	if s.EndSubscription != 0 {
+		res["end_subscription"] = s.EndSubscription
+	}
+	if s.PlanPayment.Len() != 0 {
+		res["plan_payment"] = s.PlanPayment
+	}
+	if s.PlanStakingReward.Len() != 0 {
+		res["plan_staking_reward"] = s.PlanStakingReward
+	}
+	if s.StartSubscription != 0 {
+		res["start_subscription"] = s.StartSubscription
+	}
+	if len(s.SubscriptionAddress) != 0 {
+		res["subscription_address"] = len(s.SubscriptionAddress)
+	}
+
+	return res
+}
+
+func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, owners map[uint64][]Owner, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSubscriptions", minTimestamp, maxTimestamp, minHourTimestamp, loc)
+
+	filter := bson.M{
+		"plan_id": bson.M{
+			"$gt": 0,
+		},
+	}
+	projection := bson.M{
+		"_id":             0,
+		"acc_addr":        1,
+		"end_timestamp":   1,
+		"payment":         1,
+		"plan_id":         1,
+		"staking_reward":  1,
+		"start_timestamp": 1,
+	}
+
+	cursor, err := database.SubscriptionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[Owner]map[time.Time]*SubscriptionStatistics)
+		d = make(map[Owner]map[time.Time]*SubscriptionStatistics)
+		w = make(map[Owner]map[time.Time]*SubscriptionStatistics)
+		m = make(map[Owner]map[time.Time]*SubscriptionStatistics)
+		y = make(map[Owner]map[time.Time]*SubscriptionStatistics)
+	)
+
+	bucket := func(v map[Owner]map[time.Time]*SubscriptionStatistics, owner Owner, timeframe string, t time.Time) *SubscriptionStatistics {
+		if _, ok := v[owner]; !ok {
+			v[owner] = make(map[time.Time]*SubscriptionStatistics)
+		}
+		if _, ok := v[owner][t]; !ok {
+			v[owner][t] = NewSubscriptionStatistics(timeframe)
+		}
+
+		return v[owner][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item models.Subscription
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
+		}
+
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
+		}
+
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
+		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
+		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
+		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
+
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
+		}
+
+		exclude := utils.ContainsString(excludeAddrs, item.AccAddr)
+		for _, owner := range owners[item.PlanID] {
+			if item.Payment != nil {
+				if !hourStartTimestamp.Before(minHourTimestamp) {
+					v := bucket(h, owner, "hour", hourStartTimestamp)
+					v.PlanPayment = v.PlanPayment.Add(item.Payment)
+				}
+
+				for _, v := range []*SubscriptionStatistics{
+					bucket(d, owner, "day", dayStartTimestamp),
+					bucket(w, owner, "week", weekStartTimestamp),
+					bucket(m, owner, "month", monthStartTimestamp),
+					bucket(y, owner, "year", yearStartTimestamp),
+				} {
+					v.PlanPayment = v.PlanPayment.Add(item.Payment)
+				}
+			}
+			if item.StakingReward != nil {
+				if !hourStartTimestamp.Before(minHourTimestamp) {
+					v := bucket(h, owner, "hour", hourStartTimestamp)
+					v.PlanStakingReward = v.PlanStakingReward.Add(item.StakingReward)
+				}
+
+				for _, v := range []*SubscriptionStatistics{
+					bucket(d, owner, "day", dayStartTimestamp),
+					bucket(w, owner, "week", weekStartTimestamp),
+					bucket(m, owner, "month", monthStartTimestamp),
+					bucket(y, owner, "year", yearStartTimestamp),
+				} {
+					v.PlanStakingReward = v.PlanStakingReward.Add(item.StakingReward)
+				}
+			}
+
+			if exclude {
+				continue
+			}
+
+			for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+				v := bucket(h, owner, "hour", t)
+				v.ActiveSubscription += 1
+				v.SubscriptionAddress[item.AccAddr] = true
+			}
+
+			for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
+				v := bucket(d, owner, "day", t)
+				v.ActiveSubscription += 1
+				v.SubscriptionAddress[item.AccAddr] = true
+			}
+
+			for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
+				v := bucket(w, owner, "week", t)
+				v.ActiveSubscription += 1
+				v.SubscriptionAddress[item.AccAddr] = true
+			}
+
+			for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
+				v := bucket(m, owner, "month", t)
+				v.ActiveSubscription += 1
+				v.SubscriptionAddress[item.AccAddr] = true
+			}
+
+			for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
+				v := bucket(y, owner, "year", t)
+				v.ActiveSubscription += 1
+				v.SubscriptionAddress[item.AccAddr] = true
+			}
+
+			if !item.EndTimestamp.IsZero() {
+				if !hourEndTimestamp.Before(minHourTimestamp) {
+					bucket(h, owner, "hour", hourEndTimestamp).EndSubscription += 1
+				}
+
+				bucket(d, owner, "day", dayEndTimestamp).EndSubscription += 1
+				bucket(w, owner, "week", weekEndTimestamp).EndSubscription += 1
+				bucket(m, owner, "month", monthEndTimestamp).EndSubscription += 1
+				bucket(y, owner, "year", yearEndTimestamp).EndSubscription += 1
+			}
+			if !item.StartTimestamp.IsZero() {
+				if !hourStartTimestamp.Before(minHourTimestamp) {
+					bucket(h, owner, "hour", hourStartTimestamp).StartSubscription += 1
+				}
+
+				bucket(d, owner, "day", dayStartTimestamp).StartSubscription += 1
+				bucket(w, owner, "week", weekStartTimestamp).StartSubscription += 1
+				bucket(m, owner, "month", monthStartTimestamp).StartSubscription += 1
+				bucket(y, owner, "year", yearStartTimestamp).StartSubscription += 1
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[Owner]map[time.Time]*SubscriptionStatistics{h, d, w, m, y} {
+		for o := range v {
+			for t := range v[o] {
+				if err := database.SendStatistics(ctx, out, loc, v[o][t].Result(o, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	AllocationEventStatistics struct {
+		Timeframe     string
+		GrantedBytes  sdk.Int
+		UtilisedBytes sdk.Int
+	}
+
+	DepositEventStatistics struct {
+		Timeframe       string
+		DepositAdd      types.Coins
+		DepositSubtract types.Coins
+	}
+
+	Allocation struct {
+		SubscriptionID uint64
+		AccAddr        string
+	}
+)
+
+func NewAllocationEventStatistics(timeframe string) *AllocationEventStatistics {
+	return &AllocationEventStatistics{
+		Timeframe:     timeframe,
+		GrantedBytes:  sdk.ZeroInt(),
+		UtilisedBytes: sdk.ZeroInt(),
+	}
+}
+
+func (s *AllocationEventStatistics) Result(accAddr string, timestamp time.Time) bson.M {
+	res := bson.M{
+		"acc_addr":  accAddr,
+		"timeframe": s.Timeframe,
+		"timestamp": timestamp,
+	}
+
+	if !s.GrantedBytes.IsZero() {
+		res["granted_bytes"] = s.GrantedBytes.String()
+	}
+	if !s.UtilisedBytes.IsZero() {
+		res["utilised_bytes"] = s.UtilisedBytes.String()
+	}
+
+	return res
+}
+
+func NewDepositEventStatistics(timeframe string) *DepositEventStatistics {
+	return &DepositEventStatistics{
+		Timeframe:       timeframe,
+		DepositAdd:      types.NewCoins(nil),
+		DepositSubtract: types.NewCoins(nil),
+	}
+}
+
+func (s *DepositEventStatistics) Result(accAddr string, timestamp time.Time) bson.M {
+	res := bson.M{
+		"acc_addr":  accAddr,
+		"timeframe": s.Timeframe,
+		"timestamp": timestamp,
+	}
+
+	if s.DepositAdd.Len() != 0 {
+		res["deposit_add"] = s.DepositAdd
+	}
+	if s.DepositSubtract.Len() != 0 {
+		res["deposit_subtract"] = s.DepositSubtract
+	}
+
+	return res
+}
+
+func eventsCursor(ctx context.Context, db *mongo.Database, eventTypes []string, excludeAddrs []string) (*mongo.Cursor, error) {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"type": bson.M{
+					"$in": eventTypes,
+				},
+				"acc_addr": bson.M{
+					"$nin": excludeAddrs,
+				},
+			},
+		},
+		{
+			"$sort": bson.D{
+				bson.E{Key: "timestamp", Value: 1},
+				bson.E{Key: "height", Value: 1},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":             0,
+				"acc_addr":        1,
+				"coins":           1,
+				"granted_bytes":   1,
+				"subscription_id": 1,
+				"timestamp":       1,
+				"type":            1,
+				"utilised_bytes":  1,
+			},
+		},
+	}
+
+	return database.EventAggregate(ctx, db, pipeline)
+}
+
+func StatisticsFromAllocationEvents(ctx context.Context, db *mongo.Database, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromAllocationEvents", minHourTimestamp, loc)
+
+	cursor, err := eventsCursor(ctx, db, []string{types.EventTypeSubscriptionAllocationUpdateDetails}, excludeAddrs)
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[string]map[time.Time]*AllocationEventStatistics)
+		d = make(map[string]map[time.Time]*AllocationEventStatistics)
+		w = make(map[string]map[time.Time]*AllocationEventStatistics)
+		m = make(map[string]map[time.Time]*AllocationEventStatistics)
+		y = make(map[string]map[time.Time]*AllocationEventStatistics)
+
+		allocations = make(map[Allocation]*models.Event)
+	)
+
+	bucket := func(v map[string]map[time.Time]*AllocationEventStatistics, accAddr string, timeframe string, t time.Time) *AllocationEventStatistics {
+		if _, ok := v[accAddr]; !ok {
+			v[accAddr] = make(map[time.Time]*AllocationEventStatistics)
+		}
+		if _, ok := v[accAddr][t]; !ok {
+			v[accAddr][t] = NewAllocationEventStatistics(timeframe)
+		}
+
+		return v[accAddr][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item models.Event
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		key := Allocation{
+			SubscriptionID: item.SubscriptionID,
+			AccAddr:        item.AccAddr,
+		}
+
+		grantedBytes := utils.MustIntFromString(item.GrantedBytes)
+		utilisedBytes := utils.MustIntFromString(item.UtilisedBytes)
+		if prev, ok := allocations[key]; ok {
+			grantedBytes = grantedBytes.Sub(utils.MustIntFromString(prev.GrantedBytes))
+			utilisedBytes = utilisedBytes.Sub(utils.MustIntFromString(prev.UtilisedBytes))
+		}
+
+		allocations[key] = &item
+
+		timestamp := item.Timestamp.In(loc)
+
+		var items []*AllocationEventStatistics
+		if hourTimestamp := utils.HourDate(timestamp); !hourTimestamp.Before(minHourTimestamp) {
+			items = append(items, bucket(h, item.AccAddr, "hour", hourTimestamp))
+		}
+
+		items = append(
+			items,
+			bucket(d, item.AccAddr, "day", utils.DayDate(timestamp)),
+			bucket(w, item.AccAddr, "week", utils.ISOWeekDate(timestamp)),
+			bucket(m, item.AccAddr, "month", utils.MonthDate(timestamp)),
+			bucket(y, item.AccAddr, "year", utils.YearDate(timestamp)),
+		)
+
+		for _, v := range items {
+			v.GrantedBytes = v.GrantedBytes.Add(grantedBytes)
+			v.UtilisedBytes = v.UtilisedBytes.Add(utilisedBytes)
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[string]map[time.Time]*AllocationEventStatistics{h, d, w, m, y} {
+		for s := range v {
+			for t := range v[s] {
+				if err := database.SendStatistics(ctx, out, loc, v[s][t].Result(s, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func StatisticsFromDepositEvents(ctx context.Context, db *mongo.Database, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromDepositEvents", minHourTimestamp, loc)
+
+	cursor, err := eventsCursor(ctx, db, []string{types.EventTypeDepositAdd, types.EventTypeDepositSubtract}, excludeAddrs)
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[string]map[time.Time]*DepositEventStatistics)
+		d = make(map[string]map[time.Time]*DepositEventStatistics)
+		w = make(map[string]map[time.Time]*DepositEventStatistics)
+		m = make(map[string]map[time.Time]*DepositEventStatistics)
+		y = make(map[string]map[time.Time]*DepositEventStatistics)
+	)
+
+	bucket := func(v map[string]map[time.Time]*DepositEventStatistics, accAddr string, timeframe string, t time.Time) *DepositEventStatistics {
+		if _, ok := v[accAddr]; !ok {
+			v[accAddr] = make(map[time.Time]*DepositEventStatistics)
+		}
+		if _, ok := v[accAddr][t]; !ok {
+			v[accAddr][t] = NewDepositEventStatistics(timeframe)
+		}
+
+		return v[accAddr][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item models.Event
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		timestamp := item.Timestamp.In(loc)
+
+		var items []*DepositEventStatistics
+		if hourTimestamp := utils.HourDate(timestamp); !hourTimestamp.Before(minHourTimestamp) {
+			items = append(items, bucket(h, item.AccAddr, "hour", hourTimestamp))
+		}
+
+		items = append(
+			items,
+			bucket(d, item.AccAddr, "day", utils.DayDate(timestamp)),
+			bucket(w, item.AccAddr, "week", utils.ISOWeekDate(timestamp)),
+			bucket(m, item.AccAddr, "month", utils.MonthDate(timestamp)),
+			bucket(y, item.AccAddr, "year", utils.YearDate(timestamp)),
+		)
+
+		for _, v := range items {
+			switch item.Type {
+			case types.EventTypeDepositAdd:
+				v.DepositAdd = v.DepositAdd.Add(item.Coins...)
+			case types.EventTypeDepositSubtract:
+				v.DepositSubtract = v.DepositSubtract.Add(item.Coins...)
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[string]map[time.Time]*DepositEventStatistics{h, d, w, m, y} {
+		for s := range v {
+			for t := range v[s] {
+				if err := database.SendStatistics(ctx, out, loc, v[s][t].Result(s, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	SessionStatistics struct {
+		Timeframe        string
+		ActiveSession    int64
+		EndSession       int64
+		SessionBandwidth *types.Bandwidth
+		SessionDuration  int64
+		SessionNode      map[string]bool
+		SessionPayment   types.Coins
+		StartSession     int64
+	}
+)
+
+func NewSessionStatistics(timeframe string) *SessionStatistics {
+	return &SessionStatistics{
+		Timeframe:        timeframe,
+		SessionBandwidth: &types.Bandwidth{},
+		SessionNode:      make(map[string]bool),
+		SessionPayment:   types.NewCoins(nil),
+	}
+}
+
+func (s *SessionStatistics) Result(accAddr string, timestamp time.Time) bson.M {
+	res := bson.M{
+		"acc_addr":  accAddr,
+		"timeframe": s.Timeframe,
+		"timestamp": timestamp,
+	}
+
+	if s.ActiveSession != 0 {
+		res["active_session"] = s.ActiveSession
+	}
+	if s.EndSession != 0 {
+		res["end_session"] = s.EndSession
+	}
+	if !s.SessionBandwidth.IsZero() {
+		res["session_bandwidth"] = s.SessionBandwidth
+	}
+	if s.SessionDuration != 0 {
+		res["session_duration"] = s.SessionDuration
+	}
+	if len(s.SessionNode) != 0 {
+		res["session_node"] = len(s.SessionNode)
+	}
+	if s.SessionPayment.Len() != 0 {
+		res["session_payment"] = s.SessionPayment
+	}
+	if s.StartSession != 0 {
+		res["start_session"] = s.StartSession
+	}
+
+	return res
+}
+
+func StatisticsFromSessions(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSessions", minTimestamp, maxTimestamp, minHourTimestamp, loc)
+
+	filter := bson.M{
+		"acc_addr": bson.M{
+			"$nin": excludeAddrs,
+		},
+	}
+	projection := bson.M{
+		"_id":             0,
+		"acc_addr":        1,
+		"bandwidth":       1,
+		"duration":        1,
+		"end_timestamp":   1,
+		"node_addr":       1,
+		"payment":         1,
+		"start_timestamp": 1,
+	}
+
+	cursor, err := database.SessionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[string]map[time.Time]*SessionStatistics)
+		d = make(map[string]map[time.Time]*SessionStatistics)
+		w = make(map[string]map[time.Time]*SessionStatistics)
+		m = make(map[string]map[time.Time]*SessionStatistics)
+		y = make(map[string]map[time.Time]*SessionStatistics)
+	)
+
+	bucket := func(v map[string]map[time.Time]*SessionStatistics, accAddr string, timeframe string, t time.Time) *SessionStatistics {
+		if _, ok := v[accAddr]; !ok {
+			v[accAddr] = make(map[time.Time]*SessionStatistics)
+		}
+		if _, ok := v[accAddr][t]; !ok {
+			v[accAddr][t] = NewSessionStatistics(timeframe)
+		}
+
+		return v[accAddr][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item models.Session
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
+		}
+
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
+		}
+
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
+		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
+		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
+		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
+
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
+		}
+
+		accAddr := item.AccAddr
+
+		for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+			v := bucket(h, accAddr, "hour", t)
+			v.ActiveSession += 1
+			v.SessionNode[item.NodeAddr] = true
+		}
+
+		for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
+			v := bucket(d, accAddr, "day", t)
+			v.ActiveSession += 1
+			v.SessionNode[item.NodeAddr] = true
+		}
+
+		for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
+			v := bucket(w, accAddr, "week", t)
+			v.ActiveSession += 1
+			v.SessionNode[item.NodeAddr] = true
+		}
+
+		for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
+			v := bucket(m, accAddr, "month", t)
+			v.ActiveSession += 1
+			v.SessionNode[item.NodeAddr] = true
+		}
+
+		for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
+			v := bucket(y, accAddr, "year", t)
+			v.ActiveSession += 1
+			v.SessionNode[item.NodeAddr] = true
+		}
+
+		if !item.EndTimestamp.IsZero() {
+			if !hourEndTimestamp.Before(minHourTimestamp) {
+				bucket(h, accAddr, "hour", hourEndTimestamp).EndSession += 1
+			}
+
+			bucket(d, accAddr, "day", dayEndTimestamp).EndSession += 1
+			bucket(w, accAddr, "week", weekEndTimestamp).EndSession += 1
+			bucket(m, accAddr, "month", monthEndTimestamp).EndSession += 1
+			bucket(y, accAddr, "year", yearEndTimestamp).EndSession += 1
+
+			if item.Duration != 0 {
+				if !hourEndTimestamp.Before(minHourTimestamp) {
+					bucket(h, accAddr, "hour", hourEndTimestamp).SessionDuration += item.Duration
+				}
+
+				bucket(d, accAddr, "day", dayEndTimestamp).SessionDuration += item.Duration
+				bucket(w, accAddr, "week", weekEndTimestamp).SessionDuration += item.Duration
+				bucket(m, accAddr, "month", monthEndTimestamp).SessionDuration += item.Duration
+				bucket(y, accAddr, "year", yearEndTimestamp).SessionDuration += item.Duration
+			}
+			if item.Payment != nil {
+				if !hourEndTimestamp.Before(minHourTimestamp) {
+					v := bucket(h, accAddr, "hour", hourEndTimestamp)
+					v.SessionPayment = v.SessionPayment.Add(item.Payment)
+				}
+
+				for _, v := range []*SessionStatistics{
+					bucket(d, accAddr, "day", dayEndTimestamp),
+					bucket(w, accAddr, "week", weekEndTimestamp),
+					bucket(m, accAddr, "month", monthEndTimestamp),
+					bucket(y, accAddr, "year", yearEndTimestamp),
+				} {
+					v.SessionPayment = v.SessionPayment.Add(item.Payment)
+				}
+			}
+			if item.Bandwidth != nil {
+				if !hourEndTimestamp.Before(minHourTimestamp) {
+					bucket(h, accAddr, "hour", hourEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+				}
+
+				bucket(d, accAddr, "day", dayEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+				bucket(w, accAddr, "week", weekEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+				bucket(m, accAddr, "month", monthEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+				bucket(y, accAddr, "year", yearEndTimestamp).SessionBandwidth.Add(item.Bandwidth)
+			}
+		}
+		if !item.StartTimestamp.IsZero() {
+			if !hourStartTimestamp.Before(minHourTimestamp) {
+				bucket(h, accAddr, "hour", hourStartTimestamp).StartSession += 1
+			}
+
+			bucket(d, accAddr, "day", dayStartTimestamp).StartSession += 1
+			bucket(w, accAddr, "week", weekStartTimestamp).StartSession += 1
+			bucket(m, accAddr, "month", monthStartTimestamp).StartSession += 1
+			bucket(y, accAddr, "year", yearStartTimestamp).StartSession += 1
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[string]map[time.Time]*SessionStatistics{h, d, w, m, y} {
+		for o := range v {
+			for t := range v[o] {
+				if err := database.SendStatistics(ctx, out, loc, v[o][t].Result(o, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/types"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+type (
+	SubscriptionStatistics struct {
+		Timeframe           string
+		ActiveSubscription  int64
+		EndSubscription     int64
+		StartSubscription   int64
+		SubscriptionDeposit types.Coins
+		SubscriptionPayment types.Coins
+	}
+)
+
+func NewSubscriptionStatistics(timeframe string) *SubscriptionStatistics {
+	return &SubscriptionStatistics{
+		Timeframe:           timeframe,
+		SubscriptionDeposit: types.NewCoins(nil),
+		SubscriptionPayment: types.NewCoins(nil),
+	}
+}
+
+func (s *SubscriptionStatistics) Result(accAddr string, timestamp time.Time) bson.M {
+	res := bson.M{
+		"acc_addr":  accAddr,
+		"timeframe": s.Timeframe,
+		"timestamp": timestamp,
+	}
+
+	if s.ActiveSubscription != 0 {
+		res["active_subscription"] = s.ActiveSubscription
+	}
+	if s.EndSubscription != 0 {
+		res["end_subscription"] = s.EndSubscription
+	}
+	if s.StartSubscription != 0 {
+		res["start_subscription"] = s.StartSubscription
+	}
+	if s.SubscriptionDeposit.Len() != 0 {
+		res["subscription_deposit"] = s.SubscriptionDeposit
+	}
+	if s.SubscriptionPayment.Len() != 0 {
+		res["subscription_payment"] = s.SubscriptionPayment
+	}
+
+	return res
+}
+
+func StatisticsFromSubscriptions(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp, minHourTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromSubscriptions", minTimestamp, maxTimestamp, minHourTimestamp, loc)
+
+	filter := bson.M{
+		"acc_addr": bson.M{
+			"$nin": excludeAddrs,
+		},
+	}
+	projection := bson.M{
+		"_id":             0,
+		"acc_addr":        1,
+		"deposit":         1,
+		"end_timestamp":   1,
+		"payment":         1,
+		"start_timestamp": 1,
+	}
+
+	cursor, err := database.SubscriptionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		h = make(map[string]map[time.Time]*SubscriptionStatistics)
+		d = make(map[string]map[time.Time]*SubscriptionStatistics)
+		w = make(map[string]map[time.Time]*SubscriptionStatistics)
+		m = make(map[string]map[time.Time]*SubscriptionStatistics)
+		y = make(map[string]map[time.Time]*SubscriptionStatistics)
+	)
+
+	bucket := func(v map[string]map[time.Time]*SubscriptionStatistics, accAddr string, timeframe string, t time.Time) *SubscriptionStatistics {
+		if _, ok := v[accAddr]; !ok {
+			v[accAddr] = make(map[time.Time]*SubscriptionStatistics)
+		}
+		if _, ok := v[accAddr][t]; !ok {
+			v[accAddr][t] = NewSubscriptionStatistics(timeframe)
+		}
+
+		return v[accAddr][t]
+	}
+
+	for cursor.Next(ctx) {
+		var item models.Subscription
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		startTimestamp := item.StartTimestamp.In(loc)
+		if item.StartTimestamp.IsZero() {
+			startTimestamp = minTimestamp.In(loc)
+		}
+
+		endTimestamp := item.EndTimestamp.In(loc)
+		if item.EndTimestamp.IsZero() {
+			endTimestamp = maxTimestamp.In(loc)
+		}
+
+		hourStartTimestamp, hourEndTimestamp := utils.HourDate(startTimestamp), utils.HourDate(endTimestamp)
+		dayStartTimestamp, dayEndTimestamp := utils.DayDate(startTimestamp), utils.DayDate(endTimestamp)
+		weekStartTimestamp, weekEndTimestamp := utils.ISOWeekDate(startTimestamp), utils.ISOWeekDate(endTimestamp)
+		monthStartTimestamp, monthEndTimestamp := utils.MonthDate(startTimestamp), utils.MonthDate(endTimestamp)
+		yearStartTimestamp, yearEndTimestamp := utils.YearDate(startTimestamp), utils.YearDate(endTimestamp)
+
+		hourFromTimestamp := hourStartTimestamp
+		if hourFromTimestamp.Before(minHourTimestamp) {
+			hourFromTimestamp = minHourTimestamp
+		}
+
+		accAddr := item.AccAddr
+
+		if item.Payment != nil {
+			if !hourStartTimestamp.Before(minHourTimestamp) {
+				v := bucket(h, accAddr, "hour", hourStartTimestamp)
+				v.SubscriptionPayment = v.SubscriptionPayment.Add(item.Payment)
+			}
+
+			for _, v := range []*SubscriptionStatistics{
+				bucket(d, accAddr, "day", dayStartTimestamp),
+				bucket(w, accAddr, "week", weekStartTimestamp),
+				bucket(m, accAddr, "month", monthStartTimestamp),
+				bucket(y, accAddr, "year", yearStartTimestamp),
+			} {
+				v.SubscriptionPayment = v.SubscriptionPayment.Add(item.Payment)
+			}
+		}
+		if item.Deposit != nil {
+			if !hourStartTimestamp.Before(minHourTimestamp) {
+				v := bucket(h, accAddr, "hour", hourStartTimestamp)
+				v.SubscriptionDeposit = v.SubscriptionDeposit.Add(item.Deposit)
+			}
+
+			for _, v := range []*SubscriptionStatistics{
+				bucket(d, accAddr, "day", dayStartTimestamp),
+				bucket(w, accAddr, "week", weekStartTimestamp),
+				bucket(m, accAddr, "month", monthStartTimestamp),
+				bucket(y, accAddr, "year", yearStartTimestamp),
+			} {
+				v.SubscriptionDeposit = v.SubscriptionDeposit.Add(item.Deposit)
+			}
+		}
+
+		for t := hourFromTimestamp; !t.After(hourEndTimestamp); t = t.Add(time.Hour) {
+			bucket(h, accAddr, "hour", t).ActiveSubscription += 1
+		}
+
+		for t := dayStartTimestamp; !t.After(dayEndTimestamp); t = t.AddDate(0, 0, 1) {
+			bucket(d, accAddr, "day", t).ActiveSubscription += 1
+		}
+
+		for t := weekStartTimestamp; !t.After(weekEndTimestamp); t = t.AddDate(0, 0, 7) {
+			bucket(w, accAddr, "week", t).ActiveSubscription += 1
+		}
+
+		for t := monthStartTimestamp; !t.After(monthEndTimestamp); t = t.AddDate(0, 1, 0) {
+			bucket(m, accAddr, "month", t).ActiveSubscription += 1
+		}
+
+		for t := yearStartTimestamp; !t.After(yearEndTimestamp); t = t.AddDate(1, 0, 0) {
+			bucket(y, accAddr, "year", t).ActiveSubscription += 1
+		}
+
+		if !item.EndTimestamp.IsZero() {
+			if !hourEndTimestamp.Before(minHourTimestamp) {
+				bucket(h, accAddr, "hour", hourEndTimestamp).EndSubscription += 1
+			}
+
+			bucket(d, accAddr, "day", dayEndTimestamp).EndSubscription += 1
+			bucket(w, accAddr, "week", weekEndTimestamp).EndSubscription += 1
+			bucket(m, accAddr, "month", monthEndTimestamp).EndSubscription += 1
+			bucket(y, accAddr, "year", yearEndTimestamp).EndSubscription += 1
+		}
+		if !item.StartTimestamp.IsZero() {
+			if !hourStartTimestamp.Before(minHourTimestamp) {
+				bucket(h, accAddr, "hour", hourStartTimestamp).StartSubscription += 1
+			}
+
+			bucket(d, accAddr, "day", dayStartTimestamp).StartSubscription += 1
+			bucket(w, accAddr, "week", weekStartTimestamp).StartSubscription += 1
+			bucket(m, accAddr, "month", monthStartTimestamp).StartSubscription += 1
+			bucket(y, accAddr, "year", yearStartTimestamp).StartSubscription += 1
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, v := range []map[string]map[time.Time]*SubscriptionStatistics{h, d, w, m, y} {
+		for o := range v {
+			for t := range v[o] {
+				if err := database.SendStatistics(ctx, out, loc, v[o][t].Result(o, t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+var (
+	cohortTimeframes = []string{"day", "week", "month", "year"}
+)
+
+type (
+	Activity struct {
+		FirstTimestamp map[string]time.Time
+		Buckets        map[string]map[string]map[time.Time]bool
+	}
+)
+
+func NewActivity() *Activity {
+	a := &Activity{
+		FirstTimestamp: make(map[string]time.Time),
+		Buckets:        make(map[string]map[string]map[time.Time]bool),
+	}
+
+	for _, timeframe := range cohortTimeframes {
+		a.Buckets[timeframe] = make(map[string]map[time.Time]bool)
+	}
+
+	return a
+}
+
+func (a *Activity) Add(accAddr string, startTimestamp, endTimestamp time.Time) {
+	if v, ok := a.FirstTimestamp[accAddr]; !ok || startTimestamp.Before(v) {
+		a.FirstTimestamp[accAddr] = startTimestamp
+	}
+
+	for _, timeframe := range cohortTimeframes {
+		if _, ok := a.Buckets[timeframe][accAddr]; !ok {
+			a.Buckets[timeframe][accAddr] = make(map[time.Time]bool)
+		}
+
+		for t := utils.TimeframeDate(timeframe, startTimestamp); !t.After(endTimestamp); t = utils.TimeframeAddDate(timeframe, t, 1) {
+			a.Buckets[timeframe][accAddr][t] = true
+		}
+	}
+}
+
+func (a *Activity) Result(timeframe string) []bson.M {
+	counts := make(map[time.Time]map[time.Time]int64)
+	for accAddr, buckets := range a.Buckets[timeframe] {
+		cohort := utils.TimeframeDate(timeframe, a.FirstTimestamp[accAddr])
+		if _, ok := counts[cohort]; !ok {
+			counts[cohort] = make(map[time.Time]int64)
+		}
+
+		for t := range buckets {
+			counts[cohort][t] += 1
+		}
+	}
+
+	var res []bson.M
+	for cohort := range counts {
+		size := counts[cohort][cohort]
+		for period, t := 0, cohort; len(counts[cohort]) != 0; period, t = period+1, utils.TimeframeAddDate(timeframe, t, 1) {
+			active, ok := counts[cohort][t]
+			if !ok {
+				continue
+			}
+
+			delete(counts[cohort], t)
+			res = append(res, bson.M{
+				"active":    active,
+				"cohort":    cohort,
+				"period":    period,
+				"size":      size,
+				"timeframe": timeframe,
+				"timestamp": t,
+			})
+		}
+	}
+
+	return res
+}
+
+func activityFromSessions(ctx context.Context, db *mongo.Database, activity *Activity, maxTimestamp time.Time, excludeAddrs []string, loc *time.Location) error {
+	filter := bson.M{
+		"acc_addr": bson.M{
+			"$nin": excludeAddrs,
+		},
+	}
+	projection := bson.M{
+		"_id":             0,
+		"acc_addr":        1,
+		"end_timestamp":   1,
+		"start_timestamp": 1,
+	}
+
+	cursor, err := database.SessionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var item models.Session
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+		if item.StartTimestamp.IsZero() {
+			continue
+		}
+
+		endTimestamp := item.EndTimestamp
+		if endTimestamp.IsZero() {
+			endTimestamp = maxTimestamp
+		}
+
+		activity.Add(item.AccAddr, item.StartTimestamp.In(loc), endTimestamp.In(loc))
+	}
+
+	return cursor.Err()
+}
+
+func activityFromSubscriptions(ctx context.Context, db *mongo.Database, activity *Activity, excludeAddrs []string, loc *time.Location) error {
+	filter := bson.M{
+		"acc_addr": bson.M{
+			"$nin": excludeAddrs,
+		},
+	}
+	projection := bson.M{
+		"_id":             0,
+		"acc_addr":        1,
+		"start_timestamp": 1,
+	}
+
+	cursor, err := database.SubscriptionFindCursor(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var item models.Subscription
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+		if item.StartTimestamp.IsZero() {
+			continue
+		}
+
+		activity.Add(item.AccAddr, item.StartTimestamp.In(loc), item.StartTimestamp.In(loc))
+	}
+
+	return cursor.Err()
+}
+
+func StatisticsFromCohorts(ctx context.Context, db *mongo.Database, maxTimestamp time.Time, excludeAddrs []string, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromCohorts", maxTimestamp, loc)
+
+	activity := NewActivity()
+	if err := activityFromSessions(ctx, db, activity, maxTimestamp, excludeAddrs, loc); err != nil {
+		return err
+	}
+	if err := activityFromSubscriptions(ctx, db, activity, excludeAddrs, loc); err != nil {
+		return err
+	}
+
+	for _, timeframe := range cohortTimeframes {
+		if err := database.SendStatistics(ctx, out, loc, activity.Result(timeframe)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+const (
+	appName = "11_cohort-statistics"
+)
+
+var (
+	batchSize    int
+	dbAddress    string
+	dbName       string
+	dbUsername   string
+	dbPassword   string
+	excludeAddrs string
+	timezones    string
+)
+
+func init() {
+	log.SetFlags(0)
+
+	flag.IntVar(&batchSize, "batch-size", 25_000, "")
+	flag.StringVar(&dbAddress, "db-address", "mongodb://127.0.0.1:27017", "")
+	flag.StringVar(&dbName, "db-name", "sentinelhub-2", "")
+	flag.StringVar(&dbUsername, "db-username", "", "")
+	flag.StringVar(&dbPassword, "db-password", "", "")
+	flag.StringVar(&excludeAddrs, "exclude-addrs", "sent1c4nvz43tlw6d0c9nfu6r957y5d9pgjk5czl3n3", "")
+	flag.StringVar(&timezones, "timezones", "", "")
+	flag.Parse()
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "type", Value: 1},
+				bson.E{Key: "timestamp", Value: -1},
+			},
+		},
+	}
+
+	_, err := database.EventIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "id", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true),
+		},
+	}
+
+	_, err = database.SessionIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "timeframe", Value: 1},
+				bson.E{Key: "tz", Value: 1},
+				bson.E{Key: "cohort", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "timeframe", Value: 1},
+				bson.E{Key: "tz", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+		},
+	}
+
+	_, err = database.CohortStatisticIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func main() {
+	db, err := utils.PrepareDatabase(context.TODO(), appName, dbUsername, dbPassword, dbAddress, dbName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := db.Client().Ping(context.TODO(), nil); err != nil {
+		log.Fatalln(err)
+	}
+
+	now := time.Now()
+	if err := createIndexes(context.TODO(), db); err != nil {
+		log.Fatalln(err)
+	}
+
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":    0,
+		"height": 1,
+		"time":   1,
+	}
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "height", Value: -1},
+		}).
+		SetLimit(1)
+
+	dBlocks, err := database.BlockFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	maxTimestamp := time.Now().UTC()
+	if len(dBlocks) > 0 {
+		maxTimestamp = dBlocks[0].Time
+	}
+
+	excludeAddrs := strings.Split(excludeAddrs, ",")
+	sort.Strings(excludeAddrs)
+
+	locations, err := utils.ParseLocations(timezones)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	var (
+		out    = make(chan bson.M, batchSize)
+		writer = errgroup.Group{}
+	)
+
+	writer.Go(func() error {
+		defer cancel()
+
+		return database.WriteStatistics(ctx, db, out, batchSize, func(item bson.M) (string, mongo.WriteModel) {
+			return database.CohortStatisticCollectionName, database.NewStatisticUpsertModel(item, "cohort")
+		})
+	})
+
+	produce := func(loc *time.Location) error {
+		return StatisticsFromCohorts(ctx, db, maxTimestamp, excludeAddrs, loc, out)
+	}
+
+	var producerErr error
+	for _, loc := range locations {
+		if producerErr = produce(loc); producerErr != nil {
+			cancel()
+			break
+		}
+	}
+
+	close(out)
+
+	if err := writer.Wait(); err != nil {
+		log.Fatalln(err)
+	}
+	if producerErr != nil {
+		log.Fatalln(producerErr)
+	}
+
+	log.Println("Duration", time.Since(now))
+	log.Println("")
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
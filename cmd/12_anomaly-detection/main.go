@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+const (
+	appName = "12_anomaly-detection"
+)
+
+var (
+	baselineDays    int
+	batchSize       int
+	dbAddress       string
+	dbName          string
+	dbUsername      string
+	dbPassword      string
+	denom           string
+	lookbackDays    int
+	minBaselineDays int
+	minChange       float64
+	threshold       float64
+)
+
+func init() {
+	log.SetFlags(0)
+
+	flag.IntVar(&baselineDays, "baseline-days", 28, "")
+	flag.IntVar(&batchSize, "batch-size", 25_000, "")
+	flag.StringVar(&dbAddress, "db-address", "mongodb://127.0.0.1:27017", "")
+	flag.StringVar(&dbName, "db-name", "sentinelhub-2", "")
+	flag.StringVar(&dbUsername, "db-username", "", "")
+	flag.StringVar(&dbPassword, "db-password", "", "")
+	flag.StringVar(&denom, "denom", "udvpn", "")
+	flag.IntVar(&lookbackDays, "lookback-days", 7, "")
+	flag.IntVar(&minBaselineDays, "min-baseline-days", 14, "")
+	flag.Float64Var(&minChange, "min-change", 0.5, "")
+	flag.Float64Var(&threshold, "threshold", 3, "")
+	flag.Parse()
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "timeframe", Value: 1},
+				bson.E{Key: "tz", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+		},
+	}
+
+	_, err := database.NodeStatisticIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "source", Value: 1},
+				bson.E{Key: "type", Value: 1},
+				bson.E{Key: "addr", Value: 1},
+				bson.E{Key: "timeframe", Value: 1},
+				bson.E{Key: "timestamp", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "addr", Value: 1},
+				bson.E{Key: "timestamp", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "timestamp", Value: -1},
+			},
+		},
+	}
+
+	_, err = database.AnomalyIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func main() {
+	db, err := utils.PrepareDatabase(context.TODO(), appName, dbUsername, dbPassword, dbAddress, dbName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := db.Client().Ping(context.TODO(), nil); err != nil {
+		log.Fatalln(err)
+	}
+
+	now := time.Now()
+	if err := createIndexes(context.TODO(), db); err != nil {
+		log.Fatalln(err)
+	}
+
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":    0,
+		"height": 1,
+		"time":   1,
+	}
+	opts := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "height", Value: -1},
+		}).
+		SetLimit(1)
+
+	dBlocks, err := database.BlockFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	maxTimestamp := time.Now().UTC()
+	if len(dBlocks) > 0 {
+		maxTimestamp = dBlocks[0].Time
+	}
+
+	maxTimestamp = utils.DayDate(maxTimestamp.UTC())
+	minTimestamp := maxTimestamp.AddDate(0, 0, -lookbackDays)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	var (
+		out    = make(chan bson.M, batchSize)
+		writer = errgroup.Group{}
+	)
+
+	writer.Go(func() error {
+		defer cancel()
+
+		return database.WriteStatistics(ctx, db, out, batchSize, func(item bson.M) (string, mongo.WriteModel) {
+			filter := bson.M{
+				"addr":      item["addr"],
+				"source":    item["source"],
+				"timeframe": item["timeframe"],
+				"timestamp": item["timestamp"],
+				"type":      item["type"],
+			}
+
+			detectedAt := item["detected_at"]
+
+			delete(item, "addr")
+			delete(item, "detected_at")
+			delete(item, "source")
+			delete(item, "timeframe")
+			delete(item, "timestamp")
+			delete(item, "type")
+
+			update := bson.M{
+				"$set": item,
+				"$setOnInsert": bson.M{
+					"detected_at": detectedAt,
+				},
+			}
+
+			return database.AnomalyCollectionName, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(update).
+				SetUpsert(true)
+		})
+	})
+
+	producerErr := func() error {
+		if err := AnomaliesFromStatistics(ctx, db, minTimestamp, maxTimestamp, now, out); err != nil {
+			return err
+		}
+		if err := AnomaliesFromNodeStatistics(ctx, db, minTimestamp, maxTimestamp, now, out); err != nil {
+			return err
+		}
+
+		return nil
+	}()
+	if producerErr != nil {
+		cancel()
+	}
+
+	close(out)
+
+	if err := writer.Wait(); err != nil {
+		log.Fatalln(err)
+	}
+	if producerErr != nil {
+		log.Fatalln(producerErr)
+	}
+
+	log.Println("Duration", time.Since(now))
+	log.Println("")
+}
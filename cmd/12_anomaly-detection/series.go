@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/sentinel-official/explorer/types"
+)
+
+const (
+	AnomalySourceNodeStatistic = "node_statistics"
+	AnomalySourceStatistic     = "statistics"
+
+	AnomalyDirectionDrop  = "drop"
+	AnomalyDirectionSpike = "spike"
+
+	AnomalySeverityHigh   = "high"
+	AnomalySeverityLow    = "low"
+	AnomalySeverityMedium = "medium"
+)
+
+type Series struct {
+	Source string
+	Type   string
+	Addr   string
+	Values map[time.Time]float64
+}
+
+func NewSeries(source, key, addr string) *Series {
+	return &Series{
+		Source: source,
+		Type:   key,
+		Addr:   addr,
+		Values: make(map[time.Time]float64),
+	}
+}
+
+func (s *Series) Detect(minTimestamp, maxTimestamp, detectedAt time.Time) (items []bson.M) {
+	for t := minTimestamp; t.Before(maxTimestamp); t = t.AddDate(0, 0, 1) {
+		var (
+			days  int
+			sum   float64
+			value = s.Values[t]
+		)
+
+		values := make([]float64, 0, baselineDays)
+		for i := baselineDays; i > 0; i-- {
+			v := s.Values[t.AddDate(0, 0, -i)]
+			if v != 0 {
+				days++
+			}
+
+			sum = sum + v
+			values = append(values, v)
+		}
+
+		if days < minBaselineDays {
+			continue
+		}
+
+		mean := sum / float64(len(values))
+
+		var variance float64
+		for _, v := range values {
+			variance = variance + (v-mean)*(v-mean)
+		}
+
+		stddev := math.Sqrt(variance / float64(len(values)))
+
+		deviation := math.Max(stddev, mean*0.05)
+		if deviation == 0 {
+			continue
+		}
+
+		score := (value - mean) / deviation
+		change := (value - mean) / mean
+		if math.Abs(score) < threshold || math.Abs(change) < minChange {
+			continue
+		}
+
+		direction := AnomalyDirectionSpike
+		if score < 0 {
+			direction = AnomalyDirectionDrop
+		}
+
+		severity := AnomalySeverityLow
+		switch {
+		case math.Abs(score) >= 3*threshold, value == 0 && days == baselineDays:
+			severity = AnomalySeverityHigh
+		case math.Abs(score) >= 2*threshold:
+			severity = AnomalySeverityMedium
+		}
+
+		item := bson.M{
+			"baseline": bson.M{
+				"days":   days,
+				"mean":   mean,
+				"stddev": stddev,
+			},
+			"change":      change,
+			"detected_at": detectedAt,
+			"direction":   direction,
+			"score":       score,
+			"severity":    severity,
+			"source":      s.Source,
+			"timeframe":   "day",
+			"timestamp":   t,
+			"type":        s.Type,
+			"value":       value,
+		}
+		if s.Addr != "" {
+			item["addr"] = s.Addr
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func valueFromInterface(kind string, v interface{}) float64 {
+	switch kind {
+	case types.StatisticValueKindAmount:
+		return floatFromString(v)
+	case types.StatisticValueKindBandwidth:
+		bandwidth, _ := v.(bson.M)
+		return floatFromString(bandwidth["download"]) + floatFromString(bandwidth["upload"])
+	case types.StatisticValueKindCoins:
+		coins, _ := v.(bson.A)
+		for _, coin := range coins {
+			coin, ok := coin.(bson.M)
+			if ok && coin["denom"] == denom {
+				return floatFromString(coin["amount"])
+			}
+		}
+
+		return 0
+	case types.StatisticValueKindNumber:
+		switch v := v.(type) {
+		case int32:
+			return float64(v)
+		case int64:
+			return float64(v)
+		case float64:
+			return v
+		}
+
+		return 0
+	default:
+		return 0
+	}
+}
+
+func floatFromString(v interface{}) float64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+
+	return f
+}
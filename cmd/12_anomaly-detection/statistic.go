@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+)
+
+var (
+	nodeStatisticKinds = map[string]string{
+		"active_session":      types.StatisticValueKindNumber,
+		"active_subscription": types.StatisticValueKindNumber,
+		"bytes_earning":       types.StatisticValueKindCoins,
+		"hours_earning":       types.StatisticValueKindCoins,
+		"session_address":     types.StatisticValueKindNumber,
+		"session_bandwidth":   types.StatisticValueKindBandwidth,
+	}
+)
+
+func statisticTypes() (items []string) {
+	for key, kind := range types.StatisticTypeValueKinds {
+		if kind == types.StatisticValueKindHistogram || kind == types.StatisticValueKindHistograms {
+			continue
+		}
+
+		items = append(items, key)
+	}
+
+	return items
+}
+
+func AnomaliesFromStatistics(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp, detectedAt time.Time, out chan<- bson.M) error {
+	log.Println("AnomaliesFromStatistics", minTimestamp, maxTimestamp)
+
+	filter := bson.M{
+		"timeframe": "day",
+		"timestamp": bson.M{
+			"$gte": minTimestamp.AddDate(0, 0, -baselineDays),
+			"$lt":  maxTimestamp,
+		},
+		"type": bson.M{
+			"$in": statisticTypes(),
+		},
+		"tz": types.TimezoneFilter(""),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"timestamp": 1,
+		"type":      1,
+		"value":     1,
+	}
+	opts := options.Find().
+		SetProjection(projection)
+
+	items, err := database.StatisticFind(ctx, db, filter, opts)
+	if err != nil {
+		return err
+	}
+
+	series := make(map[string]*Series)
+	for _, item := range items {
+		key := types.StringFromInterface(item["type"])
+		timestamp := types.TimeFromInterface(item["timestamp"])
+
+		if _, ok := series[key]; !ok {
+			series[key] = NewSeries(AnomalySourceStatistic, key, "")
+		}
+
+		series[key].Values[timestamp] = valueFromInterface(types.StatisticTypeValueKinds[key], item["value"])
+	}
+
+	for _, s := range series {
+		if err := database.SendStatistics(ctx, out, time.UTC, s.Detect(minTimestamp, maxTimestamp, detectedAt)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func AnomaliesFromNodeStatistics(ctx context.Context, db *mongo.Database, minTimestamp, maxTimestamp, detectedAt time.Time, out chan<- bson.M) error {
+	log.Println("AnomaliesFromNodeStatistics", minTimestamp, maxTimestamp)
+
+	filter := bson.M{
+		"timeframe": "day",
+		"timestamp": bson.M{
+			"$gte": minTimestamp.AddDate(0, 0, -baselineDays),
+			"$lt":  maxTimestamp,
+		},
+		"tz": types.TimezoneFilter(""),
+	}
+	projection := bson.M{
+		"_id":       0,
+		"addr":      1,
+		"timestamp": 1,
+	}
+	for key := range nodeStatisticKinds {
+		projection[key] = 1
+	}
+
+	opts := options.Find().
+		SetAllowDiskUse(true).
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "addr", Value: 1},
+		})
+
+	cursor, err := database.NodeStatisticFindCursor(ctx, db, filter, opts)
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	var (
+		addr   string
+		series = make(map[string]*Series)
+	)
+
+	flush := func() error {
+		for _, s := range series {
+			if err := database.SendStatistics(ctx, out, time.UTC, s.Detect(minTimestamp, maxTimestamp, detectedAt)...); err != nil {
+				return err
+			}
+		}
+
+		series = make(map[string]*Series)
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var item bson.M
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		nodeAddr := types.StringFromInterface(item["addr"])
+		timestamp := types.TimeFromInterface(item["timestamp"])
+
+		if nodeAddr != addr {
+			if err := flush(); err != nil {
+				return err
+			}
+
+			addr = nodeAddr
+		}
+
+		for key, kind := range nodeStatisticKinds {
+			if _, ok := series[key]; !ok {
+				series[key] = NewSeries(AnomalySourceNodeStatistic, key, nodeAddr)
+			}
+
+			series[key].Values[timestamp] = valueFromInterface(kind, item[key])
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return flush()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+type Item struct {
+	Addr    string
+	Cluster *nodetypes.Cluster
+	Funder  bool
+}
+
+func newClusterID(addr string) string {
+	sum := sha256.Sum256([]byte(addr))
+	return hex.EncodeToString(sum[:8])
+}
+
+func Clusterize(items []*Item, signals []string, maxFunderNodes int, now time.Time) int {
+	funded := make(map[string]int)
+	for _, item := range items {
+		if item.Funder && item.Cluster.Operator != "" {
+			funded[item.Cluster.Operator]++
+		}
+	}
+
+	for _, item := range items {
+		if item.Funder && maxFunderNodes > 0 && funded[item.Cluster.Operator] > maxFunderNodes {
+			item.Cluster.Operator = ""
+		}
+	}
+
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+
+		return parent[i]
+	}
+
+	for _, signal := range signals {
+		first := make(map[string]int)
+		for i, item := range items {
+			value := item.Cluster.Signals()[signal]
+			if value == "" {
+				continue
+			}
+
+			j, ok := first[value]
+			if !ok {
+				first[value] = i
+				continue
+			}
+
+			if a, b := find(i), find(j); a != b {
+				parent[a] = b
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range items {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	for _, group := range groups {
+		addr := items[group[0]].Addr
+		for _, i := range group {
+			if items[i].Addr < addr {
+				addr = items[i].Addr
+			}
+		}
+
+		id := newClusterID(addr)
+		for _, i := range group {
+			items[i].Cluster.ID = id
+			items[i].Cluster.Size = len(group)
+			items[i].Cluster.Timestamp = now
+		}
+	}
+
+	return len(groups)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+var (
+	testSignals = []string{
+		nodetypes.ClusterSignalFingerprint,
+		nodetypes.ClusterSignalHostname,
+		nodetypes.ClusterSignalIP,
+		nodetypes.ClusterSignalOperator,
+		nodetypes.ClusterSignalSubnet,
+	}
+	testNow = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestItems(n int, operator string, funder bool) []*Item {
+	items := make([]*Item, n)
+	for i := 0; i < n; i++ {
+		items[i] = &Item{
+			Addr: fmt.Sprintf("sentnode%s%02d", operator, i),
+			Cluster: &nodetypes.Cluster{
+				IP:       fmt.Sprintf("10.%d.0.1", i),
+				Operator: operator,
+				Subnet:   fmt.Sprintf("10.%d.0.0/24", i),
+			},
+			Funder: funder,
+		}
+	}
+
+	return items
+}
+
+func TestClusterize(t *testing.T) {
+	tests := []struct {
+		name           string
+		items          []*Item
+		maxFunderNodes int
+		clusters       int
+		operator       bool
+	}{
+		{
+			name:           "exchange funder",
+			items:          newTestItems(12, "exchange", true),
+			maxFunderNodes: 10,
+			clusters:       12,
+			operator:       false,
+		},
+		{
+			name:           "small funder",
+			items:          newTestItems(3, "operator", true),
+			maxFunderNodes: 10,
+			clusters:       1,
+			operator:       true,
+		},
+		{
+			name:           "funder at limit",
+			items:          newTestItems(10, "operator", true),
+			maxFunderNodes: 10,
+			clusters:       1,
+			operator:       true,
+		},
+		{
+			name:           "granter",
+			items:          newTestItems(12, "granter", false),
+			maxFunderNodes: 10,
+			clusters:       1,
+			operator:       true,
+		},
+		{
+			name:           "no limit",
+			items:          newTestItems(12, "exchange", true),
+			maxFunderNodes: 0,
+			clusters:       1,
+			operator:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := Clusterize(tt.items, testSignals, tt.maxFunderNodes, testNow)
+			if clusters != tt.clusters {
+				t.Fatalf("clusters %d, want %d", clusters, tt.clusters)
+			}
+
+			for _, item := range tt.items {
+				if (item.Cluster.Operator != "") != tt.operator {
+					t.Errorf("%s operator %q, want operator kept %t", item.Addr, item.Cluster.Operator, tt.operator)
+				}
+				if item.Cluster.Size != len(tt.items)/tt.clusters {
+					t.Errorf("%s size %d, want %d", item.Addr, item.Cluster.Size, len(tt.items)/tt.clusters)
+				}
+				if !item.Cluster.Timestamp.Equal(testNow) {
+					t.Errorf("%s timestamp %s, want %s", item.Addr, item.Cluster.Timestamp, testNow)
+				}
+			}
+		})
+	}
+}
+
+func TestClusterizeExchangeFunderKeepsOtherSignals(t *testing.T) {
+	items := newTestItems(12, "exchange", true)
+	items[1].Cluster.IP = items[0].Cluster.IP
+	items[2].Cluster.Fingerprint = "fingerprint"
+	items[1].Cluster.Fingerprint = "fingerprint"
+
+	items = append(items, &Item{
+		Addr: "sentnodeoperator00",
+		Cluster: &nodetypes.Cluster{
+			Hostname: "node.example.com",
+			Operator: "operator",
+		},
+		Funder: true,
+	}, &Item{
+		Addr: "sentnodeoperator01",
+		Cluster: &nodetypes.Cluster{
+			Hostname: "node.example.com",
+		},
+	})
+
+	if clusters := Clusterize(items, testSignals, 10, testNow); clusters != 11 {
+		t.Fatalf("clusters %d, want 11", clusters)
+	}
+
+	for _, i := range []int{1, 2} {
+		if items[i].Cluster.ID != items[0].Cluster.ID {
+			t.Errorf("%s cluster %s, want %s", items[i].Addr, items[i].Cluster.ID, items[0].Cluster.ID)
+		}
+	}
+	if items[0].Cluster.ID != newClusterID(items[0].Addr) || items[0].Cluster.Size != 3 {
+		t.Errorf("cluster %s size %d, want %s size 3", items[0].Cluster.ID, items[0].Cluster.Size, newClusterID(items[0].Addr))
+	}
+	if items[3].Cluster.ID == items[0].Cluster.ID {
+		t.Errorf("%s joined the exchange funded cluster", items[3].Addr)
+	}
+	if items[12].Cluster.ID != items[13].Cluster.ID || items[12].Cluster.Operator != "operator" {
+		t.Errorf("operator nodes split or lost their operator")
+	}
+}
+
+func TestClusterizeSharedSubnet(t *testing.T) {
+	signals, err := parseSignals(defaultSignals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var items []*Item
+	for _, operator := range []string{"alpha", "beta", "gamma"} {
+		for _, item := range newTestItems(2, operator, false) {
+			item.Cluster.IP = fmt.Sprintf("10.0.0.%d", len(items)+1)
+			item.Cluster.Subnet = "10.0.0.0/24"
+			items = append(items, item)
+		}
+	}
+
+	if clusters := Clusterize(items, signals, 10, testNow); clusters != 3 {
+		t.Fatalf("clusters %d, want 3", clusters)
+	}
+	for _, item := range items {
+		if item.Cluster.Size != 2 {
+			t.Errorf("%s size %d, want 2", item.Addr, item.Cluster.Size)
+		}
+	}
+
+	if clusters := Clusterize(items, testSignals, 10, testNow); clusters != 1 {
+		t.Fatalf("clusters %d with the subnet signal, want 1", clusters)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sentinel-official/explorer/database"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+const (
+	appName = "13_node-clustering"
+
+	// defaultSignals leaves out the subnet signal: unrelated operators share
+	// hosting /24s, and the transitive closure would chain them together with
+	// everything they touch through the other signals.
+	defaultSignals = "fingerprint,hostname,ip,operator"
+)
+
+var (
+	batchSize      int
+	concurrency    int
+	dbAddress      string
+	dbName         string
+	dbUsername     string
+	dbPassword     string
+	maxFunderNodes int
+	resolveTimeout time.Duration
+	signals        string
+)
+
+func init() {
+	log.SetFlags(0)
+
+	flag.IntVar(&batchSize, "batch-size", 1_000, "")
+	flag.IntVar(&concurrency, "concurrency", 32, "")
+	flag.StringVar(&dbAddress, "db-address", "mongodb://127.0.0.1:27017", "")
+	flag.StringVar(&dbName, "db-name", "sentinelhub-2", "")
+	flag.StringVar(&dbUsername, "db-username", "", "")
+	flag.StringVar(&dbPassword, "db-password", "", "")
+	flag.IntVar(&maxFunderNodes, "max-funder-nodes", 10, "")
+	flag.DurationVar(&resolveTimeout, "resolve-timeout", 5*time.Second, "")
+	flag.StringVar(&signals, "signals", defaultSignals, "")
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "cluster.id", Value: 1},
+			},
+		},
+	}
+
+	_, err := database.NodeIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	indexes = []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "messages.data.to_address", Value: 1},
+			},
+		},
+	}
+
+	_, err = database.TxIndexesCreateMany(ctx, db, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func parseSignals(s string) ([]string, error) {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		switch item {
+		case "":
+			continue
+		case nodetypes.ClusterSignalFingerprint,
+			nodetypes.ClusterSignalHostname,
+			nodetypes.ClusterSignalIP,
+			nodetypes.ClusterSignalOperator,
+			nodetypes.ClusterSignalSubnet:
+			items = append(items, item)
+		default:
+			return nil, fmt.Errorf("unknown signal %s", item)
+		}
+	}
+
+	return items, nil
+}
+
+func main() {
+	flag.Parse()
+
+	clusterSignals, err := parseSignals(signals)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	db, err := utils.PrepareDatabase(context.TODO(), appName, dbUsername, dbPassword, dbAddress, dbName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := db.Client().Ping(context.TODO(), nil); err != nil {
+		log.Fatalln(err)
+	}
+
+	now := time.Now()
+	if err := createIndexes(context.TODO(), db); err != nil {
+		log.Fatalln(err)
+	}
+
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":                     0,
+		"addr":                    1,
+		"certificate.fingerprint": 1,
+		"register_tx_hash":        1,
+		"remote_url":              1,
+		"verified_location.ip":    1,
+	}
+	opts := options.Find().
+		SetProjection(projection)
+
+	dNodes, err := database.NodeFind(context.TODO(), db, filter, opts)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var (
+		items = make([]*Item, len(dNodes))
+		group = errgroup.Group{}
+	)
+
+	group.SetLimit(concurrency)
+
+	for i := 0; i < len(dNodes); i++ {
+		i := i
+
+		group.Go(func() error {
+			item, err := nodeSignals(context.TODO(), db, dNodes[i])
+			if err != nil {
+				return err
+			}
+
+			items[i] = item
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		log.Fatalln(err)
+	}
+
+	clusters := Clusterize(items, clusterSignals, maxFunderNodes, now.UTC())
+	log.Println("Nodes", len(items), "clusters", clusters)
+
+	models := make([]mongo.WriteModel, 0, batchSize)
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+
+		opts := options.BulkWrite().
+			SetBypassDocumentValidation(false).
+			SetOrdered(false)
+
+		if _, err := database.NodeBulkWrite(context.TODO(), db, models, opts); err != nil {
+			return err
+		}
+
+		models = models[:0]
+		return nil
+	}
+
+	for _, item := range items {
+		models = append(
+			models,
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"addr": item.Addr}).
+				SetUpdate(bson.M{"$set": bson.M{"cluster": item.Cluster}}),
+		)
+
+		if len(models) >= batchSize {
+			if err := flush(); err != nil {
+				log.Fatalln(err)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Println("Duration", time.Since(now))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	hubtypes "github.com/sentinel-official/hub/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/models"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+)
+
+func resolveIP(ctx context.Context, host string) net.IP {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP
+		}
+	}
+	if len(addrs) > 0 {
+		return addrs[0].IP
+	}
+
+	return nil
+}
+
+func findOperator(ctx context.Context, db *mongo.Database, node *models.Node) (operator string, funder bool, err error) {
+	if node.RegisterTxHash != "" {
+		filter := bson.M{
+			"hash": node.RegisterTxHash,
+		}
+		projection := bson.M{
+			"_id":     0,
+			"granter": 1,
+		}
+
+		dTx, err := database.TxFindOne(ctx, db, filter, options.FindOne().SetProjection(projection))
+		if err != nil {
+			return "", false, err
+		}
+		if dTx != nil && dTx.Granter != "" {
+			return dTx.Granter, false, nil
+		}
+	}
+
+	nodeAddr, err := hubtypes.NodeAddressFromBech32(node.Addr)
+	if err != nil {
+		return "", false, err
+	}
+
+	accAddr, err := bech32.ConvertAndEncode(hubtypes.Bech32PrefixAccAddr, nodeAddr.Bytes())
+	if err != nil {
+		return "", false, err
+	}
+
+	filter := bson.M{
+		"messages": bson.M{
+			"$elemMatch": bson.M{
+				"type":            "/cosmos.bank.v1beta1.MsgSend",
+				"data.to_address": accAddr,
+			},
+		},
+		"result.code": 0,
+	}
+	projection := bson.M{
+		"_id":      0,
+		"messages": 1,
+	}
+	opts := options.FindOne().
+		SetProjection(projection).
+		SetSort(bson.D{
+			bson.E{Key: "height", Value: 1},
+			bson.E{Key: "index", Value: 1},
+		})
+
+	dTx, err := database.TxFindOne(ctx, db, filter, opts)
+	if err != nil {
+		return "", false, err
+	}
+	if dTx == nil {
+		return "", false, nil
+	}
+
+	for _, msg := range dTx.Messages {
+		if msg.Type == "/cosmos.bank.v1beta1.MsgSend" && msg.Data["to_address"] == accAddr {
+			from, _ := msg.Data["from_address"].(string)
+			return from, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func nodeSignals(ctx context.Context, db *mongo.Database, node *models.Node) (*Item, error) {
+	item := &Item{
+		Addr:    node.Addr,
+		Cluster: &nodetypes.Cluster{},
+	}
+
+	var ip net.IP
+	if u, err := url.Parse(node.RemoteURL); err == nil && u.Hostname() != "" {
+		host := strings.ToLower(u.Hostname())
+		if ip = net.ParseIP(host); ip == nil {
+			item.Cluster.Hostname = host
+			if node.VerifiedLocation != nil && node.VerifiedLocation.IP != "" {
+				ip = net.ParseIP(node.VerifiedLocation.IP)
+			} else {
+				ip = resolveIP(ctx, host)
+			}
+		}
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		item.Cluster.IP = ip4.String()
+		item.Cluster.Subnet = ip4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	} else if ip != nil {
+		item.Cluster.IP = ip.String()
+		item.Cluster.Subnet = ip.Mask(net.CIDRMask(48, 128)).String() + "/48"
+	}
+
+	if node.Certificate != nil {
+		item.Cluster.Fingerprint = node.Certificate.Fingerprint
+	}
+
+	operator, funder, err := findOperator(ctx, db, node)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Cluster.Operator = operator
+	item.Funder = funder
+
+	return item, nil
+}
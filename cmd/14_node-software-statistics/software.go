@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	"github.com/sentinel-official/explorer/types"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+var (
+	// softwareTimeframes has no year: health check history is trimmed after
+	// the retention window, so a year bucket would never be complete. Buckets
+	// that start before the oldest sample are no longer refreshed and keep
+	// the numbers from their last run, which are complete as long as the
+	// retention exceeds a month.
+	softwareTimeframes = []string{"day", "week", "month"}
+)
+
+type (
+	Sample struct {
+		Timestamp time.Time
+		Type      uint64
+		Version   string
+	}
+	Adoption struct {
+		FirstTimestamp time.Time
+		Buckets        map[string]map[time.Time]map[string]*Sample
+	}
+)
+
+func NewAdoption() *Adoption {
+	a := &Adoption{
+		Buckets: make(map[string]map[time.Time]map[string]*Sample),
+	}
+
+	for _, timeframe := range softwareTimeframes {
+		a.Buckets[timeframe] = make(map[time.Time]map[string]*Sample)
+	}
+
+	return a
+}
+
+func (a *Adoption) Add(nodeAddr string, sample *Sample) {
+	if a.FirstTimestamp.IsZero() || sample.Timestamp.Before(a.FirstTimestamp) {
+		a.FirstTimestamp = sample.Timestamp
+	}
+
+	for _, timeframe := range softwareTimeframes {
+		t := utils.TimeframeDate(timeframe, sample.Timestamp)
+		if _, ok := a.Buckets[timeframe][t]; !ok {
+			a.Buckets[timeframe][t] = make(map[string]*Sample)
+		}
+
+		if v, ok := a.Buckets[timeframe][t][nodeAddr]; !ok || !sample.Timestamp.Before(v.Timestamp) {
+			a.Buckets[timeframe][t][nodeAddr] = sample
+		}
+	}
+}
+
+// From returns the start of the oldest bucket that Result emits.
+func (a *Adoption) From(timeframe string, truncated bool) time.Time {
+	if !truncated {
+		return time.Time{}
+	}
+
+	t := utils.TimeframeDate(timeframe, a.FirstTimestamp)
+	if t.Before(a.FirstTimestamp) {
+		t = utils.TimeframeAddDate(timeframe, t, 1)
+	}
+
+	return t
+}
+
+func (a *Adoption) Result(timeframe string, truncated bool) []bson.M {
+	var res []bson.M
+	for t, nodes := range a.Buckets[timeframe] {
+		if truncated && t.Before(a.FirstTimestamp) {
+			continue
+		}
+
+		counts := map[string]map[string]int64{
+			"type":    make(map[string]int64),
+			"version": make(map[string]int64),
+		}
+		for _, sample := range nodes {
+			counts["type"][nodetypes.TypeString(sample.Type)] += 1
+			counts["version"][sample.Version] += 1
+		}
+
+		total := float64(len(nodes))
+		for group, items := range counts {
+			for key, count := range items {
+				res = append(res, bson.M{
+					"group":      group,
+					"key":        key,
+					"node_count": count,
+					"share":      float64(count) / total,
+					"timeframe":  timeframe,
+					"timestamp":  t,
+				})
+			}
+		}
+	}
+
+	return res
+}
+
+func nodeTypes(ctx context.Context, db *mongo.Database) (map[string]uint64, error) {
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":  0,
+		"addr": 1,
+		"type": 1,
+	}
+
+	dNodes, err := database.NodeFind(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]uint64)
+	for _, dNode := range dNodes {
+		m[dNode.Addr] = dNode.Type
+	}
+
+	return m, nil
+}
+
+func StatisticsFromNodeHealthChecks(ctx context.Context, db *mongo.Database, maxTimestamp time.Time, historyRetention time.Duration, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromNodeHealthChecks", maxTimestamp, loc)
+
+	nodeTypeByAddr, err := nodeTypes(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"success": true,
+				"version": bson.M{
+					"$nin": bson.A{nil, ""},
+				},
+			},
+		},
+		{
+			"$sort": bson.M{
+				"timestamp": 1,
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"addr": "$addr",
+					"timestamp": bson.M{
+						"$dateFromParts": bson.M{
+							"timezone": loc.String(),
+							"day":      bson.M{"$dayOfMonth": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+							"month":    bson.M{"$month": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+							"year":     bson.M{"$year": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+						},
+					},
+				},
+				"type":    bson.M{"$last": "$type"},
+				"version": bson.M{"$last": "$version"},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":       0,
+				"addr":      "$_id.addr",
+				"timestamp": "$_id.timestamp",
+				"type":      1,
+				"version":   1,
+			},
+		},
+	}
+
+	cursor, err := database.NodeHealthCheckAggregate(ctx, db, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	adoption := NewAdoption()
+	for cursor.Next(ctx) {
+		var item bson.M
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		nodeAddr := types.StringFromInterface(item["addr"])
+		sample := &Sample{
+			Timestamp: types.TimeFromInterface(item["timestamp"]).In(loc),
+			Type:      nodeTypeByAddr[nodeAddr],
+			Version:   types.StringFromInterface(item["version"]),
+		}
+		if v, ok := item["type"]; ok && v != nil {
+			sample.Type = uint64(types.Int64FromInterface(v))
+		}
+
+		adoption.Add(nodeAddr, sample)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if adoption.FirstTimestamp.IsZero() {
+		return nil
+	}
+
+	// Emitted buckets replace their previous documents as a whole, so keys
+	// that no longer occur in a bucket do not keep their old counts.
+	truncated := utils.HistoryTruncated(adoption.FirstTimestamp, maxTimestamp, historyRetention)
+	for _, timeframe := range softwareTimeframes {
+		filter := bson.M{
+			"timeframe": timeframe,
+			"timestamp": bson.M{
+				"$gte": adoption.From(timeframe, truncated),
+			},
+			"tz": types.TimezoneFilter(loc.String()),
+		}
+
+		if err := database.NodeSoftwareStatisticDeleteMany(ctx, db, filter); err != nil {
+			return err
+		}
+	}
+
+	for _, timeframe := range softwareTimeframes {
+		if err := database.SendStatistics(ctx, out, loc, adoption.Result(timeframe, truncated)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
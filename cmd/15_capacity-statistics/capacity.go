@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/database"
+	nodetypes "github.com/sentinel-official/explorer/types/node"
+	"github.com/sentinel-official/explorer/utils"
+)
+
+var (
+	capacityTimeframes = []string{"hour", "day", "week", "month", "year"}
+)
+
+type (
+	Usage struct {
+		MaxPeersSamples int64
+		MaxPeersSum     float64
+		PeersSum        float64
+		Samples         int64
+	}
+	Capacity struct {
+		FirstTimestamp time.Time
+		Buckets        map[string]map[time.Time]map[string]*Usage
+	}
+)
+
+func (u *Usage) Peers() float64 {
+	if u.Samples == 0 {
+		return 0
+	}
+
+	return u.PeersSum / float64(u.Samples)
+}
+
+func (u *Usage) MaxPeers() float64 {
+	if u.MaxPeersSamples == 0 {
+		return 0
+	}
+
+	return u.MaxPeersSum / float64(u.MaxPeersSamples)
+}
+
+func (u *Usage) Utilization() (float64, bool) {
+	maxPeers := u.MaxPeers()
+	if maxPeers == 0 {
+		return 0, false
+	}
+
+	return u.Peers() / maxPeers, true
+}
+
+func NewCapacity() *Capacity {
+	c := &Capacity{
+		Buckets: make(map[string]map[time.Time]map[string]*Usage),
+	}
+
+	for _, timeframe := range capacityTimeframes {
+		c.Buckets[timeframe] = make(map[time.Time]map[string]*Usage)
+	}
+
+	return c
+}
+
+func (c *Capacity) Add(nodeAddr string, timestamp time.Time, minHourTimestamp time.Time, usage *Usage) {
+	if c.FirstTimestamp.IsZero() || timestamp.Before(c.FirstTimestamp) {
+		c.FirstTimestamp = timestamp
+	}
+
+	for _, timeframe := range capacityTimeframes {
+		if timeframe == "hour" && timestamp.Before(minHourTimestamp) {
+			continue
+		}
+
+		t := utils.TimeframeDate(timeframe, timestamp)
+		if _, ok := c.Buckets[timeframe][t]; !ok {
+			c.Buckets[timeframe][t] = make(map[string]*Usage)
+		}
+
+		v, ok := c.Buckets[timeframe][t][nodeAddr]
+		if !ok {
+			v = &Usage{}
+			c.Buckets[timeframe][t][nodeAddr] = v
+		}
+
+		v.MaxPeersSamples = v.MaxPeersSamples + usage.MaxPeersSamples
+		v.MaxPeersSum = v.MaxPeersSum + usage.MaxPeersSum
+		v.PeersSum = v.PeersSum + usage.PeersSum
+		v.Samples = v.Samples + usage.Samples
+	}
+}
+
+func (c *Capacity) Result(timeframe string, countries map[string]string, truncated bool) []bson.M {
+	type aggregate struct {
+		idle, nodes, saturated      int64
+		knownPeers, maxPeers, peers float64
+	}
+
+	var res []bson.M
+	for t, nodes := range c.Buckets[timeframe] {
+		if truncated && timeframe != "hour" && t.Before(c.FirstTimestamp) {
+			continue
+		}
+
+		var (
+			network = &aggregate{}
+			regions = make(map[string]*aggregate)
+		)
+
+		for nodeAddr, usage := range nodes {
+			item := bson.M{
+				"key":       nodeAddr,
+				"max_peers": usage.MaxPeers(),
+				"peers":     usage.Peers(),
+				"scope":     "node",
+				"timeframe": timeframe,
+				"timestamp": t,
+			}
+
+			utilization, ok := usage.Utilization()
+			if ok {
+				item["utilization"] = utilization
+			}
+
+			res = append(res, item)
+
+			country := countries[nodeAddr]
+			if _, ok := regions[country]; !ok {
+				regions[country] = &aggregate{}
+			}
+
+			for _, v := range []*aggregate{network, regions[country]} {
+				v.nodes = v.nodes + 1
+				v.peers = v.peers + usage.Peers()
+				if ok {
+					v.knownPeers = v.knownPeers + usage.Peers()
+					v.maxPeers = v.maxPeers + usage.MaxPeers()
+				}
+				if usage.Peers() == 0 {
+					v.idle = v.idle + 1
+				}
+				if ok && utilization >= saturation {
+					v.saturated = v.saturated + 1
+				}
+			}
+		}
+
+		newItem := func(scope, key string, v *aggregate) bson.M {
+			item := bson.M{
+				"idle_count":      v.idle,
+				"key":             key,
+				"max_peers":       v.maxPeers,
+				"node_count":      v.nodes,
+				"peers":           v.peers,
+				"saturated_count": v.saturated,
+				"scope":           scope,
+				"timeframe":       timeframe,
+				"timestamp":       t,
+			}
+			if v.maxPeers > 0 {
+				item["utilization"] = v.knownPeers / v.maxPeers
+			}
+
+			return item
+		}
+
+		res = append(res, newItem("network", "", network))
+		for country, v := range regions {
+			if country == "" {
+				continue
+			}
+
+			res = append(res, newItem("country", country, v))
+		}
+	}
+
+	return res
+}
+
+func nodeCountries(ctx context.Context, db *mongo.Database) (map[string]string, error) {
+	filter := bson.M{}
+	projection := bson.M{
+		"_id":                            0,
+		"addr":                           1,
+		"location.country":               1,
+		"verified_location.country_code": 1,
+	}
+
+	dNodes, err := database.NodeFind(ctx, db, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string)
+	for _, dNode := range dNodes {
+		country := nodetypes.CountryCode(dNode.Location.Country)
+		if dNode.VerifiedLocation != nil && dNode.VerifiedLocation.CountryCode != "" {
+			country = nodetypes.CountryCode(dNode.VerifiedLocation.CountryCode)
+		}
+
+		m[dNode.Addr] = country
+	}
+
+	return m, nil
+}
+
+func StatisticsFromNodeHealthChecks(ctx context.Context, db *mongo.Database, maxTimestamp, minHourTimestamp time.Time, historyRetention time.Duration, loc *time.Location, out chan<- bson.M) error {
+	log.Println("StatisticsFromNodeHealthChecks", maxTimestamp, minHourTimestamp, loc)
+
+	countries, err := nodeCountries(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"success": true,
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"addr": "$addr",
+					"timestamp": bson.M{
+						"$dateFromParts": bson.M{
+							"timezone": loc.String(),
+							"hour":     bson.M{"$hour": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+							"day":      bson.M{"$dayOfMonth": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+							"month":    bson.M{"$month": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+							"year":     bson.M{"$year": bson.M{"date": "$timestamp", "timezone": loc.String()}},
+						},
+					},
+				},
+				"max_peers_samples": bson.M{
+					"$sum": bson.M{
+						"$cond": bson.A{bson.M{"$gt": bson.A{"$max_peers", 0}}, 1, 0},
+					},
+				},
+				"max_peers_sum": bson.M{
+					"$sum": bson.M{
+						"$cond": bson.A{bson.M{"$gt": bson.A{"$max_peers", 0}}, "$max_peers", 0},
+					},
+				},
+				"peers_sum": bson.M{"$sum": "$peers"},
+				"samples":   bson.M{"$sum": 1},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":               0,
+				"addr":              "$_id.addr",
+				"max_peers_samples": 1,
+				"max_peers_sum":     1,
+				"peers_sum":         1,
+				"samples":           1,
+				"timestamp":         "$_id.timestamp",
+			},
+		},
+	}
+
+	cursor, err := database.NodeHealthCheckAggregate(ctx, db, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	capacity := NewCapacity()
+	for cursor.Next(ctx) {
+		var item struct {
+			Addr            string    `bson:"addr"`
+			MaxPeersSamples int64     `bson:"max_peers_samples"`
+			MaxPeersSum     float64   `bson:"max_peers_sum"`
+			PeersSum        float64   `bson:"peers_sum"`
+			Samples         int64     `bson:"samples"`
+			Timestamp       time.Time `bson:"timestamp"`
+		}
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+
+		usage := &Usage{
+			MaxPeersSamples: item.MaxPeersSamples,
+			MaxPeersSum:     item.MaxPeersSum,
+			PeersSum:        item.PeersSum,
+			Samples:         item.Samples,
+		}
+
+		capacity.Add(item.Addr, item.Timestamp.In(loc), minHourTimestamp, usage)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	truncated := utils.HistoryTruncated(capacity.FirstTimestamp, maxTimestamp, historyRetention)
+	for _, timeframe := range capacityTimeframes {
+		if err := database.SendStatistics(ctx, out, loc, capacity.Result(timeframe, countries, truncated)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AccountStatisticCollectionName = "account_statistics"
+)
+
+func AccountStatisticFind(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.FindOptions) ([]bson.M, error) {
+	var v []bson.M
+	if err := Find(ctx, db.Collection(AccountStatisticCollectionName), filter, &v, opts...); err != nil {
+		return nil, findError(err)
+	}
+
+	return v, nil
+}
+
+func AccountStatisticIndexesCreateMany(ctx context.Context, db *mongo.Database, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	return IndexesCreateMany(ctx, db.Collection(AccountStatisticCollectionName), models, opts...)
+}
+
+func AccountStatisticAggregateAll(ctx context.Context, db *mongo.Database, pipeline []bson.M, opts ...*options.AggregateOptions) ([]bson.M, error) {
+	var v []bson.M
+	if err := AggregateAll(ctx, db.Collection(AccountStatisticCollectionName), pipeline, &v, opts...); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func AccountStatisticBulkWrite(ctx context.Context, db *mongo.Database, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	return BulkWrite(ctx, db.Collection(AccountStatisticCollectionName), models, opts...)
+}
+
+func AccountStatisticDeleteMany(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.DeleteOptions) error {
+	_, err := DeleteMany(ctx, db.Collection(AccountStatisticCollectionName), filter, opts...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
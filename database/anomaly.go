@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AnomalyCollectionName = "anomalies"
+)
+
+func AnomalyFind(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.FindOptions) ([]bson.M, error) {
+	var v []bson.M
+	if err := Find(ctx, db.Collection(AnomalyCollectionName), filter, &v, opts...); err != nil {
+		return nil, findError(err)
+	}
+
+	return v, nil
+}
+
+func AnomalyIndexesCreateMany(ctx context.Context, db *mongo.Database, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	return IndexesCreateMany(ctx, db.Collection(AnomalyCollectionName), models, opts...)
+}
+
+func AnomalyAggregateAll(ctx context.Context, db *mongo.Database, pipeline []bson.M, opts ...*options.AggregateOptions) ([]bson.M, error) {
+	var v []bson.M
+	if err := AggregateAll(ctx, db.Collection(AnomalyCollectionName), pipeline, &v, opts...); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func AnomalyBulkWrite(ctx context.Context, db *mongo.Database, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	return BulkWrite(ctx, db.Collection(AnomalyCollectionName), models, opts...)
+}
+
+func AnomalyCountDocuments(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.CountOptions) (int64, error) {
+	return CountDocuments(ctx, db.Collection(AnomalyCollectionName), filter, opts...)
+}
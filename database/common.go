@@ -186,6 +186,111 @@ func IndexesCreateMany(ctx context.Context, c *mongo.Collection, models []mongo.
 	return c.Indexes().CreateMany(ctx, models, opts...)
 }
 
+func IndexesDropOne(ctx context.Context, c *mongo.Collection, name string, opts ...*options.DropIndexesOptions) error {
+	now := time.Now()
+	defer func() {
+		log.Println(c.Name(), "IndexesDropOne", time.Since(now))
+	}()
+
+	_, err := c.Indexes().DropOne(ctx, name, opts...)
+	if err != nil {
+		var cErr mongo.CommandError
+		if errors.As(err, &cErr) && (cErr.Code == 26 || cErr.Code == 27) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// IndexesSetExpireAfterSeconds updates the TTL of the index with the given
+// keys in place. It is a no-op when the collection or the index is missing.
+func IndexesSetExpireAfterSeconds(ctx context.Context, c *mongo.Collection, keys bson.D, seconds int32) error {
+	now := time.Now()
+	defer func() {
+		log.Println(c.Name(), "IndexesSetExpireAfterSeconds", time.Since(now))
+	}()
+
+	cmd := bson.D{
+		bson.E{Key: "collMod", Value: c.Name()},
+		bson.E{
+			Key: "index",
+			Value: bson.D{
+				bson.E{Key: "keyPattern", Value: keys},
+				bson.E{Key: "expireAfterSeconds", Value: seconds},
+			},
+		},
+	}
+
+	if err := c.Database().RunCommand(ctx, cmd).Err(); err != nil {
+		var cErr mongo.CommandError
+		if errors.As(err, &cErr) && (cErr.Code == 26 || cErr.Code == 27) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// IndexesExpireAfterSeconds returns the TTL of the index with the given keys,
+// or zero when the collection, the index or its TTL is missing.
+func IndexesExpireAfterSeconds(ctx context.Context, c *mongo.Collection, keys bson.D) (int32, error) {
+	now := time.Now()
+	defer func() {
+		log.Println(c.Name(), "IndexesExpireAfterSeconds", time.Since(now))
+	}()
+
+	specs, err := c.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		var cErr mongo.CommandError
+		if errors.As(err, &cErr) && cErr.Code == 26 {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	for _, spec := range specs {
+		if spec.ExpireAfterSeconds == nil || !indexKeysEqual(spec.KeysDocument, keys) {
+			continue
+		}
+
+		return *spec.ExpireAfterSeconds, nil
+	}
+
+	return 0, nil
+}
+
+func indexKeysEqual(doc bson.Raw, keys bson.D) bool {
+	elems, err := doc.Elements()
+	if err != nil || len(elems) != len(keys) {
+		return false
+	}
+
+	for i, elem := range elems {
+		if elem.Key() != keys[i].Key {
+			return false
+		}
+
+		t, data, err := bson.MarshalValue(keys[i].Value)
+		if err != nil {
+			return false
+		}
+
+		x, ok := elem.Value().AsInt64OK()
+		y, _ := bson.RawValue{Type: t, Value: data}.AsInt64OK()
+		if !ok || x != y {
+			return false
+		}
+	}
+
+	return true
+}
+
 func BulkWrite(ctx context.Context, c *mongo.Collection, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
 	now := time.Now()
 	defer func() {
@@ -194,3 +299,12 @@ func BulkWrite(ctx context.Context, c *mongo.Collection, models []mongo.WriteMod
 
 	return c.BulkWrite(ctx, models, opts...)
 }
+
+func FindCursor(ctx context.Context, c *mongo.Collection, filter bson.M, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	now := time.Now()
+	defer func() {
+		log.Println(c.Name(), "FindCursor", time.Since(now))
+	}()
+
+	return c.Find(ctx, filter, opts...)
+}
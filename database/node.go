@@ -52,3 +52,20 @@ func NodeIndexesCreateMany(ctx context.Context, db *mongo.Database, models []mon
 func NodeCountDocuments(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.CountOptions) (int64, error) {
 	return CountDocuments(ctx, db.Collection(NodeCollectionName), filter, opts...)
 }
+
+func NodeFindCursor(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	return FindCursor(ctx, db.Collection(NodeCollectionName), filter, opts...)
+}
+
+func NodeAggregateAll(ctx context.Context, db *mongo.Database, pipeline []bson.M, opts ...*options.AggregateOptions) ([]bson.M, error) {
+	var v []bson.M
+	if err := AggregateAll(ctx, db.Collection(NodeCollectionName), pipeline, &v, opts...); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func NodeBulkWrite(ctx context.Context, db *mongo.Database, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	return BulkWrite(ctx, db.Collection(NodeCollectionName), models, opts...)
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	NodeHealthCheckCollectionName = "node_health_checks"
+)
+
+func NodeHealthCheckFind(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.FindOptions) ([]bson.M, error) {
+	var v []bson.M
+	if err := Find(ctx, db.Collection(NodeHealthCheckCollectionName), filter, &v, opts...); err != nil {
+		return nil, findError(err)
+	}
+
+	return v, nil
+}
+
+func NodeHealthCheckIndexesCreateMany(ctx context.Context, db *mongo.Database, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	return IndexesCreateMany(ctx, db.Collection(NodeHealthCheckCollectionName), models, opts...)
+}
+
+func NodeHealthCheckIndexesSetExpireAfterSeconds(ctx context.Context, db *mongo.Database, keys bson.D, seconds int32) error {
+	return IndexesSetExpireAfterSeconds(ctx, db.Collection(NodeHealthCheckCollectionName), keys, seconds)
+}
+
+// NodeHealthCheckRetention returns how long health checks are kept by the TTL
+// index on timestamp, or zero when they are not expired.
+func NodeHealthCheckRetention(ctx context.Context, db *mongo.Database) (time.Duration, error) {
+	keys := bson.D{
+		bson.E{Key: "timestamp", Value: 1},
+	}
+
+	seconds, err := IndexesExpireAfterSeconds(ctx, db.Collection(NodeHealthCheckCollectionName), keys)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func NodeHealthCheckAggregateAll(ctx context.Context, db *mongo.Database, pipeline []bson.M, opts ...*options.AggregateOptions) ([]bson.M, error) {
+	var v []bson.M
+	if err := AggregateAll(ctx, db.Collection(NodeHealthCheckCollectionName), pipeline, &v, opts...); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func NodeHealthCheckBulkWrite(ctx context.Context, db *mongo.Database, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	return BulkWrite(ctx, db.Collection(NodeHealthCheckCollectionName), models, opts...)
+}
+
+func NodeHealthCheckInsertOne(ctx context.Context, db *mongo.Database, v bson.M, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	return InsertOne(ctx, db.Collection(NodeHealthCheckCollectionName), v, opts...)
+}
+
+func NodeHealthCheckAggregate(ctx context.Context, db *mongo.Database, pipeline []bson.M, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	return Aggregate(ctx, db.Collection(NodeHealthCheckCollectionName), pipeline, opts...)
+}
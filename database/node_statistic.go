@@ -43,6 +43,10 @@ func NodeStatisticFind(ctx context.Context, db *mongo.Database, filter bson.M, o
 	return v, nil
 }
 
+func NodeStatisticIndexesDropOne(ctx context.Context, db *mongo.Database, name string, opts ...*options.DropIndexesOptions) error {
+	return IndexesDropOne(ctx, db.Collection(NodeStatisticCollectionName), name, opts...)
+}
+
 func NodeStatisticIndexesCreateMany(ctx context.Context, db *mongo.Database, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
 	return IndexesCreateMany(ctx, db.Collection(NodeStatisticCollectionName), models, opts...)
 }
@@ -67,3 +71,16 @@ func NodeStatisticDrop(ctx context.Context, db *mongo.Database) error {
 func NodeStatisticBulkWrite(ctx context.Context, db *mongo.Database, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
 	return BulkWrite(ctx, db.Collection(NodeStatisticCollectionName), models, opts...)
 }
+
+func NodeStatisticAggregateAll(ctx context.Context, db *mongo.Database, pipeline []bson.M, opts ...*options.AggregateOptions) ([]bson.M, error) {
+	var v []bson.M
+	if err := AggregateAll(ctx, db.Collection(NodeStatisticCollectionName), pipeline, &v, opts...); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func NodeStatisticFindCursor(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	return FindCursor(ctx, db.Collection(NodeStatisticCollectionName), filter, opts...)
+}
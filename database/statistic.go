@@ -56,6 +56,10 @@ func StatisticDeleteMany(ctx context.Context, db *mongo.Database, filter bson.M,
 	return nil
 }
 
+func StatisticIndexesDropOne(ctx context.Context, db *mongo.Database, name string, opts ...*options.DropIndexesOptions) error {
+	return IndexesDropOne(ctx, db.Collection(StatisticCollectionName), name, opts...)
+}
+
 func StatisticIndexesCreateMany(ctx context.Context, db *mongo.Database, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
 	return IndexesCreateMany(ctx, db.Collection(StatisticCollectionName), models, opts...)
 }
@@ -72,3 +76,7 @@ func StatisticAggregateAll(ctx context.Context, db *mongo.Database, pipeline []b
 
 	return v, nil
 }
+
+func StatisticBulkWrite(ctx context.Context, db *mongo.Database, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	return BulkWrite(ctx, db.Collection(StatisticCollectionName), models, opts...)
+}
@@ -52,3 +52,7 @@ func SubscriptionIndexesCreateMany(ctx context.Context, db *mongo.Database, mode
 func SubscriptionCountDocuments(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.CountOptions) (int64, error) {
 	return CountDocuments(ctx, db.Collection(SubscriptionCollectionName), filter, opts...)
 }
+
+func SubscriptionFindCursor(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	return FindCursor(ctx, db.Collection(SubscriptionCollectionName), filter, opts...)
+}
@@ -48,3 +48,7 @@ func SubscriptionPayoutFind(ctx context.Context, db *mongo.Database, filter bson
 func SubscriptionPayoutIndexesCreateMany(ctx context.Context, db *mongo.Database, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
 	return IndexesCreateMany(ctx, db.Collection(SubscriptionPayoutCollectionName), models, opts...)
 }
+
+func SubscriptionPayoutFindCursor(ctx context.Context, db *mongo.Database, filter bson.M, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	return FindCursor(ctx, db.Collection(SubscriptionPayoutCollectionName), filter, opts...)
+}
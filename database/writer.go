@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/sentinel-official/explorer/types"
+)
+
+// SendStatistics forwards items to out, tagging each with the timezone of loc
+// unless it is UTC.
+func SendStatistics(ctx context.Context, out chan<- bson.M, loc *time.Location, items ...bson.M) error {
+	for _, item := range items {
+		if loc != time.UTC {
+			item["tz"] = loc.String()
+		}
+
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// NewStatisticUpsertModel matches a statistic document on keys, timeframe,
+// timestamp and tz, and sets every other field of item on it. A key missing
+// from item matches documents that do not have it.
+func NewStatisticUpsertModel(item bson.M, keys ...string) *mongo.UpdateOneModel {
+	filter := bson.M{
+		"timeframe": item["timeframe"],
+		"timestamp": item["timestamp"],
+		"tz":        types.TimezoneFilter(types.StringFromInterface(item["tz"])),
+	}
+
+	for _, key := range keys {
+		if v, ok := item[key]; ok {
+			filter[key] = v
+		} else {
+			filter[key] = bson.M{
+				"$exists": false,
+			}
+		}
+
+		delete(item, key)
+	}
+
+	delete(item, "timeframe")
+	delete(item, "timestamp")
+	delete(item, "tz")
+
+	update := bson.M{
+		"$set": item,
+	}
+
+	return mongo.NewUpdateOneModel().
+		SetFilter(filter).
+		SetUpdate(update).
+		SetUpsert(true)
+}
+
+// WriteStatistics drains in, turning each item into a write model for the
+// collection named by model, and bulk writes them in batches of batchSize.
+// Items for which model returns a nil write model are skipped.
+func WriteStatistics(ctx context.Context, db *mongo.Database, in <-chan bson.M, batchSize int, model func(item bson.M) (string, mongo.WriteModel)) error {
+	var (
+		count  = 0
+		total  = 0
+		models = make(map[string][]mongo.WriteModel)
+	)
+
+	flush := func() error {
+		opts := options.BulkWrite().
+			SetBypassDocumentValidation(false).
+			SetOrdered(false)
+
+		for name, items := range models {
+			if len(items) == 0 {
+				continue
+			}
+			if _, err := BulkWrite(ctx, db.Collection(name), items, opts); err != nil {
+				return err
+			}
+
+			models[name] = items[:0]
+		}
+
+		total, count = total+count, 0
+		return nil
+	}
+
+	for item := range in {
+		name, m := model(item)
+		if m == nil {
+			continue
+		}
+
+		models[name] = append(models[name], m)
+		if count = count + 1; count < batchSize {
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	log.Println("Models", total)
+	return nil
+}
@@ -12,9 +12,11 @@ require (
 	github.com/gin-contrib/cors v1.4.0
 	github.com/gin-gonic/gin v1.9.1
 	github.com/gogo/protobuf v1.3.3
+	github.com/oschwald/maxminddb-golang v1.12.0
 	github.com/sentinel-official/hub v0.11.4-0.20231018182245-5f5c161cb97c
 	github.com/tendermint/tendermint v0.34.27
 	go.mongodb.org/mongo-driver v1.12.1
+	golang.org/x/crypto v0.17.0
 	golang.org/x/sync v0.4.0
 	google.golang.org/grpc v1.59.0
 )
@@ -164,7 +166,6 @@ require (
 	github.com/zondax/ledger-go v0.14.1 // indirect
 	go.etcd.io/bbolt v1.3.7 // indirect
 	golang.org/x/arch v0.5.0 // indirect
-	golang.org/x/crypto v0.17.0 // indirect
 	golang.org/x/exp v0.0.0-20230131160201-f062dba9d201 // indirect
 	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
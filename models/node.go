@@ -18,17 +18,21 @@ type Node struct {
 	RegisterTimestamp time.Time `json:"register_timestamp,omitempty" bson:"register_timestamp"`
 	RegisterTxHash    string    `json:"register_tx_hash,omitempty" bson:"register_tx_hash"`
 
-	InternetSpeed          types.Bandwidth        `json:"internet_speed,omitempty" bson:"internet_speed"`
-	HandshakeDNS           nodetypes.HandshakeDNS `json:"handshake_dns,omitempty" bson:"handshake_dns"`
-	IntervalSetSessions    int64                  `json:"interval_set_sessions,omitempty" bson:"interval_set_sessions"`
-	IntervalUpdateSessions int64                  `json:"interval_update_sessions,omitempty" bson:"interval_update_sessions"`
-	IntervalUpdateStatus   int64                  `json:"interval_update_status,omitempty" bson:"interval_update_status"`
-	Location               nodetypes.Location     `json:"location,omitempty" bson:"location"`
-	Moniker                string                 `json:"moniker,omitempty" bson:"moniker"`
-	Peers                  int                    `json:"peers,omitempty" bson:"peers"`
-	QOS                    nodetypes.QOS          `json:"qos,omitempty" bson:"qos"`
-	Type                   uint64                 `json:"type,omitempty" bson:"type"`
-	Version                string                 `json:"version,omitempty" bson:"version"`
+	Certificate            *nodetypes.Certificate      `json:"certificate,omitempty" bson:"certificate,omitempty"`
+	Cluster                *nodetypes.Cluster          `json:"cluster,omitempty" bson:"cluster,omitempty"`
+	InternetSpeed          types.Bandwidth             `json:"internet_speed,omitempty" bson:"internet_speed"`
+	HandshakeDNS           nodetypes.HandshakeDNS      `json:"handshake_dns,omitempty" bson:"handshake_dns"`
+	IntervalSetSessions    int64                       `json:"interval_set_sessions,omitempty" bson:"interval_set_sessions"`
+	IntervalUpdateSessions int64                       `json:"interval_update_sessions,omitempty" bson:"interval_update_sessions"`
+	IntervalUpdateStatus   int64                       `json:"interval_update_status,omitempty" bson:"interval_update_status"`
+	Location               nodetypes.Location          `json:"location,omitempty" bson:"location"`
+	MeasuredSpeed          *nodetypes.MeasuredSpeed    `json:"measured_speed,omitempty" bson:"measured_speed,omitempty"`
+	Moniker                string                      `json:"moniker,omitempty" bson:"moniker"`
+	Peers                  int                         `json:"peers,omitempty" bson:"peers"`
+	QOS                    nodetypes.QOS               `json:"qos,omitempty" bson:"qos"`
+	Type                   uint64                      `json:"type,omitempty" bson:"type"`
+	VerifiedLocation       *nodetypes.VerifiedLocation `json:"verified_location,omitempty" bson:"verified_location,omitempty"`
+	Version                string                      `json:"version,omitempty" bson:"version"`
 
 	Status          string    `json:"status,omitempty" bson:"status"`
 	StatusHeight    int64     `json:"status_height,omitempty" bson:"status_height"`
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sentinel-official/explorer/utils"
+)
+
+var (
+	BucketTimeframes = []string{"hour", "day", "week", "month", "year"}
+)
+
+type Buckets[T any] struct {
+	closeFunc func(timeframe string, timestamp time.Time, item T) error
+	items     map[string]map[time.Time]T
+	timestamp time.Time
+}
+
+func NewBuckets[T any](closeFunc func(timeframe string, timestamp time.Time, item T) error) *Buckets[T] {
+	items := make(map[string]map[time.Time]T)
+	for _, timeframe := range BucketTimeframes {
+		items[timeframe] = make(map[time.Time]T)
+	}
+
+	return &Buckets[T]{
+		closeFunc: closeFunc,
+		items:     items,
+	}
+}
+
+func (b *Buckets[T]) Items(timeframe string) map[time.Time]T {
+	return b.items[timeframe]
+}
+
+func (b *Buckets[T]) close(timeframe string, timestamp time.Time) error {
+	items := b.items[timeframe]
+
+	var keys []time.Time
+	for t := range items {
+		if timestamp.IsZero() || t.Before(timestamp) {
+			keys = append(keys, t)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Before(keys[j])
+	})
+
+	for _, t := range keys {
+		if err := b.closeFunc(timeframe, t, items[t]); err != nil {
+			return err
+		}
+
+		delete(items, t)
+	}
+
+	return nil
+}
+
+func (b *Buckets[T]) CloseBefore(v time.Time) error {
+	timestamp := utils.HourDate(v)
+	if timestamp.Equal(b.timestamp) {
+		return nil
+	}
+
+	for _, timeframe := range BucketTimeframes {
+		if err := b.close(timeframe, utils.TimeframeDate(timeframe, v)); err != nil {
+			return err
+		}
+	}
+
+	b.timestamp = timestamp
+	return nil
+}
+
+func (b *Buckets[T]) Close() error {
+	for _, timeframe := range BucketTimeframes {
+		if err := b.close(timeframe, time.Time{}); err != nil {
+			return err
+		}
+	}
+
+	b.timestamp = time.Time{}
+	return nil
+}
@@ -290,6 +290,13 @@ func (b *Bandwidth) IsZero() bool {
 	return false
 }
 
+func (b *Bandwidth) Sum() string {
+	bu := utils.MustIntFromString(b.Upload)
+	bd := utils.MustIntFromString(b.Download)
+
+	return bu.Add(bd).String()
+}
+
 func (b *Bandwidth) Copy() *Bandwidth {
 	return &Bandwidth{
 		Upload:   b.Upload,
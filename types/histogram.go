@@ -0,0 +1,177 @@
+package types
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	HistogramBounds = func() (items []float64) {
+		mantissas := []float64{1, 1.25, 1.5, 2, 2.5, 3, 4, 5, 6, 8}
+		for e := 0; e <= 20; e++ {
+			for _, m := range mantissas {
+				items = append(items, m*math.Pow10(e))
+			}
+		}
+
+		return items
+	}()
+	HistogramPercentiles = []float64{50, 90, 99}
+)
+
+type Histogram struct {
+	Count   int64
+	Min     float64
+	Max     float64
+	Buckets map[int]int64
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{
+		Buckets: make(map[int]int64),
+	}
+}
+
+func (h *Histogram) Add(v float64) *Histogram {
+	i := sort.Search(len(HistogramBounds), func(i int) bool {
+		return v < HistogramBounds[i]
+	})
+
+	if h.Count == 0 || v < h.Min {
+		h.Min = v
+	}
+	if h.Count == 0 || v > h.Max {
+		h.Max = v
+	}
+
+	h.Count = h.Count + 1
+	h.Buckets[i] = h.Buckets[i] + 1
+
+	return h
+}
+
+func (h *Histogram) AddString(v string) *Histogram {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return h
+	}
+
+	return h.Add(f)
+}
+
+func (h *Histogram) IsZero() bool {
+	return h.Count == 0
+}
+
+func (h *Histogram) keys() []int {
+	keys := make([]int, 0, len(h.Buckets))
+	for i := range h.Buckets {
+		keys = append(keys, i)
+	}
+
+	sort.Ints(keys)
+	return keys
+}
+
+// Percentile interpolates linearly inside the bucket holding the rank and
+// clamps the result to the observed range, so discrete values such as
+// ratings never report a percentile above the largest sample.
+func (h *Histogram) Percentile(p float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return math.Min(math.Max(h.percentile(p), h.Min), h.Max)
+}
+
+func (h *Histogram) percentile(p float64) float64 {
+
+	var (
+		rank       = p / 100 * float64(h.Count)
+		cumulative = float64(0)
+	)
+
+	for _, i := range h.keys() {
+		count := float64(h.Buckets[i])
+		if cumulative+count < rank {
+			cumulative = cumulative + count
+			continue
+		}
+
+		lower := float64(0)
+		if i > 0 {
+			lower = HistogramBounds[i-1]
+		}
+		if i == len(HistogramBounds) {
+			return lower
+		}
+
+		upper := HistogramBounds[i]
+		return lower + (upper-lower)*(rank-cumulative)/count
+	}
+
+	return HistogramBounds[len(HistogramBounds)-1]
+}
+
+func (h *Histogram) Result() bson.M {
+	buckets := bson.A{}
+	for _, i := range h.keys() {
+		bucket := bson.M{
+			"count": h.Buckets[i],
+		}
+		if i > 0 {
+			bucket["gte"] = HistogramBounds[i-1]
+		}
+		if i < len(HistogramBounds) {
+			bucket["lt"] = HistogramBounds[i]
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	res := bson.M{
+		"buckets": buckets,
+		"count":   h.Count,
+		"max":     h.Max,
+		"min":     h.Min,
+	}
+
+	for _, p := range HistogramPercentiles {
+		res["p"+strconv.FormatFloat(p, 'f', -1, 64)] = h.Percentile(p)
+	}
+
+	return res
+}
+
+type Histograms map[string]*Histogram
+
+func (h Histograms) AddCoin(v *Coin) Histograms {
+	if _, ok := h[v.Denom]; !ok {
+		h[v.Denom] = NewHistogram()
+	}
+
+	h[v.Denom].AddString(v.Amount)
+	return h
+}
+
+func (h Histograms) Result() bson.A {
+	denoms := make([]string, 0, len(h))
+	for denom := range h {
+		denoms = append(denoms, denom)
+	}
+
+	sort.Strings(denoms)
+
+	res := bson.A{}
+	for _, denom := range denoms {
+		item := h[denom].Result()
+		item["denom"] = denom
+
+		res = append(res, item)
+	}
+
+	return res
+}
@@ -0,0 +1,117 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+)
+
+type (
+	CertificateChainItem struct {
+		Issuer    string    `json:"issuer,omitempty" bson:"issuer"`
+		NotAfter  time.Time `json:"not_after,omitempty" bson:"not_after"`
+		NotBefore time.Time `json:"not_before,omitempty" bson:"not_before"`
+		Subject   string    `json:"subject,omitempty" bson:"subject"`
+	}
+	Certificate struct {
+		Chain         []*CertificateChainItem `json:"chain,omitempty" bson:"chain"`
+		DNSNames      []string                `json:"dns_names,omitempty" bson:"dns_names"`
+		Fingerprint   string                  `json:"fingerprint,omitempty" bson:"fingerprint"`
+		HostnameMatch bool                    `json:"hostname_match" bson:"hostname_match"`
+		IPAddresses   []string                `json:"ip_addresses,omitempty" bson:"ip_addresses"`
+		Issuer        string                  `json:"issuer,omitempty" bson:"issuer"`
+		NotAfter      time.Time               `json:"not_after,omitempty" bson:"not_after"`
+		NotBefore     time.Time               `json:"not_before,omitempty" bson:"not_before"`
+		SelfSigned    bool                    `json:"self_signed" bson:"self_signed"`
+		Subject       string                  `json:"subject,omitempty" bson:"subject"`
+		Trusted       bool                    `json:"trusted" bson:"trusted"`
+		VerifyError   string                  `json:"verify_error,omitempty" bson:"verify_error"`
+	}
+)
+
+func NewCertificate(host string, chain []*x509.Certificate) *Certificate {
+	leaf := chain[0]
+	fingerprint := sha256.Sum256(leaf.Raw)
+
+	v := &Certificate{
+		DNSNames:    leaf.DNSNames,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		Issuer:      leaf.Issuer.String(),
+		NotAfter:    leaf.NotAfter.UTC(),
+		NotBefore:   leaf.NotBefore.UTC(),
+		Subject:     leaf.Subject.String(),
+	}
+
+	for _, ip := range leaf.IPAddresses {
+		v.IPAddresses = append(v.IPAddresses, ip.String())
+	}
+
+	intermediates := x509.NewCertPool()
+	for i, cert := range chain {
+		if i > 0 {
+			intermediates.AddCert(cert)
+		}
+
+		v.Chain = append(v.Chain, &CertificateChainItem{
+			Issuer:    cert.Issuer.String(),
+			NotAfter:  cert.NotAfter.UTC(),
+			NotBefore: cert.NotBefore.UTC(),
+			Subject:   cert.Subject.String(),
+		})
+	}
+
+	v.SelfSigned = leaf.Issuer.String() == leaf.Subject.String() && leaf.CheckSignatureFrom(leaf) == nil
+	v.HostnameMatch = leaf.VerifyHostname(host) == nil
+
+	if _, err := leaf.Verify(x509.VerifyOptions{Intermediates: intermediates}); err != nil {
+		v.VerifyError = err.Error()
+	} else {
+		v.Trusted = true
+	}
+
+	return v
+}
+
+func FetchCertificate(ctx context.Context, remoteURL string, timeout time.Duration) (*Certificate, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, errors.New("remote url scheme is not https")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{
+			Timeout: timeout,
+		},
+		Config: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, errors.New("no peer certificates")
+	}
+
+	return NewCertificate(u.Hostname(), chain), nil
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"time"
+)
+
+const (
+	ClusterSignalFingerprint = "fingerprint"
+	ClusterSignalHostname    = "hostname"
+	ClusterSignalIP          = "ip"
+	ClusterSignalOperator    = "operator"
+	ClusterSignalSubnet      = "subnet"
+)
+
+type Cluster struct {
+	Fingerprint string    `json:"fingerprint,omitempty" bson:"fingerprint"`
+	Hostname    string    `json:"hostname,omitempty" bson:"hostname"`
+	ID          string    `json:"id,omitempty" bson:"id"`
+	IP          string    `json:"ip,omitempty" bson:"ip"`
+	Operator    string    `json:"operator,omitempty" bson:"operator"`
+	Size        int       `json:"size,omitempty" bson:"size"`
+	Subnet      string    `json:"subnet,omitempty" bson:"subnet"`
+	Timestamp   time.Time `json:"timestamp,omitempty" bson:"timestamp"`
+}
+
+func (c *Cluster) Signals() map[string]string {
+	return map[string]string{
+		ClusterSignalFingerprint: c.Fingerprint,
+		ClusterSignalHostname:    c.Hostname,
+		ClusterSignalIP:          c.IP,
+		ClusterSignalOperator:    c.Operator,
+		ClusterSignalSubnet:      c.Subnet,
+	}
+}
+
+func (c *Cluster) Matches(v *Cluster) []string {
+	var (
+		items   []string
+		signals = v.Signals()
+	)
+
+	for _, key := range []string{
+		ClusterSignalFingerprint,
+		ClusterSignalHostname,
+		ClusterSignalIP,
+		ClusterSignalOperator,
+		ClusterSignalSubnet,
+	} {
+		if value := c.Signals()[key]; value != "" && value == signals[key] {
+			items = append(items, key)
+		}
+	}
+
+	return items
+}
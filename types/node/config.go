@@ -0,0 +1,117 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"golang.org/x/crypto/curve25519"
+)
+
+const (
+	TypeWireGuard = 1
+	TypeV2Ray     = 2
+)
+
+func NewConfigExchangeKey(nodeType uint64) ([]byte, error) {
+	switch nodeType {
+	case TypeWireGuard:
+		privKey := make([]byte, curve25519.ScalarSize)
+		if _, err := rand.Read(privKey); err != nil {
+			return nil, err
+		}
+
+		privKey[0] &= 248
+		privKey[31] = (privKey[31] & 127) | 64
+
+		return curve25519.X25519(privKey, curve25519.Basepoint)
+	case TypeV2Ray:
+		uid := make([]byte, 16)
+		if _, err := rand.Read(uid); err != nil {
+			return nil, err
+		}
+
+		uid[6] = (uid[6] & 0x0f) | 0x40
+		uid[8] = (uid[8] & 0x3f) | 0x80
+
+		return append([]byte{0x01}, uid...), nil
+	default:
+		return nil, fmt.Errorf("unsupported node type %d", nodeType)
+	}
+}
+
+func ExchangeConfig(ctx context.Context, remoteURL, accAddr string, sessionID uint64, key []byte, privKey cryptotypes.PrivKey, timeout time.Duration) ([]byte, error) {
+	urlPath, err := url.JoinPath(remoteURL, "accounts", accAddr, "sessions", strconv.FormatUint(sessionID, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := privKey.Sign(sdk.Uint64ToBigEndian(sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"key":       base64.StdEncoding.EncodeToString(key),
+		"signature": base64.StdEncoding.EncodeToString(signature),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+		Timeout: timeout,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, urlPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var v struct {
+		Success bool   `json:"success"`
+		Result  string `json:"result"`
+		Error   *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+	if !v.Success {
+		if v.Error != nil {
+			return nil, fmt.Errorf("config exchange failed with code %d: %s", v.Error.Code, v.Error.Message)
+		}
+
+		return nil, errors.New("config exchange failed")
+	}
+
+	return base64.StdEncoding.DecodeString(v.Result)
+}
@@ -0,0 +1,183 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	testAccAddr   = "sent1test"
+	testSessionID = 42
+)
+
+var (
+	testServerConfig = []byte("server-config")
+)
+
+type exchangeRequest struct {
+	Key       []byte
+	Signature []byte
+}
+
+func newExchangeServer(t *testing.T, privKey cryptotypes.PrivKey, nodeType uint64, respond func(w http.ResponseWriter)) (*httptest.Server, *exchangeRequest) {
+	t.Helper()
+
+	req := &exchangeRequest{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := fmt.Sprintf("/accounts/%s/sessions/%d", testAccAddr, testSessionID)
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		var body struct {
+			Key       string `json:"key"`
+			Signature string `json:"signature"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %s", err)
+		}
+
+		var err error
+		if req.Key, err = base64.StdEncoding.DecodeString(body.Key); err != nil {
+			t.Errorf("decode key: %s", err)
+		}
+		if req.Signature, err = base64.StdEncoding.DecodeString(body.Signature); err != nil {
+			t.Errorf("decode signature: %s", err)
+		}
+
+		if !privKey.PubKey().VerifySignature(sdk.Uint64ToBigEndian(testSessionID), req.Signature) {
+			t.Errorf("signature does not verify for session %d", testSessionID)
+		}
+
+		checkExchangeKey(t, nodeType, req.Key)
+		respond(w)
+	}))
+
+	t.Cleanup(server.Close)
+	return server, req
+}
+
+func checkExchangeKey(t *testing.T, nodeType uint64, key []byte) {
+	t.Helper()
+
+	switch nodeType {
+	case TypeWireGuard:
+		if len(key) != 32 {
+			t.Errorf("wireguard key length %d, want 32", len(key))
+		}
+	case TypeV2Ray:
+		if len(key) != 17 || key[0] != 0x01 {
+			t.Errorf("v2ray key %x, want 0x01 followed by a uuid", key)
+			return
+		}
+		if key[7]>>4 != 4 {
+			t.Errorf("v2ray uuid version %d, want 4", key[7]>>4)
+		}
+		if key[9]&0xc0 != 0x80 {
+			t.Errorf("v2ray uuid variant %x, want rfc 4122", key[9]&0xc0)
+		}
+	}
+}
+
+func respondSuccess(w http.ResponseWriter) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"result":  base64.StdEncoding.EncodeToString(testServerConfig),
+	})
+}
+
+func TestExchangeConfig(t *testing.T) {
+	privKey := secp256k1.GenPrivKey()
+
+	tests := []struct {
+		name     string
+		nodeType uint64
+		respond  func(w http.ResponseWriter)
+		err      string
+	}{
+		{
+			name:     "wireguard",
+			nodeType: TypeWireGuard,
+			respond:  respondSuccess,
+		},
+		{
+			name:     "v2ray",
+			nodeType: TypeV2Ray,
+			respond:  respondSuccess,
+		},
+		{
+			name:     "error response",
+			nodeType: TypeWireGuard,
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"success":false,"error":{"code":4,"message":"invalid signature"}}`))
+			},
+			err: "config exchange failed with code 4: invalid signature",
+		},
+		{
+			name:     "failure without error",
+			nodeType: TypeV2Ray,
+			respond: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte(`{"success":false}`))
+			},
+			err: "config exchange failed",
+		},
+		{
+			name:     "malformed response",
+			nodeType: TypeWireGuard,
+			respond: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte(`<html>`))
+			},
+			err: "invalid character",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, req := newExchangeServer(t, privKey, tt.nodeType, tt.respond)
+
+			key, err := NewConfigExchangeKey(tt.nodeType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := ExchangeConfig(context.Background(), server.URL, testAccAddr, testSessionID, key, privKey, time.Second)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(req.Key, key) {
+				t.Errorf("server received key %x, want %x", req.Key, key)
+			}
+			if !bytes.Equal(config, testServerConfig) {
+				t.Errorf("config %q, want %q", config, testServerConfig)
+			}
+		})
+	}
+}
+
+func TestNewConfigExchangeKeyUnsupportedType(t *testing.T) {
+	if _, err := NewConfigExchangeKey(0); err == nil {
+		t.Fatal("expected an error for an unsupported node type")
+	}
+}
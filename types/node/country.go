@@ -0,0 +1,302 @@
+package node
+
+import (
+	"regexp"
+	"strings"
+)
+
+// countryNames lists the English names and common aliases under which nodes
+// report each ISO 3166-1 alpha-2 country code.
+var countryNames = map[string][]string{
+	"AD": {"Andorra"},
+	"AE": {"United Arab Emirates", "UAE"},
+	"AF": {"Afghanistan"},
+	"AG": {"Antigua and Barbuda"},
+	"AI": {"Anguilla"},
+	"AL": {"Albania"},
+	"AM": {"Armenia"},
+	"AO": {"Angola"},
+	"AQ": {"Antarctica"},
+	"AR": {"Argentina"},
+	"AS": {"American Samoa"},
+	"AT": {"Austria"},
+	"AU": {"Australia"},
+	"AW": {"Aruba"},
+	"AX": {"Aland Islands", "Åland Islands", "Åland"},
+	"AZ": {"Azerbaijan"},
+	"BA": {"Bosnia and Herzegovina"},
+	"BB": {"Barbados"},
+	"BD": {"Bangladesh"},
+	"BE": {"Belgium"},
+	"BF": {"Burkina Faso"},
+	"BG": {"Bulgaria"},
+	"BH": {"Bahrain"},
+	"BI": {"Burundi"},
+	"BJ": {"Benin"},
+	"BL": {"Saint Barthelemy", "Saint Barthélemy"},
+	"BM": {"Bermuda"},
+	"BN": {"Brunei", "Brunei Darussalam"},
+	"BO": {"Bolivia", "Bolivia, Plurinational State of"},
+	"BQ": {"Bonaire, Sint Eustatius and Saba", "Caribbean Netherlands", "Bonaire, Sint Eustatius, and Saba"},
+	"BR": {"Brazil"},
+	"BS": {"Bahamas", "The Bahamas"},
+	"BT": {"Bhutan"},
+	"BV": {"Bouvet Island"},
+	"BW": {"Botswana"},
+	"BY": {"Belarus"},
+	"BZ": {"Belize"},
+	"CA": {"Canada"},
+	"CC": {"Cocos (Keeling) Islands", "Cocos Islands"},
+	"CD": {"Democratic Republic of the Congo", "DR Congo", "Congo, The Democratic Republic of the", "Congo (Kinshasa)"},
+	"CF": {"Central African Republic"},
+	"CG": {"Republic of the Congo", "Congo", "Congo Republic", "Congo (Brazzaville)"},
+	"CH": {"Switzerland"},
+	"CI": {"Ivory Coast", "Cote d'Ivoire", "Côte d'Ivoire"},
+	"CK": {"Cook Islands"},
+	"CL": {"Chile"},
+	"CM": {"Cameroon"},
+	"CN": {"China"},
+	"CO": {"Colombia"},
+	"CR": {"Costa Rica"},
+	"CU": {"Cuba"},
+	"CV": {"Cape Verde", "Cabo Verde"},
+	"CW": {"Curacao", "Curaçao"},
+	"CX": {"Christmas Island"},
+	"CY": {"Cyprus"},
+	"CZ": {"Czechia", "Czech Republic"},
+	"DE": {"Germany"},
+	"DJ": {"Djibouti"},
+	"DK": {"Denmark"},
+	"DM": {"Dominica"},
+	"DO": {"Dominican Republic"},
+	"DZ": {"Algeria"},
+	"EC": {"Ecuador"},
+	"EE": {"Estonia"},
+	"EG": {"Egypt"},
+	"EH": {"Western Sahara"},
+	"ER": {"Eritrea"},
+	"ES": {"Spain"},
+	"ET": {"Ethiopia"},
+	"FI": {"Finland"},
+	"FJ": {"Fiji"},
+	"FK": {"Falkland Islands", "Falkland Islands (Malvinas)"},
+	"FM": {"Micronesia", "Federated States of Micronesia", "Micronesia, Federated States of"},
+	"FO": {"Faroe Islands"},
+	"FR": {"France"},
+	"GA": {"Gabon"},
+	"GB": {"United Kingdom", "UK", "Great Britain", "England", "United Kingdom of Great Britain and Northern Ireland"},
+	"GD": {"Grenada"},
+	"GE": {"Georgia"},
+	"GF": {"French Guiana"},
+	"GG": {"Guernsey"},
+	"GH": {"Ghana"},
+	"GI": {"Gibraltar"},
+	"GL": {"Greenland"},
+	"GM": {"Gambia", "The Gambia"},
+	"GN": {"Guinea"},
+	"GP": {"Guadeloupe"},
+	"GQ": {"Equatorial Guinea"},
+	"GR": {"Greece"},
+	"GS": {"South Georgia and the South Sandwich Islands"},
+	"GT": {"Guatemala"},
+	"GU": {"Guam"},
+	"GW": {"Guinea-Bissau"},
+	"GY": {"Guyana"},
+	"HK": {"Hong Kong"},
+	"HM": {"Heard Island and McDonald Islands"},
+	"HN": {"Honduras"},
+	"HR": {"Croatia"},
+	"HT": {"Haiti"},
+	"HU": {"Hungary"},
+	"ID": {"Indonesia"},
+	"IE": {"Ireland"},
+	"IL": {"Israel"},
+	"IM": {"Isle of Man"},
+	"IN": {"India"},
+	"IO": {"British Indian Ocean Territory"},
+	"IQ": {"Iraq"},
+	"IR": {"Iran", "Iran, Islamic Republic of"},
+	"IS": {"Iceland"},
+	"IT": {"Italy"},
+	"JE": {"Jersey"},
+	"JM": {"Jamaica"},
+	"JO": {"Jordan", "Hashemite Kingdom of Jordan"},
+	"JP": {"Japan"},
+	"KE": {"Kenya"},
+	"KG": {"Kyrgyzstan"},
+	"KH": {"Cambodia"},
+	"KI": {"Kiribati"},
+	"KM": {"Comoros"},
+	"KN": {"Saint Kitts and Nevis", "St Kitts and Nevis"},
+	"KP": {"North Korea", "Korea, Democratic People's Republic of"},
+	"KR": {"South Korea", "Korea", "Republic of Korea", "Korea, Republic of"},
+	"KW": {"Kuwait"},
+	"KY": {"Cayman Islands"},
+	"KZ": {"Kazakhstan"},
+	"LA": {"Laos", "Lao People's Democratic Republic"},
+	"LB": {"Lebanon"},
+	"LC": {"Saint Lucia", "St Lucia"},
+	"LI": {"Liechtenstein"},
+	"LK": {"Sri Lanka"},
+	"LR": {"Liberia"},
+	"LS": {"Lesotho"},
+	"LT": {"Lithuania", "Republic of Lithuania"},
+	"LU": {"Luxembourg"},
+	"LV": {"Latvia"},
+	"LY": {"Libya"},
+	"MA": {"Morocco"},
+	"MC": {"Monaco"},
+	"MD": {"Moldova", "Republic of Moldova", "Moldova, Republic of"},
+	"ME": {"Montenegro"},
+	"MF": {"Saint Martin"},
+	"MG": {"Madagascar"},
+	"MH": {"Marshall Islands"},
+	"MK": {"North Macedonia", "Macedonia"},
+	"ML": {"Mali"},
+	"MM": {"Myanmar", "Burma"},
+	"MN": {"Mongolia"},
+	"MO": {"Macao", "Macau"},
+	"MP": {"Northern Mariana Islands"},
+	"MQ": {"Martinique"},
+	"MR": {"Mauritania"},
+	"MS": {"Montserrat"},
+	"MT": {"Malta"},
+	"MU": {"Mauritius"},
+	"MV": {"Maldives"},
+	"MW": {"Malawi"},
+	"MX": {"Mexico"},
+	"MY": {"Malaysia"},
+	"MZ": {"Mozambique"},
+	"NA": {"Namibia"},
+	"NC": {"New Caledonia"},
+	"NE": {"Niger"},
+	"NF": {"Norfolk Island"},
+	"NG": {"Nigeria"},
+	"NI": {"Nicaragua"},
+	"NL": {"Netherlands", "The Netherlands", "Holland"},
+	"NO": {"Norway"},
+	"NP": {"Nepal"},
+	"NR": {"Nauru"},
+	"NU": {"Niue"},
+	"NZ": {"New Zealand"},
+	"OM": {"Oman"},
+	"PA": {"Panama"},
+	"PE": {"Peru"},
+	"PF": {"French Polynesia"},
+	"PG": {"Papua New Guinea"},
+	"PH": {"Philippines"},
+	"PK": {"Pakistan"},
+	"PL": {"Poland"},
+	"PM": {"Saint Pierre and Miquelon"},
+	"PN": {"Pitcairn", "Pitcairn Islands"},
+	"PR": {"Puerto Rico"},
+	"PS": {"Palestine", "State of Palestine", "Palestine, State of"},
+	"PT": {"Portugal"},
+	"PW": {"Palau"},
+	"PY": {"Paraguay"},
+	"QA": {"Qatar"},
+	"RE": {"Reunion", "Réunion"},
+	"RO": {"Romania"},
+	"RS": {"Serbia"},
+	"RU": {"Russia", "Russian Federation"},
+	"RW": {"Rwanda"},
+	"SA": {"Saudi Arabia"},
+	"SB": {"Solomon Islands"},
+	"SC": {"Seychelles"},
+	"SD": {"Sudan"},
+	"SE": {"Sweden"},
+	"SG": {"Singapore"},
+	"SH": {"Saint Helena", "Saint Helena, Ascension and Tristan da Cunha"},
+	"SI": {"Slovenia"},
+	"SJ": {"Svalbard and Jan Mayen"},
+	"SK": {"Slovakia"},
+	"SL": {"Sierra Leone"},
+	"SM": {"San Marino"},
+	"SN": {"Senegal"},
+	"SO": {"Somalia"},
+	"SR": {"Suriname"},
+	"SS": {"South Sudan"},
+	"ST": {"Sao Tome and Principe", "São Tomé and Príncipe"},
+	"SV": {"El Salvador"},
+	"SX": {"Sint Maarten"},
+	"SY": {"Syria", "Syrian Arab Republic"},
+	"SZ": {"Eswatini", "Swaziland"},
+	"TC": {"Turks and Caicos Islands"},
+	"TD": {"Chad"},
+	"TF": {"French Southern Territories"},
+	"TG": {"Togo"},
+	"TH": {"Thailand"},
+	"TJ": {"Tajikistan"},
+	"TK": {"Tokelau"},
+	"TL": {"Timor-Leste", "East Timor"},
+	"TM": {"Turkmenistan"},
+	"TN": {"Tunisia"},
+	"TO": {"Tonga"},
+	"TR": {"Turkey", "Türkiye", "Turkiye"},
+	"TT": {"Trinidad and Tobago"},
+	"TV": {"Tuvalu"},
+	"TW": {"Taiwan"},
+	"TZ": {"Tanzania", "Tanzania, United Republic of"},
+	"UA": {"Ukraine"},
+	"UG": {"Uganda"},
+	"UM": {"United States Minor Outlying Islands", "U.S. Minor Outlying Islands"},
+	"US": {"United States", "United States of America", "USA", "U.S.A."},
+	"UY": {"Uruguay"},
+	"UZ": {"Uzbekistan"},
+	"VA": {"Vatican City", "Holy See", "Holy See (Vatican City State)"},
+	"VC": {"Saint Vincent and the Grenadines"},
+	"VE": {"Venezuela", "Venezuela, Bolivarian Republic of"},
+	"VG": {"British Virgin Islands", "Virgin Islands, British"},
+	"VI": {"U.S. Virgin Islands", "Virgin Islands, U.S.", "United States Virgin Islands"},
+	"VN": {"Vietnam", "Viet Nam"},
+	"VU": {"Vanuatu"},
+	"WF": {"Wallis and Futuna"},
+	"WS": {"Samoa"},
+	"XK": {"Kosovo"},
+	"YE": {"Yemen"},
+	"YT": {"Mayotte"},
+	"ZA": {"South Africa"},
+	"ZM": {"Zambia"},
+	"ZW": {"Zimbabwe"},
+}
+
+var countryCodes = func() map[string]string {
+	m := make(map[string]string)
+	for code, names := range countryNames {
+		for _, name := range names {
+			m[strings.ToLower(name)] = code
+		}
+	}
+
+	return m
+}()
+
+// CountryCode normalizes a country code or a country name to its ISO 3166-1
+// alpha-2 code. It returns an empty string for unknown values.
+func CountryCode(v string) string {
+	v = strings.TrimSpace(v)
+	if code := strings.ToUpper(v); len(code) == 2 {
+		if _, ok := countryNames[code]; ok {
+			return code
+		}
+	}
+
+	return countryCodes[strings.ToLower(v)]
+}
+
+// CountryNames returns the values a node may report for the given code,
+// including the code itself.
+func CountryNames(code string) []string {
+	return append([]string{code}, countryNames[code]...)
+}
+
+// CountryPattern returns an anchored regular expression that matches any of
+// the values returned by CountryNames.
+func CountryPattern(code string) string {
+	names := CountryNames(code)
+	for i := range names {
+		names[i] = regexp.QuoteMeta(names[i])
+	}
+
+	return "^(?:" + strings.Join(names, "|") + ")$"
+}
@@ -2,28 +2,46 @@ package node
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"syscall"
 	"time"
 )
 
+const (
+	ErrorClassConnectionRefused = "connection_refused"
+	ErrorClassDNS               = "dns"
+	ErrorClassInvalidResponse   = "invalid_response"
+	ErrorClassOther             = "other"
+	ErrorClassTimeout           = "timeout"
+	ErrorClassTLS               = "tls"
+)
+
 type (
 	HandshakeDNS struct {
 		Enable bool   `json:"enable,omitempty" bson:"enable"`
 		Peers  uint64 `json:"peers,omitempty" bson:"peers"`
 	}
 	Health struct {
-		ClientConfig            []byte    `json:"client_config,omitempty" bson:"client_config"`
-		ConfigExchangeError     string    `json:"config_exchange_error,omitempty" bson:"config_exchange_error"`
-		ConfigExchangeTimestamp time.Time `json:"config_exchange_timestamp,omitempty" bson:"config_exchange_timestamp"`
-		LocationFetchError      string    `json:"location_fetch_error,omitempty" bson:"location_fetch_error"`
-		LocationFetchTimestamp  time.Time `json:"location_fetch_timestamp,omitempty" bson:"location_fetch_timestamp"`
-		ServerConfig            []byte    `json:"server_config,omitempty" bson:"server_config"`
-		SessionID               uint64    `json:"session_id,omitempty" bson:"session_id"`
-		StatusFetchError        string    `json:"status_fetch_error,omitempty" bson:"status_fetch_error"`
-		StatusFetchTimestamp    time.Time `json:"status_fetch_timestamp,omitempty" bson:"status_fetch_timestamp"`
-		SubscriptionID          uint64    `json:"subscription_id,omitempty" bson:"subscription_id"`
+		CertificateFetchError     string    `json:"certificate_fetch_error,omitempty" bson:"certificate_fetch_error"`
+		CertificateFetchTimestamp time.Time `json:"certificate_fetch_timestamp,omitempty" bson:"certificate_fetch_timestamp"`
+		ClientConfig              []byte    `json:"client_config,omitempty" bson:"client_config"`
+		ConfigExchangeError       string    `json:"config_exchange_error,omitempty" bson:"config_exchange_error"`
+		ConfigExchangeTimestamp   time.Time `json:"config_exchange_timestamp,omitempty" bson:"config_exchange_timestamp"`
+		LocationFetchError        string    `json:"location_fetch_error,omitempty" bson:"location_fetch_error"`
+		LocationFetchTimestamp    time.Time `json:"location_fetch_timestamp,omitempty" bson:"location_fetch_timestamp"`
+		ServerConfig              []byte    `json:"server_config,omitempty" bson:"server_config"`
+		SessionID                 uint64    `json:"session_id,omitempty" bson:"session_id"`
+		SpeedTestError            string    `json:"speed_test_error,omitempty" bson:"speed_test_error"`
+		SpeedTestTimestamp        time.Time `json:"speed_test_timestamp,omitempty" bson:"speed_test_timestamp"`
+		StatusFetchError          string    `json:"status_fetch_error,omitempty" bson:"status_fetch_error"`
+		StatusFetchTimestamp      time.Time `json:"status_fetch_timestamp,omitempty" bson:"status_fetch_timestamp"`
+		SubscriptionID            uint64    `json:"subscription_id,omitempty" bson:"subscription_id"`
 	}
 	Location struct {
 		City      string  `json:"city,omitempty" bson:"city"`
@@ -34,6 +52,19 @@ type (
 	QOS struct {
 		MaxPeers int `json:"max_peers,omitempty" bson:"max_peers"`
 	}
+	VerifiedLocation struct {
+		ASN            uint64  `json:"asn,omitempty" bson:"asn"`
+		ASOrganization string  `json:"as_organization,omitempty" bson:"as_organization"`
+		City           string  `json:"city,omitempty" bson:"city"`
+		CityMatch      *bool   `json:"city_match,omitempty" bson:"city_match,omitempty"`
+		Country        string  `json:"country,omitempty" bson:"country"`
+		CountryCode    string  `json:"country_code,omitempty" bson:"country_code"`
+		CountryMatch   *bool   `json:"country_match,omitempty" bson:"country_match,omitempty"`
+		Distance       float64 `json:"distance,omitempty" bson:"distance"`
+		IP             string  `json:"ip,omitempty" bson:"ip"`
+		Latitude       float64 `json:"latitude,omitempty" bson:"latitude"`
+		Longitude      float64 `json:"longitude,omitempty" bson:"longitude"`
+	}
 )
 
 type Info struct {
@@ -92,3 +123,31 @@ func FetchNewInfo(remoteURL string, timeout time.Duration) (*Info, error) {
 
 	return &v, nil
 }
+
+func ErrorClass(err error) string {
+	var (
+		dnsErr           *net.DNSError
+		netErr           net.Error
+		recordHeaderErr  tls.RecordHeaderError
+		syntaxErr        *json.SyntaxError
+		unknownAuthErr   x509.UnknownAuthorityError
+		certInvalidErr   x509.CertificateInvalidError
+		hostnameErr      x509.HostnameError
+		unmarshalTypeErr *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassConnectionRefused
+	case errors.As(err, &recordHeaderErr), errors.As(err, &unknownAuthErr), errors.As(err, &certInvalidErr), errors.As(err, &hostnameErr):
+		return ErrorClassTLS
+	case errors.As(err, &syntaxErr), errors.As(err, &unmarshalTypeErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorClassInvalidResponse
+	default:
+		return ErrorClassOther
+	}
+}
@@ -0,0 +1,176 @@
+package node
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"time"
+)
+
+type (
+	LatencyStats struct {
+		Avg float64 `json:"avg" bson:"avg"`
+		Max float64 `json:"max" bson:"max"`
+		Min float64 `json:"min" bson:"min"`
+	}
+	Latency struct {
+		RTT     LatencyStats `json:"rtt" bson:"rtt"`
+		Samples int          `json:"samples" bson:"samples"`
+		TTFB    LatencyStats `json:"ttfb" bson:"ttfb"`
+	}
+	// Throughput is measured by fetching a payload from the node, so Upload
+	// is the rate at which the node sends data and verifies its claimed
+	// internet_speed.upload.
+	Throughput struct {
+		Bytes    int64   `json:"bytes" bson:"bytes"`
+		Duration float64 `json:"duration" bson:"duration"`
+		Upload   int64   `json:"upload" bson:"upload"`
+		URL      string  `json:"url,omitempty" bson:"url"`
+	}
+	MeasuredSpeed struct {
+		Latency             *Latency    `json:"latency,omitempty" bson:"latency,omitempty"`
+		LatencyTimestamp    time.Time   `json:"latency_timestamp,omitempty" bson:"latency_timestamp"`
+		Throughput          *Throughput `json:"throughput,omitempty" bson:"throughput,omitempty"`
+		ThroughputTimestamp time.Time   `json:"throughput_timestamp,omitempty" bson:"throughput_timestamp"`
+	}
+)
+
+func NewLatencyStats(values []time.Duration) LatencyStats {
+	var v LatencyStats
+	for i, d := range values {
+		ms := float64(d) / float64(time.Millisecond)
+		if i == 0 || ms < v.Min {
+			v.Min = ms
+		}
+		if ms > v.Max {
+			v.Max = ms
+		}
+
+		v.Avg = v.Avg + ms/float64(len(values))
+	}
+
+	return v
+}
+
+func newMeasureClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+		Timeout: timeout,
+	}
+}
+
+func MeasureLatency(ctx context.Context, remoteURL string, samples int, timeout time.Duration) (*Latency, error) {
+	urlPath, err := url.JoinPath(remoteURL, "status")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		client = newMeasureClient(timeout)
+		rtts   []time.Duration
+		ttfbs  []time.Duration
+	)
+
+	for i := 0; i < samples; i++ {
+		var connectStart, connectDone, start, firstByte time.Time
+
+		trace := &httptrace.ClientTrace{
+			ConnectStart:         func(_, _ string) { connectStart = time.Now() },
+			ConnectDone:          func(_, _ string, _ error) { connectDone = time.Now() },
+			GotFirstResponseByte: func() { firstByte = time.Now() },
+		}
+
+		req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, urlPath, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		start = time.Now()
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		if !connectStart.IsZero() && !connectDone.IsZero() {
+			rtts = append(rtts, connectDone.Sub(connectStart))
+		}
+		if !firstByte.IsZero() {
+			ttfbs = append(ttfbs, firstByte.Sub(start))
+		}
+	}
+
+	if len(ttfbs) == 0 {
+		return nil, errors.New("no latency samples")
+	}
+
+	return &Latency{
+		RTT:     NewLatencyStats(rtts),
+		Samples: len(ttfbs),
+		TTFB:    NewLatencyStats(ttfbs),
+	}, nil
+}
+
+func MeasureThroughput(ctx context.Context, remoteURL, path string, minBytes, maxBytes int64, timeout time.Duration) (*Throughput, error) {
+	if path == "" {
+		return nil, errors.New("throughput path is required")
+	}
+
+	urlPath, err := url.JoinPath(remoteURL, path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := newMeasureClient(timeout)
+
+	start := time.Now()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Since(start)
+	if duration <= 0 || n == 0 {
+		return nil, errors.New("no bytes received")
+	}
+	if n < minBytes {
+		return nil, fmt.Errorf("received %d bytes, want at least %d", n, minBytes)
+	}
+
+	return &Throughput{
+		Bytes:    n,
+		Duration: duration.Seconds(),
+		Upload:   int64(float64(n) / duration.Seconds()),
+		URL:      urlPath,
+	}, nil
+}
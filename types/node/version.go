@@ -0,0 +1,58 @@
+package node
+
+import (
+	"strconv"
+	"strings"
+)
+
+func TypeString(v uint64) string {
+	switch v {
+	case TypeWireGuard:
+		return "wireguard"
+	case TypeV2Ray:
+		return "v2ray"
+	default:
+		return "unknown"
+	}
+}
+
+func versionParts(v string) []int64 {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	var items []int64
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			break
+		}
+
+		items = append(items, n)
+	}
+
+	return items
+}
+
+func CompareVersions(a, b string) int {
+	x, y := versionParts(a), versionParts(b)
+	for i := 0; i < len(x) || i < len(y); i++ {
+		var m, n int64
+		if i < len(x) {
+			m = x[i]
+		}
+		if i < len(y) {
+			n = y[i]
+		}
+
+		if m < n {
+			return -1
+		}
+		if m > n {
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -1,38 +1,44 @@
 package types
 
 const (
-	StatisticTypeActiveNode          = "active_node"
-	StatisticTypeActiveSession       = "active_session"
-	StatisticTypeActiveSubscription  = "active_subscription"
-	StatisticTypeBytesPayment        = "bytes_payment"
-	StatisticTypeBytesStakingReward  = "bytes_staking_reward"
-	StatisticTypeBytesSubscription   = "bytes_subscription"
-	StatisticTypeEndSession          = "end_session"
-	StatisticTypeEndSubscription     = "end_subscription"
-	StatisticTypeHoursPayment        = "hours_payment"
-	StatisticTypeHoursStakingReward  = "hours_staking_reward"
-	StatisticTypeHoursSubscription   = "hours_subscription"
-	StatisticTypePlanPayment         = "plan_payment"
-	StatisticTypePlanStakingReward   = "plan_staking_reward"
-	StatisticTypePlanSubscription    = "plan_subscription"
-	StatisticTypeRegisterNode        = "register_node"
-	StatisticTypeSessionAddress      = "session_address"
-	StatisticTypeSessionBytes        = "session_bytes"
-	StatisticTypeSessionDuration     = "session_duration"
-	StatisticTypeSessionNode         = "session_node"
-	StatisticTypeStartSession        = "start_session"
-	StatisticTypeStartSubscription   = "start_subscription"
-	StatisticTypeSubscriptionBytes   = "subscription_bytes"
-	StatisticTypeSubscriptionDeposit = "subscription_deposit"
-	StatisticTypeSubscriptionHours   = "subscription_hours"
-	StatisticTypeSubscriptionRefund  = "subscription_refund"
+	StatisticTypeActiveNode                      = "active_node"
+	StatisticTypeActiveSession                   = "active_session"
+	StatisticTypeActiveSubscription              = "active_subscription"
+	StatisticTypeBytesPayment                    = "bytes_payment"
+	StatisticTypeBytesStakingReward              = "bytes_staking_reward"
+	StatisticTypeBytesSubscription               = "bytes_subscription"
+	StatisticTypeEndSession                      = "end_session"
+	StatisticTypeEndSubscription                 = "end_subscription"
+	StatisticTypeHoursPayment                    = "hours_payment"
+	StatisticTypeHoursStakingReward              = "hours_staking_reward"
+	StatisticTypeHoursSubscription               = "hours_subscription"
+	StatisticTypePlanPayment                     = "plan_payment"
+	StatisticTypePlanStakingReward               = "plan_staking_reward"
+	StatisticTypePlanSubscription                = "plan_subscription"
+	StatisticTypeRegisterNode                    = "register_node"
+	StatisticTypeSessionAddress                  = "session_address"
+	StatisticTypeSessionBytes                    = "session_bytes"
+	StatisticTypeSessionBytesDistribution        = "session_bytes_distribution"
+	StatisticTypeSessionDuration                 = "session_duration"
+	StatisticTypeSessionDurationDistribution     = "session_duration_distribution"
+	StatisticTypeSessionNode                     = "session_node"
+	StatisticTypeSessionPaymentDistribution      = "session_payment_distribution"
+	StatisticTypeSessionRatingDistribution       = "session_rating_distribution"
+	StatisticTypeStartSession                    = "start_session"
+	StatisticTypeStartSubscription               = "start_subscription"
+	StatisticTypeSubscriptionBytes               = "subscription_bytes"
+	StatisticTypeSubscriptionDeposit             = "subscription_deposit"
+	StatisticTypeSubscriptionDepositDistribution = "subscription_deposit_distribution"
+	StatisticTypeSubscriptionHours               = "subscription_hours"
+	StatisticTypeSubscriptionRefund              = "subscription_refund"
 )
 
 const (
-	StatisticMethodCurrentNodeCount           = "CurrentNodeCount"
-	StatisticMethodCurrentSessionAddressCount = "CurrentSessionAddressCount"
-	StatisticMethodCurrentSessionCount        = "CurrentSessionCount"
-	StatisticMethodCurrentSessionNodeCount    = "CurrentSessionNodeCount"
+	StatisticMethodCurrentDistinctOperatorCount = "CurrentDistinctOperatorCount"
+	StatisticMethodCurrentNodeCount             = "CurrentNodeCount"
+	StatisticMethodCurrentSessionAddressCount   = "CurrentSessionAddressCount"
+	StatisticMethodCurrentSessionCount          = "CurrentSessionCount"
+	StatisticMethodCurrentSessionNodeCount      = "CurrentSessionNodeCount"
 
 	StatisticMethodHistoricalBytesEarning        = "HistoricalBytesEarning"
 	StatisticMethodHistoricalBytesPayment        = "HistoricalBytesPayment"
@@ -102,6 +108,15 @@ const (
 	StatisticMethodHistoricalSubscriptionHours       = "HistoricalSubscriptionHours"
 	StatisticMethodHistoricalSubscriptionRefund      = "HistoricalSubscriptionRefund"
 
+	StatisticMethodCohortNewReturning = "CohortNewReturning"
+	StatisticMethodCohortRetention    = "CohortRetention"
+
+	StatisticMethodDistributionSessionBytes        = "DistributionSessionBytes"
+	StatisticMethodDistributionSessionDuration     = "DistributionSessionDuration"
+	StatisticMethodDistributionSessionPayment      = "DistributionSessionPayment"
+	StatisticMethodDistributionSessionRating       = "DistributionSessionRating"
+	StatisticMethodDistributionSubscriptionDeposit = "DistributionSubscriptionDeposit"
+
 	StatisticMethodTotalBytesSubscriptionCount = "TotalBytesSubscriptionCount"
 	StatisticMethodTotalEndSessionCount        = "TotalEndSessionCount"
 	StatisticMethodTotalEndSubscriptionCount   = "TotalEndSubscriptionCount"
@@ -116,3 +131,115 @@ const (
 	StatisticMethodTotalSubscriptionHours      = "TotalSubscriptionHours"
 	StatisticMethodTotalSubscriptionRefund     = "TotalSubscriptionRefund"
 )
+
+const (
+	StatisticAggregationAvg  = "avg"
+	StatisticAggregationLast = "last"
+	StatisticAggregationMax  = "max"
+	StatisticAggregationMin  = "min"
+	StatisticAggregationSum  = "sum"
+)
+
+const (
+	StatisticValueKindAmount     = "amount"
+	StatisticValueKindBandwidth  = "bandwidth"
+	StatisticValueKindCoins      = "coins"
+	StatisticValueKindHistogram  = "histogram"
+	StatisticValueKindHistograms = "histograms"
+	StatisticValueKindNumber     = "number"
+)
+
+type StatisticQuery struct {
+	Type        string
+	Aggregation string
+}
+
+var (
+	StatisticTypeValueKinds = map[string]string{
+		StatisticTypeActiveNode:                      StatisticValueKindNumber,
+		StatisticTypeActiveSession:                   StatisticValueKindNumber,
+		StatisticTypeActiveSubscription:              StatisticValueKindNumber,
+		StatisticTypeBytesPayment:                    StatisticValueKindCoins,
+		StatisticTypeBytesStakingReward:              StatisticValueKindCoins,
+		StatisticTypeBytesSubscription:               StatisticValueKindNumber,
+		StatisticTypeEndSession:                      StatisticValueKindNumber,
+		StatisticTypeEndSubscription:                 StatisticValueKindNumber,
+		StatisticTypeHoursPayment:                    StatisticValueKindCoins,
+		StatisticTypeHoursStakingReward:              StatisticValueKindCoins,
+		StatisticTypeHoursSubscription:               StatisticValueKindNumber,
+		StatisticTypePlanPayment:                     StatisticValueKindCoins,
+		StatisticTypePlanStakingReward:               StatisticValueKindCoins,
+		StatisticTypePlanSubscription:                StatisticValueKindNumber,
+		StatisticTypeRegisterNode:                    StatisticValueKindNumber,
+		StatisticTypeSessionAddress:                  StatisticValueKindNumber,
+		StatisticTypeSessionBytes:                    StatisticValueKindBandwidth,
+		StatisticTypeSessionBytesDistribution:        StatisticValueKindHistogram,
+		StatisticTypeSessionDuration:                 StatisticValueKindNumber,
+		StatisticTypeSessionDurationDistribution:     StatisticValueKindHistogram,
+		StatisticTypeSessionNode:                     StatisticValueKindNumber,
+		StatisticTypeSessionPaymentDistribution:      StatisticValueKindHistograms,
+		StatisticTypeSessionRatingDistribution:       StatisticValueKindHistogram,
+		StatisticTypeStartSession:                    StatisticValueKindNumber,
+		StatisticTypeStartSubscription:               StatisticValueKindNumber,
+		StatisticTypeSubscriptionBytes:               StatisticValueKindAmount,
+		StatisticTypeSubscriptionDeposit:             StatisticValueKindCoins,
+		StatisticTypeSubscriptionDepositDistribution: StatisticValueKindHistograms,
+		StatisticTypeSubscriptionHours:               StatisticValueKindNumber,
+		StatisticTypeSubscriptionRefund:              StatisticValueKindCoins,
+	}
+
+	StatisticValueKindFields = map[string][]string{
+		StatisticValueKindBandwidth:  {"download", "upload"},
+		StatisticValueKindHistogram:  {"count", "max", "min", "p50", "p90", "p99"},
+		StatisticValueKindHistograms: {"count", "max", "min", "p50", "p90", "p99"},
+	}
+
+	StatisticMethodAliases = map[string]StatisticQuery{
+		StatisticMethodAverageActiveNodeCount:            {StatisticTypeActiveNode, StatisticAggregationAvg},
+		StatisticMethodAverageActiveSessionCount:         {StatisticTypeActiveSession, StatisticAggregationAvg},
+		StatisticMethodAverageActiveSubscriptionCount:    {StatisticTypeActiveSubscription, StatisticAggregationAvg},
+		StatisticMethodAverageBytesPayment:               {StatisticTypeBytesPayment, StatisticAggregationAvg},
+		StatisticMethodAverageBytesStakingReward:         {StatisticTypeBytesStakingReward, StatisticAggregationAvg},
+		StatisticMethodAverageEndSessionCount:            {StatisticTypeEndSession, StatisticAggregationAvg},
+		StatisticMethodAverageEndSubscriptionCount:       {StatisticTypeEndSubscription, StatisticAggregationAvg},
+		StatisticMethodAveragePlanPayment:                {StatisticTypePlanPayment, StatisticAggregationAvg},
+		StatisticMethodAveragePlanStakingReward:          {StatisticTypePlanStakingReward, StatisticAggregationAvg},
+		StatisticMethodAverageRegisterNodeCount:          {StatisticTypeRegisterNode, StatisticAggregationAvg},
+		StatisticMethodAverageStartSessionCount:          {StatisticTypeStartSession, StatisticAggregationAvg},
+		StatisticMethodAverageStartSubscriptionCount:     {StatisticTypeStartSubscription, StatisticAggregationAvg},
+		StatisticMethodAverageSubscriptionDeposit:        {StatisticTypeSubscriptionDeposit, StatisticAggregationAvg},
+		StatisticMethodDistributionSessionBytes:          {StatisticTypeSessionBytesDistribution, ""},
+		StatisticMethodDistributionSessionDuration:       {StatisticTypeSessionDurationDistribution, ""},
+		StatisticMethodDistributionSessionPayment:        {StatisticTypeSessionPaymentDistribution, ""},
+		StatisticMethodDistributionSessionRating:         {StatisticTypeSessionRatingDistribution, ""},
+		StatisticMethodDistributionSubscriptionDeposit:   {StatisticTypeSubscriptionDepositDistribution, ""},
+		StatisticMethodHistoricalActiveNodeCount:         {StatisticTypeActiveNode, ""},
+		StatisticMethodHistoricalActiveSessionCount:      {StatisticTypeActiveSession, ""},
+		StatisticMethodHistoricalActiveSubscriptionCount: {StatisticTypeActiveSubscription, ""},
+		StatisticMethodHistoricalBytesPayment:            {StatisticTypeBytesPayment, ""},
+		StatisticMethodHistoricalBytesStakingReward:      {StatisticTypeBytesStakingReward, ""},
+		StatisticMethodHistoricalEndSessionCount:         {StatisticTypeEndSession, ""},
+		StatisticMethodHistoricalEndSubscriptionCount:    {StatisticTypeEndSubscription, ""},
+		StatisticMethodHistoricalHoursPayment:            {StatisticTypeHoursPayment, ""},
+		StatisticMethodHistoricalHoursStakingReward:      {StatisticTypeHoursStakingReward, ""},
+		StatisticMethodHistoricalPlanPayment:             {StatisticTypePlanPayment, ""},
+		StatisticMethodHistoricalPlanStakingReward:       {StatisticTypePlanStakingReward, ""},
+		StatisticMethodHistoricalRegisterNodeCount:       {StatisticTypeRegisterNode, ""},
+		StatisticMethodHistoricalSessionAddressCount:     {StatisticTypeSessionAddress, ""},
+		StatisticMethodHistoricalSessionBytes:            {StatisticTypeSessionBytes, ""},
+		StatisticMethodHistoricalSessionDuration:         {StatisticTypeSessionDuration, ""},
+		StatisticMethodHistoricalSessionNodeCount:        {StatisticTypeSessionNode, ""},
+		StatisticMethodHistoricalStartSessionCount:       {StatisticTypeStartSession, ""},
+		StatisticMethodHistoricalStartSubscriptionCount:  {StatisticTypeStartSubscription, ""},
+		StatisticMethodHistoricalSubscriptionDeposit:     {StatisticTypeSubscriptionDeposit, ""},
+		StatisticMethodTotalBytesPayment:                 {StatisticTypeBytesPayment, StatisticAggregationSum},
+		StatisticMethodTotalBytesStakingReward:           {StatisticTypeBytesStakingReward, StatisticAggregationSum},
+		StatisticMethodTotalHoursPayment:                 {StatisticTypeHoursPayment, StatisticAggregationSum},
+		StatisticMethodTotalHoursStakingReward:           {StatisticTypeHoursStakingReward, StatisticAggregationSum},
+		StatisticMethodTotalPlanPayment:                  {StatisticTypePlanPayment, StatisticAggregationSum},
+		StatisticMethodTotalPlanStakingReward:            {StatisticTypePlanStakingReward, StatisticAggregationSum},
+		StatisticMethodTotalSessionBytes:                 {StatisticTypeSessionBytes, StatisticAggregationSum},
+		StatisticMethodTotalSessionDuration:              {StatisticTypeSessionDuration, StatisticAggregationSum},
+		StatisticMethodTotalSubscriptionDeposit:          {StatisticTypeSubscriptionDeposit, StatisticAggregationSum},
+	}
+)
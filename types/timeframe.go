@@ -0,0 +1,476 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/sentinel-official/explorer/utils"
+)
+
+func RollingTimeframeDays(timeframe string) int {
+	switch timeframe {
+	case "7d":
+		return 7
+	case "30d":
+		return 30
+	default:
+		return 0
+	}
+}
+
+func BucketTimeframe(timeframe string) string {
+	if RollingTimeframeDays(timeframe) != 0 {
+		return "day"
+	}
+
+	return timeframe
+}
+
+func TimezoneFilter(tz string) interface{} {
+	if tz == "" || tz == time.UTC.String() {
+		return bson.M{
+			"$exists": false,
+		}
+	}
+
+	return tz
+}
+
+type Timezones map[string]*time.Location
+
+func NewTimezones(locations []*time.Location) Timezones {
+	v := make(Timezones)
+	for _, loc := range locations {
+		v[loc.String()] = loc
+	}
+
+	return v
+}
+
+func (t Timezones) Location(name string) (*time.Location, error) {
+	if name == "" {
+		name = time.UTC.String()
+	}
+
+	loc, ok := t[name]
+	if !ok {
+		return nil, fmt.Errorf("timezone %s is not supported", name)
+	}
+
+	return loc, nil
+}
+
+// TimeframeLocation returns the location of the timezone name for statistics of
+// the given timeframe. Hourly buckets are only computed in UTC.
+func (t Timezones) TimeframeLocation(timeframe, name string) (*time.Location, error) {
+	loc, err := t.Location(name)
+	if err != nil {
+		return nil, err
+	}
+	if timeframe == "hour" && loc != time.UTC {
+		return nil, fmt.Errorf("timeframe hour is not supported for timezone %s", loc)
+	}
+
+	return loc, nil
+}
+
+func NewRollingStatistics(items []bson.M, days int, fromTimestamp, toTimestamp time.Time, loc *time.Location, keys ...string) []bson.M {
+	if len(items) == 0 || days <= 0 {
+		return nil
+	}
+
+	var (
+		buckets      = make(map[time.Time]bson.M)
+		minTimestamp time.Time
+		maxTimestamp time.Time
+	)
+
+	for _, item := range items {
+		t := utils.DayDate(TimeFromInterface(item["timestamp"]).In(loc))
+		buckets[t] = item
+
+		if minTimestamp.IsZero() || t.Before(minTimestamp) {
+			minTimestamp = t
+		}
+		if t.After(maxTimestamp) {
+			maxTimestamp = t
+		}
+	}
+
+	startTimestamp := utils.DayDate(fromTimestamp.In(loc))
+	if startTimestamp.Before(fromTimestamp) {
+		startTimestamp = startTimestamp.AddDate(0, 0, 1)
+	}
+	if startTimestamp.Before(minTimestamp) {
+		startTimestamp = minTimestamp
+	}
+
+	var result []bson.M
+	for t := startTimestamp; !t.After(maxTimestamp) && t.Before(toTimestamp); t = t.AddDate(0, 0, 1) {
+		res := bson.M{
+			"timestamp": t,
+		}
+
+		covered := 0
+		for i := 0; i < days && !t.AddDate(0, 0, -i).Before(minTimestamp); i++ {
+			covered++
+		}
+
+		for _, key := range keys {
+			var values []interface{}
+			for i := 0; i < covered; i++ {
+				item, ok := buckets[t.AddDate(0, 0, -i)]
+				if !ok || item[key] == nil {
+					continue
+				}
+
+				values = append(values, item[key])
+			}
+
+			if len(values) == 0 {
+				continue
+			}
+
+			res[key] = averageValue(values, int64(covered))
+		}
+
+		if len(res) == 1 {
+			continue
+		}
+
+		result = append(result, res)
+	}
+
+	return result
+}
+
+func SortStatistics(items []bson.M, d bson.D) {
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, e := range d {
+			c := compareValues(items[i][e.Key], items[j][e.Key])
+			if c == 0 {
+				continue
+			}
+			if e.Value == -1 {
+				return c > 0
+			}
+
+			return c < 0
+		}
+
+		return false
+	})
+}
+
+func PaginateStatistics(items []bson.M, skip, limit int64) []bson.M {
+	if skip >= int64(len(items)) {
+		return []bson.M{}
+	}
+
+	items = items[skip:]
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+func float64FromInterface(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func compareValues(a, b interface{}) int {
+	if a, ok := a.(time.Time); ok {
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+	}
+
+	x, ok := float64FromInterface(a)
+	if !ok {
+		return 0
+	}
+
+	y, ok := float64FromInterface(b)
+	if !ok {
+		return 0
+	}
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func averageValue(values []interface{}, n int64) interface{} {
+	switch values[0].(type) {
+	case int32, int64, float64:
+		var sum float64
+		for _, v := range values {
+			f, _ := float64FromInterface(v)
+			sum = sum + f
+		}
+
+		return sum / float64(n)
+	case string:
+		sum := sdk.ZeroInt()
+		for _, v := range values {
+			sum = sum.Add(utils.MustIntFromString(StringFromInterface(v)))
+		}
+
+		return sum.QuoRaw(n).String()
+	case bson.M:
+		children := make(map[string][]interface{})
+		for _, v := range values {
+			for key, child := range v.(bson.M) {
+				children[key] = append(children[key], child)
+			}
+		}
+
+		res := bson.M{}
+		for key := range children {
+			res[key] = averageValue(children[key], n)
+		}
+
+		return res
+	case bson.A:
+		coins := NewCoins(nil)
+		for _, v := range values {
+			for _, c := range v.(bson.A) {
+				coin := c.(bson.M)
+				coins = coins.Add(
+					&Coin{
+						Denom:  StringFromInterface(coin["denom"]),
+						Amount: StringFromInterface(coin["amount"]),
+					},
+				)
+			}
+		}
+
+		for i := 0; i < coins.Len(); i++ {
+			coins[i].Amount = utils.MustIntFromString(coins[i].Amount).QuoRaw(n).String()
+		}
+
+		return coins
+	default:
+		return nil
+	}
+}
+
+func numberFromInterface(v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+
+	return float64FromInterface(v)
+}
+
+func statisticValueDelta(current, previous interface{}) (absolute, percent interface{}, ok bool) {
+	if x, ok := numberFromInterface(current); ok {
+		y, ok := numberFromInterface(previous)
+		if !ok {
+			return nil, nil, false
+		}
+		if y != 0 {
+			percent = (x - y) / y * 100
+		}
+
+		return x - y, percent, true
+	}
+
+	switch current := current.(type) {
+	case bson.M:
+		previous, _ := previous.(bson.M)
+
+		absolutes, percents := bson.M{}, bson.M{}
+		for key := range current {
+			if a, p, ok := statisticValueDelta(current[key], previous[key]); ok {
+				absolutes[key], percents[key] = a, p
+			}
+		}
+		if len(absolutes) == 0 {
+			return nil, nil, false
+		}
+
+		return absolutes, percents, true
+	case bson.A:
+		previous, _ := previous.(bson.A)
+
+		denoms := make(map[string]bson.M)
+		for _, item := range previous {
+			if item, ok := item.(bson.M); ok {
+				denoms[StringFromInterface(item["denom"])] = item
+			}
+		}
+
+		absolutes, percents := bson.A{}, bson.A{}
+		for _, item := range current {
+			item, ok := item.(bson.M)
+			if !ok {
+				continue
+			}
+
+			denom := StringFromInterface(item["denom"])
+			prev, ok := denoms[denom]
+			if !ok {
+				prev = bson.M{}
+			}
+
+			a, p, ok := statisticValueDelta(withoutKey(item, "denom"), withoutKey(prev, "denom"))
+			if !ok {
+				continue
+			}
+
+			a.(bson.M)["denom"], p.(bson.M)["denom"] = denom, denom
+			absolutes, percents = append(absolutes, a), append(percents, p)
+		}
+		if len(absolutes) == 0 {
+			return nil, nil, false
+		}
+
+		return absolutes, percents, true
+	default:
+		return nil, nil, false
+	}
+}
+
+func withoutKey(v bson.M, key string) bson.M {
+	res := make(bson.M, len(v))
+	for k := range v {
+		if k != key {
+			res[k] = v[k]
+		}
+	}
+
+	return res
+}
+
+func NewStatisticDeltas(current, previous []bson.M, timeframe string, currentFrom, previousFrom time.Time, loc *time.Location) []bson.M {
+	var (
+		currentStart  = utils.TimeframeDate(timeframe, currentFrom.In(loc))
+		previousStart = utils.TimeframeDate(timeframe, previousFrom.In(loc))
+		ids           = make(map[string]bson.M)
+		offsets       = make(map[int]bson.M)
+	)
+
+	for _, item := range previous {
+		if id, ok := item["_id"]; ok {
+			ids[fmt.Sprint(id)] = item
+			continue
+		}
+
+		t := utils.TimeframeDate(timeframe, TimeFromInterface(item["timestamp"]).In(loc))
+		offsets[utils.TimeframeOffset(timeframe, previousStart, t)] = item
+	}
+
+	result := make([]bson.M, 0, len(current))
+	for _, item := range current {
+		res := bson.M{
+			"absolute": nil,
+			"percent":  nil,
+		}
+
+		var prev bson.M
+		if id, ok := item["_id"]; ok {
+			res["_id"], prev = id, ids[fmt.Sprint(id)]
+		} else {
+			res["timestamp"] = item["timestamp"]
+
+			t := utils.TimeframeDate(timeframe, TimeFromInterface(item["timestamp"]).In(loc))
+			prev = offsets[utils.TimeframeOffset(timeframe, currentStart, t)]
+		}
+
+		if prev != nil {
+			if a, p, ok := statisticValueDelta(item["value"], prev["value"]); ok {
+				res["absolute"], res["percent"] = a, p
+			}
+		}
+
+		result = append(result, res)
+	}
+
+	return result
+}
+
+func FillStatistics(items []bson.M, timeframe, fill string, fromTimestamp, toTimestamp time.Time, loc *time.Location, zeros bson.M) []bson.M {
+	buckets := make(map[int64]bson.M)
+	for _, item := range items {
+		buckets[TimeFromInterface(item["timestamp"]).Unix()] = item
+	}
+
+	if now := time.Now(); toTimestamp.After(now) {
+		toTimestamp = now
+	}
+
+	startTimestamp := utils.TimeframeDate(timeframe, fromTimestamp.In(loc))
+	if startTimestamp.Before(fromTimestamp) {
+		startTimestamp = utils.TimeframeAddDate(timeframe, startTimestamp, 1)
+	}
+
+	var (
+		previous = bson.M{}
+		result   []bson.M
+	)
+
+	for t := startTimestamp; t.Before(toTimestamp); t = utils.TimeframeAddDate(timeframe, t, 1) {
+		res, ok := buckets[t.Unix()]
+		if !ok {
+			res = bson.M{
+				"timestamp": t,
+			}
+		}
+
+		for key, zero := range zeros {
+			if v, ok := res[key]; ok {
+				previous[key] = v
+				continue
+			}
+
+			switch fill {
+			case "zero":
+				res[key] = zero
+			case "previous":
+				res[key] = previous[key]
+			default:
+				res[key] = nil
+			}
+		}
+
+		result = append(result, res)
+	}
+
+	return result
+}
+
+func ColumnarStatistics(items []bson.M, keys ...string) bson.M {
+	res := bson.M{}
+	for _, key := range keys {
+		values := make(bson.A, 0, len(items))
+		for _, item := range items {
+			values = append(values, item[key])
+		}
+
+		res[key] = values
+	}
+
+	return res
+}
@@ -1,9 +1,14 @@
 package utils
 
 import (
+	"strings"
 	"time"
 )
 
+func HourDate(v time.Time) time.Time {
+	return time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), 0, 0, 0, v.Location())
+}
+
 func DayDate(v time.Time) time.Time {
 	return time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, v.Location())
 }
@@ -24,3 +29,95 @@ func MonthDate(v time.Time) time.Time {
 func YearDate(v time.Time) time.Time {
 	return time.Date(v.Year(), 1, 1, 0, 0, 0, 0, v.Location())
 }
+
+func TimeframeDate(timeframe string, v time.Time) time.Time {
+	switch timeframe {
+	case "hour":
+		return HourDate(v)
+	case "day":
+		return DayDate(v)
+	case "week":
+		return ISOWeekDate(v)
+	case "month":
+		return MonthDate(v)
+	case "year":
+		return YearDate(v)
+	default:
+		return v
+	}
+}
+
+// HistoryTruncated reports whether history that starts at first has been
+// trimmed by a retention measured back from max. The first two days past the
+// retention boundary still count as trimmed, since expired documents are not
+// removed the moment they expire. A zero retention never trims history.
+func HistoryTruncated(first, max time.Time, retention time.Duration) bool {
+	if retention <= 0 {
+		return false
+	}
+
+	return first.Before(max.Add(-retention).Add(48 * time.Hour))
+}
+
+func ParseLocations(v string) ([]*time.Location, error) {
+	locations := []*time.Location{time.UTC}
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, err
+		}
+		if loc.String() == time.UTC.String() {
+			continue
+		}
+
+		locations = append(locations, loc)
+	}
+
+	return locations, nil
+}
+
+func TimeframeAddDate(timeframe string, v time.Time, n int) time.Time {
+	switch timeframe {
+	case "hour":
+		return v.Add(time.Duration(n) * time.Hour)
+	case "day":
+		return v.AddDate(0, 0, n)
+	case "week":
+		return v.AddDate(0, 0, 7*n)
+	case "month":
+		return v.AddDate(0, n, 0)
+	case "year":
+		return v.AddDate(n, 0, 0)
+	default:
+		return v
+	}
+}
+
+func TimeframeOffset(timeframe string, from, to time.Time) int {
+	days := func() int {
+		a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+		b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+		return int(b.Sub(a) / (24 * time.Hour))
+	}
+
+	switch timeframe {
+	case "hour":
+		return int(to.Sub(from) / time.Hour)
+	case "day":
+		return days()
+	case "week":
+		return days() / 7
+	case "month":
+		return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+	case "year":
+		return to.Year() - from.Year()
+	default:
+		return 0
+	}
+}